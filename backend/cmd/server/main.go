@@ -1,10 +1,17 @@
 package main
 
 import (
+	// Embedded so imported calendars' time zones resolve on hosts without
+	// zoneinfo, such as the alpine image.
+	_ "time/tzdata"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/routes"
 	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -16,28 +23,53 @@ func main() {
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:5174", "https://slot-swapper-peer-to-peer.vercel.app"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Device-Name", "X-API-Key", "X-Time-Zone"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After"},
 		AllowCredentials: true,
 	}))
 
 	cfg := config.LoadConfig()
 	port := cfg.PORT
 
+	// Gin trusts every proxy by default, which would let clients pick their
+	// own IP with X-Forwarded-For and dodge the per-IP sign-in limits.
+	if err := r.SetTrustedProxies(cfg.TRUSTED_PROXIES); err != nil {
+		logger.Error("Invalid TRUSTED_PROXIES: " + err.Error())
+		panic(err)
+	}
+
 	_, err := db.ConnectDB(cfg)
 	if err != nil {
 		logger.Error("Failed to connect to database: " + err.Error())
 		panic(err)
 	}
 
+	if err := services.PromoteAdmins(cfg.ADMIN_EMAILS); err != nil {
+		logger.Error("Failed to promote configured admins: " + err.Error())
+	}
+
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		logger.Error("Failed to set up mailer: " + err.Error())
+		panic(err)
+	}
+
+	guard := loginguard.New(services.NewLoginAttemptStore(), loginguard.PolicyFromConfig(cfg))
+
+	stopSweeper := services.StartSwapExpirySweeper(cfg.SWAP_EXPIRY_SWEEP_INTERVAL)
+	defer stopSweeper()
+
+	stopExtender := services.StartEventSeriesExtender(cfg.RECURRENCE_HORIZON, cfg.RECURRENCE_EXTEND_INTERVAL)
+	defer stopExtender()
+
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "pong",
 		})
 	})
 
-	routes.SetUpRoutes(r, cfg)
+	routes.SetUpRoutes(r, cfg, mail, guard)
 
 	r.Run(":" + port)
 }
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/gin-gonic/gin"
+)
+
+// writeAdminError maps admin service errors to status codes.
+func writeAdminError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrSwapRequestNotFound),
+		errors.Is(err, services.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDisableOwnAccount), errors.Is(err, services.ErrChangeOwnRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSwapRequestNotPending), errors.Is(err, services.ErrEventHeldBySwap):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		logger.Error(fallback + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// bindAdminReason reads the optional reason body; an empty body is allowed.
+func bindAdminReason(c *gin.Context) (string, bool) {
+	var input models.AdminReasonInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			if !writeValidationError(c, err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			}
+			return "", false
+		}
+	}
+	return strings.TrimSpace(input.Reason), true
+}
+
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func AdminListUsersHandler(c *gin.Context) {
+	search := models.UserSearch{
+		Query: c.Query("q"),
+		Role:  models.Role(strings.ToUpper(c.Query("role"))),
+	}
+	search.Page, _ = strconv.Atoi(c.Query("page"))
+	search.Limit, _ = strconv.Atoi(c.Query("limit"))
+	if disabled := c.Query("disabled"); disabled != "" {
+		value, err := strconv.ParseBool(disabled)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "disabled must be true or false"})
+			return
+		}
+		search.Disabled = &value
+	}
+
+	users, total, err := services.SearchUsers(search)
+	if err != nil {
+		writeAdminError(c, err, "Failed to list users")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    users,
+		"total":   total,
+		"message": "Users retrieved successfully",
+	})
+}
+
+func adminSetUserDisabled(c *gin.Context, disabled bool) {
+	actorID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	targetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	reason, ok := bindAdminReason(c)
+	if !ok {
+		return
+	}
+
+	user, err := services.SetUserDisabled(actorID.(uint), targetID, disabled, reason)
+	if err != nil {
+		writeAdminError(c, err, "Failed to update account")
+		return
+	}
+
+	message := "Account enabled"
+	if disabled {
+		message = "Account disabled"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user,
+		"message": message,
+	})
+}
+
+func AdminDisableUserHandler(c *gin.Context) {
+	adminSetUserDisabled(c, true)
+}
+
+func AdminEnableUserHandler(c *gin.Context) {
+	adminSetUserDisabled(c, false)
+}
+
+func AdminUnlockUserHandler(c *gin.Context, guard *loginguard.Guard) {
+	actorID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	targetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	reason, ok := bindAdminReason(c)
+	if !ok {
+		return
+	}
+
+	user, err := services.AdminUnlockUser(actorID.(uint), targetID, reason, guard)
+	if err != nil {
+		writeAdminError(c, err, "Failed to unlock account")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user,
+		"message": "Account unlocked",
+	})
+}
+
+func AdminSetUserRoleHandler(c *gin.Context) {
+	actorID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	targetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input models.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	user, err := services.SetUserRole(actorID.(uint), targetID, input.Role)
+	if err != nil {
+		writeAdminError(c, err, "Failed to change role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user,
+		"message": "Role updated",
+	})
+}
+
+func AdminCancelSwapRequestHandler(c *gin.Context) {
+	actorID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	requestID, ok := parseIDParam(c, "requestId")
+	if !ok {
+		return
+	}
+	reason, ok := bindAdminReason(c)
+	if !ok {
+		return
+	}
+
+	request, err := services.AdminCancelSwapRequest(actorID.(uint), requestID, reason)
+	if err != nil {
+		writeAdminError(c, err, "Failed to cancel swap request")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    request,
+		"message": "Swap request cancelled",
+	})
+}
+
+func AdminResetEventStatusHandler(c *gin.Context) {
+	actorID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	eventID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var input models.EventStatusResetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	event, err := services.AdminResetEventStatus(actorID.(uint), eventID, input.Status, strings.TrimSpace(input.Reason))
+	if err != nil {
+		writeAdminError(c, err, "Failed to reset event status")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    event,
+		"message": "Event status reset",
+	})
+}
+
+func AdminAuditLogHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	targetID, _ := strconv.ParseUint(c.Query("target_id"), 10, 32)
+
+	entries, total, err := services.GetAdminAuditLog(c.Query("target_type"), uint(targetID), page, limit)
+	if err != nil {
+		writeAdminError(c, err, "Failed to retrieve audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+		"total":   total,
+		"message": "Audit log retrieved successfully",
+	})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateAPIKeyHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	apiKey, key, err := services.CreateAPIKey(userID.(uint), &input)
+	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTooManyAPIKeys) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to create API key: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    gin.H{"apiKey": apiKey, "key": key},
+		"message": "API key created; copy it now, it will not be shown again",
+	})
+}
+
+func GetAPIKeysHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	keys, err := services.ListAPIKeys(userID.(uint))
+	if err != nil {
+		logger.Error("Failed to list API keys: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    keys,
+		"message": "API keys retrieved successfully",
+	})
+}
+
+func RevokeAPIKeyHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	keyID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := services.RevokeAPIKey(userID.(uint), keyID); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to revoke API key: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "API key revoked",
+	})
+}
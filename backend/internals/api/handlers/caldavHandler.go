@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/dav"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// The CalDAV tree: the principal is always the signed-in user, whose one
+// calendar holds their events.
+const (
+	davRootPath      = "/dav/"
+	davPrincipalPath = "/dav/principal/"
+	davHomePath      = "/dav/calendars/"
+	davCalendarPath  = "/dav/calendars/events/"
+
+	davAllow                  = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+	davXMLContentType         = "application/xml; charset=utf-8"
+	calendarObjectContentType = "text/calendar; charset=utf-8; component=vevent"
+)
+
+var (
+	davResourceType = dav.Name(dav.NSDAV, "resourcetype")
+	davCalendarData = dav.Name(dav.NSCalDAV, "calendar-data")
+)
+
+func hrefXML(href string) string {
+	var b strings.Builder
+	b.WriteString("<d:href>")
+	xml.EscapeText(&b, []byte(href))
+	b.WriteString("</d:href>")
+	return b.String()
+}
+
+// davObjectName returns the calendar object an escaped path or href points
+// at. Names are unescaped, so UIDs holding a slash still fit in one segment.
+func davObjectName(href string) (string, bool) {
+	if u, err := url.Parse(href); err == nil {
+		href = u.EscapedPath()
+	}
+	escaped, ok := strings.CutPrefix(href, davCalendarPath)
+	if !ok || escaped == "" || strings.Contains(escaped, "/") {
+		return "", false
+	}
+	name, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+func davObjectHref(name string) string {
+	return davCalendarPath + url.PathEscape(name)
+}
+
+// davCollectionPath is the request path with the trailing slash collections
+// are named with.
+func davCollectionPath(c *gin.Context) string {
+	path := c.Request.URL.EscapedPath()
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	return path
+}
+
+// davCanWrite reports whether the request may change events; requests made
+// with a read-only API key may not.
+func davCanWrite(c *gin.Context) bool {
+	scopes, ok := c.Get("api_key_scopes")
+	return !ok || services.APIKeyAllows(scopes.(string), "events:write")
+}
+
+func davCollectionProps(c *gin.Context, path string, resources []services.CalDAVResource) []dav.Prop {
+	props := []dav.Prop{{Name: dav.Name(dav.NSDAV, "current-user-principal"), XML: hrefXML(davPrincipalPath)}}
+	switch path {
+	case davRootPath, davHomePath:
+		props = append(props, dav.Prop{Name: davResourceType, XML: "<d:collection/>"})
+	case davCalendarPath:
+		privileges := "<d:privilege><d:read/></d:privilege>"
+		if davCanWrite(c) {
+			privileges += "<d:privilege><d:write/></d:privilege><d:privilege><d:write-content/></d:privilege>" +
+				"<d:privilege><d:bind/></d:privilege><d:privilege><d:unbind/></d:privilege>"
+		}
+		props = append(props,
+			dav.Prop{Name: davResourceType, XML: "<d:collection/><c:calendar/>"},
+			dav.Prop{Name: dav.Name(dav.NSDAV, "displayname"), Text: "SlotSwapper"},
+			dav.Prop{Name: dav.Name(dav.NSDAV, "owner"), XML: hrefXML(davPrincipalPath)},
+			dav.Prop{Name: dav.Name(dav.NSDAV, "current-user-privilege-set"), XML: privileges},
+			dav.Prop{Name: dav.Name(dav.NSDAV, "supported-report-set"), XML: "" +
+				"<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+				"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>"},
+			dav.Prop{Name: dav.Name(dav.NSCalDAV, "supported-calendar-component-set"), XML: `<c:comp name="VEVENT"/>`},
+			dav.Prop{Name: dav.Name(dav.NSCalendarServer, "getctag"), Text: services.CalDAVCollectionTag(resources)},
+		)
+	}
+	return props
+}
+
+func davPrincipalProps(c *gin.Context) ([]dav.Prop, error) {
+	user, err := services.GetEmailByID(c.GetUint("user_id"))
+	if err != nil {
+		return nil, err
+	}
+	return []dav.Prop{
+		{Name: dav.Name(dav.NSDAV, "current-user-principal"), XML: hrefXML(davPrincipalPath)},
+		{Name: davResourceType, XML: "<d:collection/><d:principal/>"},
+		{Name: dav.Name(dav.NSDAV, "displayname"), Text: user.Name},
+		{Name: dav.Name(dav.NSDAV, "principal-URL"), XML: hrefXML(davPrincipalPath)},
+		{Name: dav.Name(dav.NSCalDAV, "calendar-home-set"), XML: hrefXML(davHomePath)},
+		{Name: dav.Name(dav.NSCalDAV, "calendar-user-address-set"), XML: hrefXML("mailto:" + user.Email)},
+	}, nil
+}
+
+// davObjectProps lists a calendar object's properties. The calendar data is
+// only offered to REPORTs, as PROPFIND allprop leaves it out.
+func davObjectProps(resource *services.CalDAVResource, withData bool) []dav.Prop {
+	props := []dav.Prop{
+		{Name: davResourceType},
+		{Name: dav.Name(dav.NSDAV, "getetag"), Text: resource.ETag},
+		{Name: dav.Name(dav.NSDAV, "getcontenttype"), Text: calendarObjectContentType},
+		{Name: dav.Name(dav.NSDAV, "getcontentlength"), Text: strconv.Itoa(len(resource.Data))},
+	}
+	if withData {
+		props = append(props, dav.Prop{Name: davCalendarData, Text: string(resource.Data)})
+	}
+	return props
+}
+
+// davResponse answers for one resource with the properties requested, or
+// with all of them when requested is nil. namesOnly leaves out the values,
+// for PROPFIND propname.
+func davResponse(href string, available []dav.Prop, requested []xml.Name, namesOnly bool) dav.Response {
+	response := dav.Response{Href: href}
+	if requested == nil {
+		for _, prop := range available {
+			if namesOnly {
+				prop = dav.Prop{Name: prop.Name}
+			}
+			response.Found = append(response.Found, prop)
+		}
+		return response
+	}
+
+	for _, name := range requested {
+		found := false
+		for _, prop := range available {
+			if prop.Name == name {
+				response.Found = append(response.Found, prop)
+				found = true
+				break
+			}
+		}
+		if !found {
+			response.NotFound = append(response.NotFound, name)
+		}
+	}
+	return response
+}
+
+// writeCalDAVError maps CalDAV service errors to status codes. Refusals,
+// such as edits to an event in a pending swap, are 403 with the reason as
+// plain text, which calendar clients show to the user.
+func writeCalDAVError(c *gin.Context, err error, fallback string) {
+	var tooLarge *http.MaxBytesError
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.String(http.StatusRequestEntityTooLarge, "Calendar object is too large")
+	case errors.Is(err, services.ErrPreconditionFailed):
+		c.String(http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, services.ErrInvalidCalendar):
+		c.Data(http.StatusForbidden, davXMLContentType, dav.Error(dav.Name(dav.NSCalDAV, "valid-calendar-data")))
+	case errors.Is(err, services.ErrRecurringResource), errors.Is(err, services.ErrEventSwapPending),
+		errors.Is(err, services.ErrDeleteSwapPending):
+		c.String(http.StatusForbidden, err.Error())
+	case errors.As(err, &validationErr):
+		c.String(http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrEventNotFound), errors.Is(err, services.ErrUserNotFound):
+		c.String(http.StatusNotFound, err.Error())
+	default:
+		logger.Error(fallback + ": " + err.Error())
+		c.String(http.StatusInternalServerError, fallback)
+	}
+}
+
+func davConditions(c *gin.Context) services.CalDAVConditions {
+	return services.CalDAVConditions{
+		IfMatch:     c.GetHeader("If-Match"),
+		IfNoneMatch: c.GetHeader("If-None-Match"),
+	}
+}
+
+// CalDAVWellKnownHandler points clients that discover the server by its
+// host name at the CalDAV root.
+func CalDAVWellKnownHandler(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, davRootPath)
+}
+
+func CalDAVOptionsHandler(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", davAllow)
+	c.Status(http.StatusOK)
+}
+
+func CalDAVPropfindHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	propfind, err := dav.ParsePropfind(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	requested := propfind.Props
+	if propfind.AllProp || propfind.PropName {
+		requested = nil
+	}
+	withChildren := c.GetHeader("Depth") != "0"
+
+	var responses []dav.Response
+	if name, ok := davObjectName(c.Request.URL.EscapedPath()); ok {
+		resource, err := services.GetCalDAVResource(userID.(uint), name)
+		if err != nil {
+			writeCalDAVError(c, err, "Failed to read event")
+			return
+		}
+		responses = append(responses, davResponse(davObjectHref(resource.Name), davObjectProps(resource, false), requested, propfind.PropName))
+	} else {
+		path := davCollectionPath(c)
+		switch path {
+		case davRootPath:
+			responses = append(responses, davResponse(path, davCollectionProps(c, path, nil), requested, propfind.PropName))
+		case davPrincipalPath:
+			props, err := davPrincipalProps(c)
+			if err != nil {
+				writeCalDAVError(c, err, "Failed to read user")
+				return
+			}
+			responses = append(responses, davResponse(path, props, requested, propfind.PropName))
+		case davHomePath, davCalendarPath:
+			resources, err := services.ListCalDAVResources(userID.(uint), time.Time{}, time.Time{})
+			if err != nil {
+				writeCalDAVError(c, err, "Failed to list events")
+				return
+			}
+			responses = append(responses, davResponse(path, davCollectionProps(c, path, resources), requested, propfind.PropName))
+			switch {
+			case path == davHomePath && withChildren:
+				responses = append(responses, davResponse(davCalendarPath, davCollectionProps(c, davCalendarPath, resources), requested, propfind.PropName))
+			case path == davCalendarPath && withChildren:
+				for i := range resources {
+					responses = append(responses, davResponse(davObjectHref(resources[i].Name), davObjectProps(&resources[i], false), requested, propfind.PropName))
+				}
+			}
+		default:
+			c.String(http.StatusNotFound, "Not found")
+			return
+		}
+	}
+
+	c.Data(http.StatusMultiStatus, davXMLContentType, dav.Multistatus(responses))
+}
+
+// CalDAVReportHandler answers calendar-query, optionally limited to a
+// time-range, and calendar-multiget on the calendar collection.
+func CalDAVReportHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	report, err := dav.ParseReport(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if davCollectionPath(c) != davCalendarPath {
+		c.Data(http.StatusForbidden, davXMLContentType, dav.Error(dav.Name(dav.NSDAV, "supported-report")))
+		return
+	}
+
+	var responses []dav.Response
+	switch report.Name {
+	case dav.Name(dav.NSCalDAV, "calendar-query"):
+		resources, err := services.ListCalDAVResources(userID.(uint), report.Start, report.End)
+		if err != nil {
+			writeCalDAVError(c, err, "Failed to list events")
+			return
+		}
+		for i := range resources {
+			responses = append(responses, davResponse(davObjectHref(resources[i].Name), davObjectProps(&resources[i], true), report.Props, false))
+		}
+	case dav.Name(dav.NSCalDAV, "calendar-multiget"):
+		for _, href := range report.Hrefs {
+			name, ok := davObjectName(href)
+			if !ok {
+				responses = append(responses, dav.Response{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			resource, err := services.GetCalDAVResource(userID.(uint), name)
+			if err != nil {
+				if !errors.Is(err, services.ErrEventNotFound) {
+					writeCalDAVError(c, err, "Failed to read events")
+					return
+				}
+				responses = append(responses, dav.Response{Href: href, Status: http.StatusNotFound})
+				continue
+			}
+			responses = append(responses, davResponse(href, davObjectProps(resource, true), report.Props, false))
+		}
+	default:
+		c.Data(http.StatusForbidden, davXMLContentType, dav.Error(dav.Name(dav.NSDAV, "supported-report")))
+		return
+	}
+
+	c.Data(http.StatusMultiStatus, davXMLContentType, dav.Multistatus(responses))
+}
+
+// CalDAVGetHandler serves one calendar object, or the whole calendar when the
+// collection itself is fetched.
+func CalDAVGetHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	name, ok := davObjectName(c.Request.URL.EscapedPath())
+	if !ok {
+		if davCollectionPath(c) != davCalendarPath {
+			c.String(http.StatusNotFound, "Not found")
+			return
+		}
+		body, err := services.ExportUserCalendar(userID.(uint))
+		if err != nil {
+			writeCalDAVError(c, err, "Failed to export calendar")
+			return
+		}
+		c.Data(http.StatusOK, calendarContentType, body)
+		return
+	}
+
+	resource, err := services.GetCalDAVResource(userID.(uint), name)
+	if err != nil {
+		writeCalDAVError(c, err, "Failed to read event")
+		return
+	}
+
+	c.Header("ETag", resource.ETag)
+	if c.GetHeader("If-None-Match") == resource.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, calendarObjectContentType, resource.Data)
+}
+
+// CalDAVPutHandler creates or changes an event from a calendar object. The
+// object's UID must match its name, as calendar clients name objects.
+func CalDAVPutHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	name, ok := davObjectName(c.Request.URL.EscapedPath())
+	if !ok {
+		c.Header("Allow", "OPTIONS, GET, HEAD, PROPFIND, REPORT")
+		c.String(http.StatusMethodNotAllowed, "Only calendar objects can be written")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalendarImportSize)
+	resource, created, err := services.PutCalDAVResource(userID.(uint), name, c.Request.Body, davConditions(c), services.EventLimitsFromConfig(cfg))
+	if err != nil {
+		writeCalDAVError(c, err, "Failed to save event")
+		return
+	}
+
+	c.Header("ETag", resource.ETag)
+	if created {
+		c.Status(http.StatusCreated)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func CalDAVDeleteHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.String(http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	name, ok := davObjectName(c.Request.URL.EscapedPath())
+	if !ok {
+		c.Header("Allow", "OPTIONS, GET, HEAD, PROPFIND, REPORT")
+		c.String(http.StatusMethodNotAllowed, "Only calendar objects can be deleted")
+		return
+	}
+
+	if err := services.DeleteCalDAVResource(userID.(uint), name, davConditions(c)); err != nil {
+		writeCalDAVError(c, err, "Failed to delete event")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// maxCalendarImportSize bounds an uploaded .ics file.
+const maxCalendarImportSize = 2 << 20
+
+// requestBaseURL is the scheme and host the client used to reach the API,
+// honouring a TLS-terminating proxy.
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func ExportEventsICSHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	body, err := services.ExportUserCalendar(userID.(uint))
+	if err != nil {
+		logger.Error("Failed to export calendar: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export calendar"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="slotswapper.ics"`)
+	c.Data(http.StatusOK, calendarContentType, body)
+}
+
+// ImportEventsHandler imports an .ics file sent either as the "file" field of
+// a multipart form or as the raw request body.
+func ImportEventsHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var teamID *uint
+	if raw := c.Query("team_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team_id"})
+			return
+		}
+		value := uint(id)
+		teamID = &value
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalendarImportSize)
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeImportError(c, err)
+			} else {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "An .ics file is required in the file field"})
+			}
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			writeImportError(c, err)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	report, err := services.ImportCalendar(userID.(uint), body, teamID, services.EventLimitsFromConfig(cfg), cfg.RECURRENCE_HORIZON)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+		"message": "Calendar imported",
+	})
+}
+
+func writeImportError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Calendar file is too large"})
+	case errors.Is(err, services.ErrInvalidCalendar):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case writeValidationError(c, err):
+	default:
+		logger.Error("Failed to import calendar: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import calendar"})
+	}
+}
+
+func CreateCalendarFeedHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token, err := services.CreateCalendarFeedToken(userID.(uint))
+	if err != nil {
+		logger.Error("Failed to create calendar feed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    gin.H{"url": requestBaseURL(c) + "/api/calendar/feeds/" + token + ".ics"},
+		"message": "Calendar feed created; this URL is only shown once",
+	})
+}
+
+func GetCalendarFeedHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	feed, err := services.GetCalendarFeedToken(userID.(uint))
+	if err != nil {
+		if errors.Is(err, services.ErrCalendarFeedNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to retrieve calendar feed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feed,
+		"message": "Calendar feed retrieved successfully",
+	})
+}
+
+func RevokeCalendarFeedHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := services.RevokeCalendarFeedToken(userID.(uint)); err != nil {
+		if errors.Is(err, services.ErrCalendarFeedNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to revoke calendar feed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke calendar feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Calendar feed revoked",
+	})
+}
+
+// CalendarFeedHandler serves a subscribed calendar. The token in the URL is
+// the only credential, so it works in calendar apps that cannot sign in.
+func CalendarFeedHandler(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	body, err := services.CalendarFeed(token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidFeedToken) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to render calendar feed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render calendar feed"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, calendarContentType, body)
+}
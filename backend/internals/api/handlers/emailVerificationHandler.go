@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	"github.com/gin-gonic/gin"
+)
+
+func VerifyEmailHandler(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	user, err := services.VerifyEmail(token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to verify email: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"email": user.Email, "emailVerifiedAt": user.EmailVerifiedAt},
+		"message": "Email verified successfully",
+	})
+}
+
+func ResendVerificationHandler(c *gin.Context, cfg *config.Config, m mailer.Mailer) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := services.ResendEmailVerification(userID.(uint), cfg, m); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to resend verification email: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Verification email sent",
+	})
+}
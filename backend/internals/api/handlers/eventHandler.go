@@ -1,17 +1,85 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
 	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
-func CreateEventHandler(c *gin.Context) {
+// writeValidationError answers with 400 and per-field messages when err is a
+// binding or service validation error, and reports whether it did so.
+func writeValidationError(c *gin.Context, err error) bool {
+	var fields []services.FieldError
+
+	var validationErr *services.ValidationError
+	var bindingErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &validationErr):
+		fields = validationErr.Fields
+	case errors.As(err, &bindingErrs):
+		for _, fieldErr := range bindingErrs {
+			name := fieldErr.Field()
+			fields = append(fields, services.FieldError{
+				Field:   strings.ToLower(name[:1]) + name[1:],
+				Message: "failed on the '" + fieldErr.Tag() + "' rule",
+			})
+		}
+	default:
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "validation failed",
+		"fields": fields,
+	})
+	return true
+}
+
+// parseTimeQuery reads an optional RFC3339 query parameter. A missing one
+// gives the zero time.
+func parseTimeQuery(c *gin.Context, name string) (time.Time, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return time.Time{}, true
+	}
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + ", expected RFC3339"})
+		return time.Time{}, false
+	}
+	return value, true
+}
+
+// requestLocation reads the time zone the caller wants times shown in, from
+// the tz query parameter or else the X-Time-Zone header. It gives nil when
+// neither is set, leaving times in UTC.
+func requestLocation(c *gin.Context) (*time.Location, bool) {
+	name := c.Query("tz")
+	if name == "" {
+		name = c.GetHeader("X-Time-Zone")
+	}
+	if name == "" {
+		return nil, true
+	}
+	loc, err := services.LoadTimeZone(name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone, expected an IANA name such as Europe/Berlin"})
+		return nil, false
+	}
+	return loc, true
+}
+
+func CreateEventHandler(c *gin.Context, cfg *config.Config) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		logger.Error("User ID not found in context")
@@ -19,21 +87,34 @@ func CreateEventHandler(c *gin.Context) {
 		return
 	}
 
-	var input models.Event
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	var input models.CreateEventInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		logger.Error("Failed to bind JSON for event creation: " + err.Error())
+		if writeValidationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	input.OwnerID = userID.(uint)
-
-	event, err := services.CreateEvent(&input)
+	event, err := services.CreateEvent(userID.(uint), &input, services.EventLimitsFromConfig(cfg))
 	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if loc != nil {
+		services.LocalizeEvent(event, loc)
+	}
+
 	logger.Info("Event created successfully")
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -50,11 +131,27 @@ func GetUserEventsHandler(c *gin.Context) {
 		return
 	}
 
-	events, err := services.GetUserEvents(userID.(uint))
+	from, ok := parseTimeQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseTimeQuery(c, "to")
+	if !ok {
+		return
+	}
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	events, err := services.GetUserEventsBetween(userID.(uint), from, to)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if loc != nil {
+		services.LocalizeEvents(events, loc)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -63,7 +160,7 @@ func GetUserEventsHandler(c *gin.Context) {
 	})
 }
 
-func UpdateEventHandler(c *gin.Context) {
+func UpdateEventHandler(c *gin.Context, cfg *config.Config) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		logger.Error("User ID not found in context")
@@ -79,6 +176,11 @@ func UpdateEventHandler(c *gin.Context) {
 		return
 	}
 
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
 	var input models.UpdateEventInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		logger.Error("Failed to bind JSON for event update: " + err.Error())
@@ -88,12 +190,19 @@ func UpdateEventHandler(c *gin.Context) {
 
 	logger.Info(fmt.Sprintf("Received update request for event %s with input: %+v", eventIDStr, input))
 
-	event, err := services.UpdateEventPartial(uint(eventID), userID.(uint), &input)
+	event, err := services.UpdateEventPartial(uint(eventID), userID.(uint), &input, services.EventLimitsFromConfig(cfg))
 	if err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if loc != nil {
+		services.LocalizeEvent(event, loc)
+	}
+
 	logger.Info("Event updated successfully")
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -139,11 +248,28 @@ func GetSwappableSlotsHandler(c *gin.Context) {
 		return
 	}
 
-	slots, err := services.GetSwappableSlots(userID.(uint))
+	var teamID uint
+	if raw := c.Query("team_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team_id"})
+			return
+		}
+		teamID = uint(id)
+	}
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	slots, err := services.GetSwappableSlots(userID.(uint), teamID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if loc != nil {
+		services.LocalizeEvents(slots, loc)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -151,3 +277,76 @@ func GetSwappableSlotsHandler(c *gin.Context) {
 		"message": "Swappable slots retrieved successfully",
 	})
 }
+
+func GetEventHistoryHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	eventIDStr := c.Param("id")
+	eventID, err := strconv.ParseUint(eventIDStr, 10, 32)
+	if err != nil {
+		logger.Error("Invalid event ID: " + eventIDStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	history, err := services.GetEventOwnershipHistory(uint(eventID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    history,
+		"message": "Event history retrieved successfully",
+	})
+}
+
+func GetEventConflictsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var eventID *uint
+	if eventIDStr := c.Query("event_id"); eventIDStr != "" {
+		parsed, err := strconv.ParseUint(eventIDStr, 10, 32)
+		if err != nil {
+			logger.Error("Invalid event ID: " + eventIDStr)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+			return
+		}
+		id := uint(parsed)
+		eventID = &id
+	}
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	conflicts, err := services.GetEventConflicts(userID.(uint), eventID)
+	if err != nil {
+		if errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if loc != nil {
+		services.LocalizeConflicts(conflicts, loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    conflicts,
+		"message": "Event conflicts retrieved successfully",
+	})
+}
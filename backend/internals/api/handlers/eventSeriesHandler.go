@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// writeEventSeriesError maps event series service errors to status codes.
+func writeEventSeriesError(c *gin.Context, err error, fallback string) {
+	if writeValidationError(c, err) {
+		return
+	}
+	if errors.Is(err, services.ErrEventSeriesNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Error(fallback + ": " + err.Error())
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
+func CreateEventSeriesHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	var input models.EventSeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	series, err := services.CreateEventSeries(userID.(uint), &input, services.EventLimitsFromConfig(cfg), cfg.RECURRENCE_HORIZON)
+	if err != nil {
+		writeEventSeriesError(c, err, "Failed to create event series")
+		return
+	}
+	if loc != nil {
+		services.LocalizeEventSeries(series, loc)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    series,
+		"message": "Event series created successfully",
+	})
+}
+
+func GetEventSeriesListHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	series, err := services.GetUserEventSeries(userID.(uint))
+	if err != nil {
+		writeEventSeriesError(c, err, "Failed to retrieve event series")
+		return
+	}
+	if loc != nil {
+		for i := range series {
+			services.LocalizeEventSeries(&series[i], loc)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+		"message": "Event series retrieved successfully",
+	})
+}
+
+func GetEventSeriesHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	seriesID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	series, err := services.GetEventSeries(userID.(uint), seriesID)
+	if err != nil {
+		writeEventSeriesError(c, err, "Failed to retrieve event series")
+		return
+	}
+	if loc != nil {
+		services.LocalizeEventSeries(series, loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+		"message": "Event series retrieved successfully",
+	})
+}
+
+func UpdateEventSeriesHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	seriesID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateEventSeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	series, err := services.UpdateEventSeries(userID.(uint), seriesID, &input, services.EventLimitsFromConfig(cfg), cfg.RECURRENCE_HORIZON)
+	if err != nil {
+		writeEventSeriesError(c, err, "Failed to update event series")
+		return
+	}
+	if loc != nil {
+		services.LocalizeEventSeries(series, loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+		"message": "Event series updated successfully",
+	})
+}
+
+func DeleteEventSeriesHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	seriesID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteEventSeries(userID.(uint), seriesID); err != nil {
+		writeEventSeriesError(c, err, "Failed to delete event series")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Event series deleted successfully",
+	})
+}
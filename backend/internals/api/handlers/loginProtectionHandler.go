@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	"github.com/gin-gonic/gin"
+)
+
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// checkSignInAllowed refuses a sign-in step while the account or client IP
+// is backing off or locked out. It writes the response and returns false
+// when the attempt must not go ahead.
+func checkSignInAllowed(c *gin.Context, guard *loginguard.Guard, email string) bool {
+	decision, err := guard.Check(email, c.ClientIP())
+	if err != nil {
+		logger.Error("Failed to check sign-in throttling: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to sign in"})
+		return false
+	}
+	if decision.Allowed {
+		return true
+	}
+
+	seconds := retryAfterSeconds(decision.RetryAfter)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	if decision.Locked {
+		logger.Security("login_blocked", map[string]interface{}{
+			"email": email,
+			"ip":    c.ClientIP(),
+			"key":   decision.Key,
+		})
+	}
+
+	if decision.Locked && decision.Key == loginguard.AccountKey(email) {
+		c.JSON(http.StatusLocked, gin.H{
+			"error":       "account locked",
+			"retry_after": seconds,
+			"message":     "account locked after too many failed sign-in attempts; use the unlock link sent by email or try again later",
+		})
+		return false
+	}
+
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "too many failed sign-in attempts",
+		"retry_after": seconds,
+		"message":     "too many failed sign-in attempts; try again later",
+	})
+	return false
+}
+
+// recordSignInFailure counts a failed password or two-factor code. The
+// failure that locks an account mails its owner an unlock link.
+func recordSignInFailure(c *gin.Context, guard *loginguard.Guard, cfg *config.Config, m mailer.Mailer, email string) {
+	ip := c.ClientIP()
+	failure, err := guard.RecordFailure(email, ip)
+	if err != nil {
+		logger.Error("Failed to record failed sign-in: " + err.Error())
+		return
+	}
+
+	if failure.AccountLocked {
+		logger.Security("account_locked", map[string]interface{}{
+			"email":        email,
+			"ip":           ip,
+			"failures":     failure.AccountFailures,
+			"locked_until": time.Now().Add(failure.RetryAfter).UTC().Format(time.RFC3339),
+		})
+		if err := services.SendAccountUnlockEmail(email, cfg, m); err != nil {
+			logger.Error(fmt.Sprintf("Failed to send account unlock email: %s", err.Error()))
+		}
+	}
+	if failure.IPLocked {
+		logger.Security("ip_locked", map[string]interface{}{
+			"ip":         ip,
+			"last_email": email,
+		})
+	}
+}
+
+func UnlockAccountHandler(c *gin.Context, guard *loginguard.Guard) {
+	var input models.UnlockAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if _, err := services.UnlockAccount(input.Token, guard); err != nil {
+		if errors.Is(err, services.ErrInvalidUnlockToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to unlock account: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Account unlocked; you can sign in again",
+	})
+}
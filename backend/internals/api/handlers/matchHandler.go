@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateSwapWantHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.SwapWantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Failed to bind JSON for swap want: " + err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	want, err := services.CreateSwapWant(userID.(uint), &input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Swap want created successfully with ID: %d", want.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    want,
+		"message": "Swap want created successfully",
+	})
+}
+
+func GetSwapWantsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	wants, err := services.GetUserSwapWants(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    wants,
+		"message": "Swap wants retrieved successfully",
+	})
+}
+
+func DeleteSwapWantHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	wantIDStr := c.Param("id")
+	wantID, err := strconv.ParseUint(wantIDStr, 10, 32)
+	if err != nil {
+		logger.Error("Invalid want ID: " + wantIDStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid want ID"})
+		return
+	}
+
+	if err := services.DeleteSwapWant(uint(wantID), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Swap want deleted successfully")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Swap want deleted successfully",
+	})
+}
+
+func GetMatchesHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	matches, err := services.GetMatches(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    matches,
+		"message": "Matches retrieved successfully",
+	})
+}
+
+func AcceptMatchHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	acceptance, err := services.AcceptMatch(userID.(uint), c.Param("matchId"), cfg.SWAP_REQUEST_TTL)
+	if err != nil {
+		if writeConflictError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Match accepted successfully: " + c.Param("matchId"))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    acceptance,
+		"message": "Match accepted successfully",
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"github.com/gin-gonic/gin"
+)
+
+// writeMFAError maps the two-factor sentinel errors to responses and reports
+// whether it handled err.
+func writeMFAError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFAAlreadyEnabled), errors.Is(err, services.ErrMFANotEnabled), errors.Is(err, services.ErrMFAEnrollmentNotFound):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+func MFASignInHandler(c *gin.Context, cfg *config.Config, m mailer.Mailer, guard *loginguard.Guard) {
+	var input models.MFASignInInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := pkg.ValidateMFAToken(input.MFAToken, cfg)
+	if err != nil {
+		logger.Warn("Two-factor sign in failed: invalid mfa token - " + err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired mfa token"})
+		return
+	}
+
+	if !checkSignInAllowed(c, guard, claims.Email) {
+		return
+	}
+
+	user, err := services.VerifyMFASignIn(claims.UserID, input.Code)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMFACode) {
+			logger.Warn("Two-factor sign in failed: invalid code for email: " + claims.Email)
+			recordSignInFailure(c, guard, cfg, m, claims.Email)
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+			return
+		}
+		if writeMFAError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify two-factor code"})
+		return
+	}
+
+	completeSignIn(c, user, cfg, guard)
+}
+
+func BeginMFAEnrollmentHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	enrollment, err := services.BeginMFAEnrollment(userID.(uint), cfg.MFA_ISSUER)
+	if err != nil {
+		if writeMFAError(c, err) {
+			return
+		}
+		logger.Error("Failed to start two-factor enrolment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor enrolment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    enrollment,
+		"message": "Scan the URI with an authenticator app, then confirm with a code",
+	})
+}
+
+func ConfirmMFAEnrollmentHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := services.ConfirmMFAEnrollment(userID.(uint), input.Code)
+	if err != nil {
+		if writeMFAError(c, err) {
+			return
+		}
+		logger.Error("Failed to confirm two-factor enrolment: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm two-factor enrolment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"recovery_codes": codes},
+		"message": "Two-factor authentication enabled; store the recovery codes somewhere safe",
+	})
+}
+
+func DisableMFAHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.DisableMFA(userID.(uint), input.Code); err != nil {
+		if writeMFAError(c, err) {
+			return
+		}
+		logger.Error("Failed to disable two-factor authentication: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.MFACodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := services.RegenerateRecoveryCodes(userID.(uint), input.Code)
+	if err != nil {
+		if writeMFAError(c, err) {
+			return
+		}
+		logger.Error("Failed to regenerate recovery codes: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"recovery_codes": codes},
+		"message": "Recovery codes regenerated; earlier codes no longer work",
+	})
+}
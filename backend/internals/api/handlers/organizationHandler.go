@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	"github.com/gin-gonic/gin"
+)
+
+// writeOrganizationError maps organization service errors to status codes.
+func writeOrganizationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrNotOrganizationManager), errors.Is(err, services.ErrInvitationForOtherEmail):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOrganizationNotFound), errors.Is(err, services.ErrTeamNotFound),
+		errors.Is(err, services.ErrOrganizationMemberNotFound), errors.Is(err, services.ErrTeamMemberNotFound),
+		errors.Is(err, services.ErrInvitationNotFound), errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidInvitation), errors.Is(err, services.ErrLastOrganizationOwner),
+		errors.Is(err, services.ErrNotOrganizationMember):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyOrganizationMember):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		logger.Error(fallback + ": " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func CreateOrganizationHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.OrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	org, err := services.CreateOrganization(userID.(uint), input.Name)
+	if err != nil {
+		writeOrganizationError(c, err, "Failed to create organization")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    org,
+		"message": "Organization created successfully",
+	})
+}
+
+func GetOrganizationsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgs, err := services.GetUserOrganizations(userID.(uint))
+	if err != nil {
+		writeOrganizationError(c, err, "Failed to retrieve organizations")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    orgs,
+		"message": "Organizations retrieved successfully",
+	})
+}
+
+func GetOrganizationHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+
+	org, err := services.GetOrganization(userID.(uint), orgID)
+	if err != nil {
+		writeOrganizationError(c, err, "Failed to retrieve organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    org,
+		"message": "Organization retrieved successfully",
+	})
+}
+
+func RemoveOrganizationMemberHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	if err := services.RemoveOrganizationMember(userID.(uint), orgID, memberID); err != nil {
+		writeOrganizationError(c, err, "Failed to remove member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Member removed from organization",
+	})
+}
+
+func CreateTeamHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+
+	var input models.TeamInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	team, err := services.CreateTeam(userID.(uint), orgID, input.Name)
+	if err != nil {
+		writeOrganizationError(c, err, "Failed to create team")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    team,
+		"message": "Team created successfully",
+	})
+}
+
+func GetTeamsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+
+	teams, err := services.ListTeams(userID.(uint), orgID)
+	if err != nil {
+		writeOrganizationError(c, err, "Failed to retrieve teams")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    teams,
+		"message": "Teams retrieved successfully",
+	})
+}
+
+func AddTeamMemberHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+	teamID, ok := parseIDParam(c, "teamId")
+	if !ok {
+		return
+	}
+
+	var input models.TeamMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := services.AddTeamMember(userID.(uint), orgID, teamID, input.UserID); err != nil {
+		writeOrganizationError(c, err, "Failed to add team member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Member added to team",
+	})
+}
+
+func RemoveTeamMemberHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+	teamID, ok := parseIDParam(c, "teamId")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	if err := services.RemoveTeamMember(userID.(uint), orgID, teamID, memberID); err != nil {
+		writeOrganizationError(c, err, "Failed to remove team member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Member removed from team",
+	})
+}
+
+func CreateInvitationHandler(c *gin.Context, cfg *config.Config, m mailer.Mailer) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+
+	var input models.InvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	invitation, err := services.CreateInvitation(userID.(uint), orgID, &input, cfg, m)
+	if err != nil {
+		writeOrganizationError(c, err, "Failed to create invitation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    invitation,
+		"message": "Invitation sent",
+	})
+}
+
+func GetInvitationsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+
+	invitations, err := services.ListInvitations(userID.(uint), orgID)
+	if err != nil {
+		writeOrganizationError(c, err, "Failed to retrieve invitations")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    invitations,
+		"message": "Invitations retrieved successfully",
+	})
+}
+
+func RevokeInvitationHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orgID, ok := parseIDParam(c, "orgId")
+	if !ok {
+		return
+	}
+	invitationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := services.RevokeInvitation(userID.(uint), orgID, invitationID); err != nil {
+		writeOrganizationError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Invitation revoked",
+	})
+}
+
+func AcceptInvitationHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.AcceptInvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if !writeValidationError(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	org, err := services.AcceptInvitation(userID.(uint), input.Token)
+	if err != nil {
+		writeOrganizationError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    org,
+		"message": "Invitation accepted",
+	})
+}
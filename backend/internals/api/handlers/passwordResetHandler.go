@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	"github.com/gin-gonic/gin"
+)
+
+func ForgotPasswordHandler(c *gin.Context, cfg *config.Config, m mailer.Mailer) {
+	var input models.ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The reset is made in the background and the same answer is given
+	// whether or not the email has an account, so neither the response nor
+	// its timing tells which emails are registered.
+	go func(email string) {
+		if err := services.RequestPasswordReset(email, cfg, m); err != nil {
+			logger.Error("Failed to start password reset: " + err.Error())
+		}
+	}(input.Email)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "If an account exists for that email, a reset link has been sent",
+	})
+}
+
+func ResetPasswordHandler(c *gin.Context) {
+	var input models.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		if writeValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.ResetPassword(input.Token, input.Password); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to reset password: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password has been reset; please sign in again",
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func ProposeSwapCycleHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.SwapCycleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Failed to bind JSON for swap cycle: " + err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cycle, err := services.ProposeSwapCycle(userID.(uint), input.EventIDs, cfg.SWAP_REQUEST_TTL, input.IgnoreConflicts)
+	if err != nil {
+		if writeConflictError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Swap cycle proposed successfully with ID: %d", cycle.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    cycle,
+		"message": "Swap cycle proposed successfully",
+	})
+}
+
+func GetUserSwapCyclesHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	cycles, err := services.GetUserSwapCycles(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cycles,
+		"message": "Swap cycles retrieved successfully",
+	})
+}
+
+func GetSwapCycleHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	cycleIDStr := c.Param("cycleId")
+	cycleID, err := strconv.ParseUint(cycleIDStr, 10, 32)
+	if err != nil {
+		logger.Error("Invalid cycle ID: " + cycleIDStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cycle ID"})
+		return
+	}
+
+	cycle, err := services.GetSwapCycleByID(uint(cycleID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cycle,
+		"message": "Swap cycle retrieved successfully",
+	})
+}
+
+func RespondToSwapCycleHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	cycleIDStr := c.Param("cycleId")
+	cycleID, err := strconv.ParseUint(cycleIDStr, 10, 32)
+	if err != nil {
+		logger.Error("Invalid cycle ID: " + cycleIDStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cycle ID"})
+		return
+	}
+
+	var input models.SwapCycleResponseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Failed to bind JSON for swap cycle response: " + err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cycle, err := services.RespondToSwapCycle(uint(cycleID), userID.(uint), *input.Accepted, input.IgnoreConflicts)
+	if err != nil {
+		if writeConflictError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := "rejected"
+	if *input.Accepted {
+		status = "accepted"
+	}
+
+	logger.Info("Swap cycle " + status + " successfully")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cycle,
+		"message": "Swap cycle " + status + " successfully",
+	})
+}
@@ -1,21 +1,42 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
 	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
 	"github.com/gin-gonic/gin"
 )
 
 type SwapResponseInput struct {
-	Accepted bool `json:"accepted" binding:"required"`
+	Accepted        *bool `json:"accepted" binding:"required"`
+	IgnoreConflicts bool  `json:"ignore_conflicts"`
 }
 
-func CreateSwapRequestHandler(c *gin.Context) {
+// writeConflictError answers with 409 and the overlapping events when err is
+// a calendar conflict, and reports whether it did so.
+func writeConflictError(c *gin.Context, err error) bool {
+	var conflictErr *services.ConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+
+	logger.Warn(fmt.Sprintf("Swap refused for user %d: %s", conflictErr.UserID, conflictErr.Error()))
+	c.JSON(http.StatusConflict, gin.H{
+		"error":     conflictErr.Error(),
+		"userId":    conflictErr.UserID,
+		"blocking":  conflictErr.Blocking,
+		"conflicts": conflictErr.Conflicts,
+	})
+	return true
+}
+
+func CreateSwapRequestHandler(c *gin.Context, cfg *config.Config) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		logger.Error("User ID not found in context")
@@ -30,8 +51,11 @@ func CreateSwapRequestHandler(c *gin.Context) {
 		return
 	}
 
-	request, err := services.CreateSwapRequest(userID.(uint), input.MySlotID, input.TheirSlotID)
+	request, err := services.CreateSwapRequest(userID.(uint), input.MySlotID, input.TheirSlotID, cfg.SWAP_REQUEST_TTL, input.IgnoreConflicts)
 	if err != nil {
+		if writeConflictError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -52,11 +76,19 @@ func GetIncomingSwapRequestsHandler(c *gin.Context) {
 		return
 	}
 
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
 	requests, err := services.GetIncomingSwapRequests(userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if loc != nil {
+		services.LocalizeSwapRequests(requests, loc)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -73,11 +105,19 @@ func GetOutgoingSwapRequestsHandler(c *gin.Context) {
 		return
 	}
 
+	loc, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
 	requests, err := services.GetOutgoingSwapRequests(userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if loc != nil {
+		services.LocalizeSwapRequests(requests, loc)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -109,14 +149,17 @@ func RespondToSwapRequestHandler(c *gin.Context) {
 		return
 	}
 
-	err = services.RespondToSwapRequest(uint(requestID), userID.(uint), input.Accepted)
+	err = services.RespondToSwapRequest(uint(requestID), userID.(uint), *input.Accepted, input.IgnoreConflicts)
 	if err != nil {
+		if writeConflictError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	status := "rejected"
-	if input.Accepted {
+	if *input.Accepted {
 		status = "accepted"
 	}
 
@@ -126,3 +169,136 @@ func RespondToSwapRequestHandler(c *gin.Context) {
 		"message": "Swap request " + status + " successfully",
 	})
 }
+
+func CancelSwapRequestHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	requestIDStr := c.Param("requestId")
+	requestID, err := strconv.ParseUint(requestIDStr, 10, 32)
+	if err != nil {
+		logger.Error("Invalid request ID: " + requestIDStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return
+	}
+
+	err = services.CancelSwapRequest(uint(requestID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Swap request cancelled successfully")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Swap request cancelled successfully",
+	})
+}
+
+func CounterSwapRequestHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	requestIDStr := c.Param("requestId")
+	requestID, err := strconv.ParseUint(requestIDStr, 10, 32)
+	if err != nil {
+		logger.Error("Invalid request ID: " + requestIDStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return
+	}
+
+	var input models.CounterOfferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Failed to bind JSON for counter-offer: " + err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	counter, err := services.CounterSwapRequest(uint(requestID), userID.(uint), input.MySlotID, cfg.SWAP_REQUEST_TTL)
+	if err != nil {
+		if writeConflictError(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info(fmt.Sprintf("Counter-offer created successfully with ID: %d", counter.ID))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    counter,
+		"message": "Counter-offer created successfully",
+	})
+}
+
+func GetSwapRequestTimelineHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	requestIDStr := c.Param("requestId")
+	requestID, err := strconv.ParseUint(requestIDStr, 10, 32)
+	if err != nil {
+		logger.Error("Invalid request ID: " + requestIDStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return
+	}
+
+	timeline, err := services.GetSwapRequestTimeline(uint(requestID), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    timeline,
+		"message": "Swap request timeline retrieved successfully",
+	})
+}
+
+func ReverseSwapRequestHandler(c *gin.Context, cfg *config.Config) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	requestIDStr := c.Param("requestId")
+	requestID, err := strconv.ParseUint(requestIDStr, 10, 32)
+	if err != nil {
+		logger.Error("Invalid request ID: " + requestIDStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		return
+	}
+
+	request, err := services.ReverseSwapRequest(uint(requestID), userID.(uint), cfg.SWAP_UNDO_WINDOW, cfg.SWAP_UNDO_POLICY)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Swap reversed successfully"
+	if request.Status != models.REVERSED {
+		message = "Reversal requested, waiting for the other party to agree"
+	}
+
+	logger.Info(message)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    request,
+		"message": message,
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// respondToSwapRequest posts body to RespondToSwapRequestHandler as userID.
+func respondToSwapRequest(userID uint, requestID uint, body string) *httptest.ResponseRecorder {
+	logger.InitLogger()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/swap-response/:requestId", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		RespondToSwapRequestHandler(c)
+	})
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/swap-response/"+strconv.FormatUint(uint64(requestID), 10), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRespondToSwapRequestNeedsAnAnswer(t *testing.T) {
+	recorder := respondToSwapRequest(1, 1, `{}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "Accepted")
+}
+
+func TestRequesterRejectsCounterOffer(t *testing.T) {
+	conn := useTestDB(t)
+
+	requester := models.User{Name: "Requester", Email: "requester@example.com", Password: "x"}
+	responder := models.User{Name: "Responder", Email: "responder@example.com", Password: "x"}
+	require.NoError(t, conn.Create(&requester).Error)
+	require.NoError(t, conn.Create(&responder).Error)
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	events := []models.Event{
+		{Title: "Offered", StartTime: start, EndTime: start.Add(time.Hour), Status: models.EventStatusSwappable, OwnerID: requester.ID},
+		{Title: "Wanted", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour), Status: models.EventStatusSwappable, OwnerID: responder.ID},
+		{Title: "Alternative", StartTime: start.Add(4 * time.Hour), EndTime: start.Add(5 * time.Hour), Status: models.EventStatusSwappable, OwnerID: responder.ID},
+	}
+	require.NoError(t, conn.Create(&events).Error)
+
+	request, err := services.CreateSwapRequest(requester.ID, events[0].ID, events[1].ID, time.Hour, true)
+	require.NoError(t, err)
+	counter, err := services.CounterSwapRequest(request.ID, responder.ID, events[2].ID, time.Hour)
+	require.NoError(t, err)
+
+	recorder := respondToSwapRequest(requester.ID, counter.ID, `{"accepted":false}`)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Contains(t, recorder.Body.String(), "rejected")
+
+	require.NoError(t, conn.First(counter, counter.ID).Error)
+	assert.Equal(t, models.REJECTED, counter.Status)
+	for _, event := range events {
+		require.NoError(t, conn.First(&event, event.ID).Error)
+		assert.Equal(t, models.EventStatusSwappable, event.Status, "event %q is released", event.Title)
+	}
+}
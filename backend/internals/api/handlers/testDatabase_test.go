@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// useTestDB points db.DB at the PostgreSQL database named by
+// TEST_DATABASE_URL, migrated and emptied, for the length of the test.
+// Tests that need a database are skipped when it is not set.
+func useTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	logger.InitLogger()
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(conn))
+
+	tables, err := conn.Migrator().GetTables()
+	require.NoError(t, err)
+	for i, table := range tables {
+		tables[i] = strconv.Quote(table)
+	}
+	require.NoError(t, conn.Exec("TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE").Error)
+
+	previous := db.DB
+	db.DB = conn
+	t.Cleanup(func() {
+		db.DB = previous
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return conn
+}
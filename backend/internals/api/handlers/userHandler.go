@@ -1,17 +1,23 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
 	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
 	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterUserHandler(c *gin.Context) {
+func RegisterUserHandler(c *gin.Context, cfg *config.Config, m mailer.Mailer) {
 	var input models.User
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -29,6 +35,18 @@ func RegisterUserHandler(c *gin.Context) {
 		return
 	}
 
+	timeZone := "UTC"
+	if input.TimeZone != "" {
+		if _, err := services.LoadTimeZone(input.TimeZone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   err.Error(),
+				"message": "TimeZone must be an IANA time zone such as Europe/Berlin",
+			})
+			return
+		}
+		timeZone = input.TimeZone
+	}
+
 	hashPassword, err := pkg.HashPassword(input.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -38,9 +56,17 @@ func RegisterUserHandler(c *gin.Context) {
 		return
 	}
 
-	input.Password = hashPassword
+	// Only the sign-up fields are copied, so a client cannot choose its own
+	// role or verification state.
+	newUser := models.User{
+		Name:     input.Name,
+		Email:    input.Email,
+		Password: hashPassword,
+		Role:     models.RoleUser,
+		TimeZone: timeZone,
+	}
 
-	user, err := services.CreateUser(&input)
+	user, err := services.CreateUser(&newUser)
 	if err != nil {
 		logger.Error("Failed to create user: " + err.Error() + " for email: " + input.Email)
 		if err.Error() == "email already exists" {
@@ -57,6 +83,11 @@ func RegisterUserHandler(c *gin.Context) {
 		return
 	}
 
+	// The account exists either way; the user can ask for another link.
+	if err := services.SendEmailVerification(user, cfg, m); err != nil {
+		logger.Error("Failed to send verification email to " + user.Email + ": " + err.Error())
+	}
+
 	logger.Info("User created successfully for email: " + user.Email)
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -66,7 +97,7 @@ func RegisterUserHandler(c *gin.Context) {
 
 }
 
-func SignInUserHandler(c *gin.Context, cfg *config.Config) {
+func SignInUserHandler(c *gin.Context, cfg *config.Config, m mailer.Mailer, guard *loginguard.Guard) {
 	var input models.User
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -84,9 +115,14 @@ func SignInUserHandler(c *gin.Context, cfg *config.Config) {
 		return
 	}
 
+	if !checkSignInAllowed(c, guard, input.Email) {
+		return
+	}
+
 	user, err := services.GetUserByEmail(input.Email)
 	if err != nil {
 		logger.Error("User sign in failed: user not found for email: " + input.Email)
+		recordSignInFailure(c, guard, cfg, m, input.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error":   err,
 			"message": "invalid credentials",
@@ -96,46 +132,67 @@ func SignInUserHandler(c *gin.Context, cfg *config.Config) {
 
 	if !pkg.ComparePassword(user.Password, input.Password) {
 		logger.Error("User sign in failed: invalid password for email: " + input.Email)
+		recordSignInFailure(c, guard, cfg, m, input.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"message": "invalid credentials",
 		})
 		return
 	}
 
-	accessToken, err := pkg.GenerateAccessToken(user.ID, user.Email, cfg)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   err,
-			"message": "failed to generate access token",
+	if user.DisabledAt != nil {
+		logger.Warn("User sign in refused: account disabled for email: " + user.Email)
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "account disabled",
 		})
 		return
 	}
 
-	refreshToken, err := pkg.GenerateRefreshToken(user.ID, user.Email, cfg)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   err,
-			"message": "failed to generate refresh token",
+	if user.TOTPEnabledAt != nil {
+		mfaToken, err := pkg.GenerateMFAToken(user.ID, user.Email, cfg.MFA_PENDING_TTL, cfg)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   err,
+				"message": "failed to generate mfa token",
+			})
+			return
+		}
+
+		logger.Info("Password accepted, awaiting two-factor code for email: " + user.Email)
+		c.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"message":      "two-factor code required",
 		})
 		return
 	}
 
-	user.RefreshToken = refreshToken
-	err = services.UpdateUser(user)
+	completeSignIn(c, user, cfg, guard)
+}
+
+// completeSignIn opens a session for a fully authenticated user and returns
+// its tokens.
+func completeSignIn(c *gin.Context, user *models.User, cfg *config.Config, guard *loginguard.Guard) {
+	session, tokens, err := services.CreateSession(user, sessionMetaFromRequest(c), cfg)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   err,
-			"message": "failed to update user",
+			"message": "failed to create session",
 		})
 		return
 	}
 
+	if err := guard.RecordSuccess(user.Email); err != nil {
+		logger.Error("Failed to clear failed sign-ins: " + err.Error())
+	}
+
 	logger.Info("User signed in successfully for email: " + user.Email)
 	c.JSON(http.StatusOK, gin.H{
 		"success":       true,
 		"user":          user,
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+		"session_id":    session.ID,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
 		"message":       "sign in successful",
 	})
 }
@@ -162,3 +219,158 @@ func GetUserProfileHandler(c *gin.Context) {
 		"message": "Profile retrieved successfully",
 	})
 }
+
+func UpdateConflictPolicyHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input models.ConflictPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.UpdateConflictPolicy(userID.(uint), input.Policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"policy": input.Policy},
+		"message": "Conflict policy updated successfully",
+	})
+}
+
+func RefreshTokenHandler(c *gin.Context, cfg *config.Config) {
+	var input models.RefreshTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   err.Error(),
+			"message": "refresh_token is required",
+		})
+		return
+	}
+
+	tokens, err := services.RefreshSession(input.RefreshToken, cfg)
+	if err != nil {
+		if errors.Is(err, services.ErrAccountDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrRefreshTokenReused) || errors.Is(err, services.ErrInvalidRefreshToken) {
+			logger.Warn("Refresh failed: " + err.Error())
+			c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err,
+			"message": "failed to rotate refresh token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"message":       "tokens refreshed",
+	})
+}
+
+// sessionMetaFromRequest describes the calling device. Clients may name
+// themselves with X-Device-Name; otherwise the user agent is used.
+func sessionMetaFromRequest(c *gin.Context) services.SessionMeta {
+	userAgent := c.GetHeader("User-Agent")
+	device := strings.TrimSpace(c.GetHeader("X-Device-Name"))
+	if device == "" {
+		device = userAgent
+	}
+	if len(device) > 255 {
+		device = device[:255]
+	}
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	return services.SessionMeta{Device: device, IP: c.ClientIP(), UserAgent: userAgent}
+}
+
+func LogoutHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	expiresAt, _ := c.Get("token_expires_at")
+	tokenExpiresAt, _ := expiresAt.(time.Time)
+
+	if err := services.Logout(userID.(uint), c.GetUint("session_id"), c.GetString("token_id"), tokenExpiresAt); err != nil {
+		logger.Error("Failed to log out: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	logger.Info("User logged out: " + c.GetString("email"))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Logged out successfully",
+	})
+}
+
+func GetSessionsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessions, err := services.ListSessions(userID.(uint), c.GetUint("session_id"))
+	if err != nil {
+		logger.Error("Failed to fetch sessions: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sessions,
+		"message": "Sessions retrieved successfully",
+	})
+}
+
+func RevokeSessionHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Error("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := services.RevokeSession(uint(sessionID), userID.(uint)); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("Failed to revoke session: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Session revoked successfully",
+	})
+}
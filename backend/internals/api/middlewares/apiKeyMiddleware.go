@@ -0,0 +1,119 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyFromRequest returns a personal API key sent in the X-API-Key header,
+// as an Authorization bearer credential or as the password of HTTP Basic
+// credentials, which is all most calendar clients can send.
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if bearer := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); pkg.IsAPIKey(bearer) {
+		return bearer
+	}
+	if _, password, ok := c.Request.BasicAuth(); ok && pkg.IsAPIKey(password) {
+		return password
+	}
+	return ""
+}
+
+// AuthMiddleware accepts either a JWT access token or a personal API key.
+// Requests made with a key carry its scopes for RequireScope; routes that
+// must stay session-only, such as account management, use
+// JWTAuthMiddleware instead.
+func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	jwtAuth := JWTAuthMiddleware(cfg)
+	return func(c *gin.Context) {
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			jwtAuth(c)
+			return
+		}
+
+		apiKey, user, err := services.AuthenticateAPIKey(key)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) || errors.Is(err, services.ErrAccountDisabled) {
+				logger.Warn("Unauthorized access attempt: " + err.Error())
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			} else {
+				logger.Error("Failed to check API key: " + err.Error())
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify API key"})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", user.ID)
+		c.Set("email", user.Email)
+		c.Set("role", string(user.Role))
+		c.Set("api_key_id", apiKey.ID)
+		c.Set("api_key_scopes", apiKey.Scopes)
+
+		logger.Info("Authenticated user with API key " + apiKey.Prefix + ": " + user.Email)
+		c.Next()
+	}
+}
+
+// readMethods are the methods that need only a read scope. PROPFIND and
+// REPORT are how CalDAV clients list and fetch events.
+var readMethods = map[string]bool{
+	http.MethodGet:  true,
+	http.MethodHead: true,
+	"PROPFIND":      true,
+	"REPORT":        true,
+}
+
+// DAVAuthMiddleware authenticates CalDAV clients. They sign in with HTTP
+// Basic credentials: any user name and a personal API key as the password.
+// Requests without a key are challenged so clients prompt for one; bearer
+// tokens work as with AuthMiddleware.
+func DAVAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+	auth := AuthMiddleware(cfg)
+	return func(c *gin.Context) {
+		if apiKeyFromRequest(c) == "" && !strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+			logger.Warn("Unauthorized CalDAV request: no API key")
+			c.Header("WWW-Authenticate", `Basic realm="SlotSwapper", charset="UTF-8"`)
+			c.String(http.StatusUnauthorized, "Sign in with a personal API key as the password")
+			c.Abort()
+			return
+		}
+		auth(c)
+	}
+}
+
+// RequireScope checks that a request made with an API key holds the read
+// scope for resource on GET, HEAD, PROPFIND and REPORT, and the write scope
+// otherwise. Requests authenticated with a JWT are not restricted.
+func RequireScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, ok := c.Get("api_key_scopes")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		scope := resource + ":write"
+		if readMethods[c.Request.Method] {
+			scope = resource + ":read"
+		}
+		if services.APIKeyAllows(scopes.(string), scope) {
+			c.Next()
+			return
+		}
+
+		logger.Warn("Forbidden: API key for " + c.GetString("email") + " lacks scope " + scope + " for " + c.Request.Method + " " + c.FullPath())
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key lacks the " + scope + " scope"})
+		c.Abort()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
 	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
 	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
@@ -36,8 +37,30 @@ func JWTAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		if claims.ID != "" {
+			revoked, err := services.IsTokenRevoked(claims.ID)
+			if err != nil {
+				logger.Error("Failed to check token denylist: " + err.Error())
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify token"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				logger.Warn("Unauthorized access attempt: revoked token for " + claims.Email)
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+				c.Abort()
+				return
+			}
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_id", claims.ID)
+		if claims.ExpiresAt != nil {
+			c.Set("token_expires_at", claims.ExpiresAt.Time)
+		}
 
 		logger.Info("Authenticated user: " + claims.Email)
 		c.Next()
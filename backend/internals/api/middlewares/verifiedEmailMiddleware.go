@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/services"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail refuses the request when REQUIRE_EMAIL_VERIFICATION is
+// on and the caller has not verified their email. It must run after
+// JWTAuthMiddleware.
+func RequireVerifiedEmail(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.REQUIRE_EMAIL_VERIFICATION {
+			c.Next()
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		verified, err := services.IsEmailVerified(userID.(uint))
+		if err != nil {
+			logger.Error("Failed to check email verification: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification"})
+			c.Abort()
+			return
+		}
+
+		if !verified {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   services.ErrEmailNotVerified.Error(),
+				"message": "verify your email address before requesting swaps",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrDisableOwnAccount = errors.New("you cannot disable your own account")
+	ErrChangeOwnRole     = errors.New("you cannot change your own role")
+	ErrEventHeldBySwap   = errors.New("event is held by an open swap; cancel the swap instead")
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// AdminUserView is what the admin API shows of an account; it leaves out
+// the password hash and two-factor secret.
+type AdminUserView struct {
+	ID              uint        `json:"id"`
+	Name            string      `json:"name"`
+	Email           string      `json:"email"`
+	Role            models.Role `json:"role"`
+	DisabledAt      *time.Time  `json:"disabledAt,omitempty"`
+	EmailVerifiedAt *time.Time  `json:"emailVerifiedAt,omitempty"`
+	MFAEnabled      bool        `json:"mfaEnabled"`
+	CreatedAt       time.Time   `json:"createdAt"`
+}
+
+func toAdminUserView(user *models.User) AdminUserView {
+	return AdminUserView{
+		ID:              user.ID,
+		Name:            user.Name,
+		Email:           user.Email,
+		Role:            user.Role,
+		DisabledAt:      user.DisabledAt,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		MFAEnabled:      user.TOTPEnabledAt != nil,
+		CreatedAt:       user.CreatedAt,
+	}
+}
+
+// normalizePage clamps page and limit to sane values and returns the row
+// offset.
+func normalizePage(page, limit int) (int, int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return page, limit, (page - 1) * limit
+}
+
+// roleRank orders roles by privilege.
+func roleRank(role models.Role) int {
+	switch role {
+	case models.RoleAdmin:
+		return 2
+	case models.RoleModerator:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func recordAdminAudit(tx *gorm.DB, actorID uint, action models.AdminAuditAction, targetType string, targetID uint, details string) error {
+	entry := models.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		logger.Error("Failed to record admin audit entry: " + err.Error())
+		return err
+	}
+	logger.Info(fmt.Sprintf("Admin %d: %s %s %d %s", actorID, action, targetType, targetID, details))
+	return nil
+}
+
+// PromoteAdmins gives the ADMIN role to the accounts registered under emails
+// once their address is verified, so signing up first under a configured
+// address is not enough. It runs at startup so a deployment always has a
+// way into the admin API.
+func PromoteAdmins(emails []string) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	var cleaned []string
+	for _, email := range emails {
+		if email = strings.TrimSpace(email); email != "" {
+			cleaned = append(cleaned, email)
+		}
+	}
+	if len(cleaned) == 0 {
+		return nil
+	}
+
+	result := db.DB.Model(&models.User{}).
+		Where("email IN ? AND role <> ? AND email_verified_at IS NOT NULL", cleaned, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		logger.Info(fmt.Sprintf("Promoted %d configured account(s) to ADMIN", result.RowsAffected))
+	}
+	return nil
+}
+
+// SearchUsers lists accounts for the admin API. Query matches name or email.
+func SearchUsers(search models.UserSearch) ([]AdminUserView, int64, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, 0, errors.New("database connection is nil")
+	}
+
+	query := db.DB.Model(&models.User{})
+	if q := strings.TrimSpace(search.Query); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?", like, like)
+	}
+	if search.Role != "" {
+		query = query.Where("role = ?", search.Role)
+	}
+	if search.Disabled != nil {
+		if *search.Disabled {
+			query = query.Where("disabled_at IS NOT NULL")
+		} else {
+			query = query.Where("disabled_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	_, limit, offset := normalizePage(search.Page, search.Limit)
+	var users []models.User
+	if err := query.Order("id").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	views := make([]AdminUserView, 0, len(users))
+	for i := range users {
+		views = append(views, toAdminUserView(&users[i]))
+	}
+	return views, total, nil
+}
+
+// SetUserDisabled disables or re-enables an account. Disabling signs the user
+// out of every session; they cannot sign in or refresh until re-enabled.
+func SetUserDisabled(actorID, targetID uint, disabled bool, reason string) (*AdminUserView, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	if actorID == targetID {
+		return nil, ErrDisableOwnAccount
+	}
+
+	now := time.Now()
+	var user models.User
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(forUpdate).First(&user, targetID).Error; err != nil {
+			return ErrUserNotFound
+		}
+
+		if disabled == (user.DisabledAt != nil) {
+			return nil
+		}
+
+		action := models.AdminAuditUserEnabled
+		if disabled {
+			action = models.AdminAuditUserDisabled
+			user.DisabledAt = &now
+			if err := revokeUserSessions(tx, user.ID, now); err != nil {
+				return err
+			}
+		} else {
+			user.DisabledAt = nil
+		}
+
+		if err := tx.Model(&user).Update("disabled_at", user.DisabledAt).Error; err != nil {
+			return err
+		}
+
+		return recordAdminAudit(tx, actorID, action, "user", user.ID, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	view := toAdminUserView(&user)
+	return &view, nil
+}
+
+// SetUserRole changes an account's role. Lowering a role revokes the user's
+// sessions so the old role in their access tokens stops working at once.
+func SetUserRole(actorID, targetID uint, role models.Role) (*AdminUserView, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	if actorID == targetID {
+		return nil, ErrChangeOwnRole
+	}
+
+	now := time.Now()
+	var user models.User
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(forUpdate).First(&user, targetID).Error; err != nil {
+			return ErrUserNotFound
+		}
+
+		previous := user.Role
+		if previous == role {
+			return nil
+		}
+
+		user.Role = role
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+
+		if roleRank(role) < roleRank(previous) {
+			if err := revokeUserSessions(tx, user.ID, now); err != nil {
+				return err
+			}
+		}
+
+		return recordAdminAudit(tx, actorID, models.AdminAuditUserRoleChanged, "user", user.ID, fmt.Sprintf("%s -> %s", previous, role))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	view := toAdminUserView(&user)
+	return &view, nil
+}
+
+// AdminUnlockUser lifts a sign-in lockout and clears the account's failed
+// attempts.
+func AdminUnlockUser(actorID, targetID uint, reason string, guard *loginguard.Guard) (*AdminUserView, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var user models.User
+	if err := db.DB.First(&user, targetID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if err := guard.Unlock(user.Email); err != nil {
+		return nil, err
+	}
+
+	if err := recordAdminAudit(db.DB, actorID, models.AdminAuditUserUnlocked, "user", user.ID, reason); err != nil {
+		return nil, err
+	}
+
+	logger.Security("account_unlocked", map[string]interface{}{
+		"user_id":  user.ID,
+		"email":    user.Email,
+		"method":   "admin",
+		"actor_id": actorID,
+	})
+
+	view := toAdminUserView(&user)
+	return &view, nil
+}
+
+// AdminCancelSwapRequest cancels an open swap request on behalf of either
+// party. Events still held for the request go back to SWAPPABLE.
+func AdminCancelSwapRequest(actorID, requestID uint, reason string) (*models.SwapRequest, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var request models.SwapRequest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(forUpdate).First(&request, requestID).Error; err != nil {
+			return ErrSwapRequestNotFound
+		}
+
+		if !isOpenSwapStatus(request.Status) {
+			return ErrSwapRequestNotPending
+		}
+
+		details := "cancelled by administrator"
+		if reason != "" {
+			details += ": " + reason
+		}
+		if err := forceCancelSwapRequest(tx, &request, actorID, details); err != nil {
+			return err
+		}
+
+		return recordAdminAudit(tx, actorID, models.AdminAuditSwapRequestCancelled, "swap_request", request.ID, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+// AdminResetEventStatus moves an event to BUSY or SWAPPABLE, typically to
+// free one stuck in SWAP_PENDING. Events that an open swap request or cycle
+// still holds are refused; cancel the swap instead.
+func AdminResetEventStatus(actorID, eventID uint, status models.EventStatus, reason string) (*models.Event, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var event *models.Event
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		events, err := lockEvents(tx, eventID)
+		if err != nil {
+			return ErrEventNotFound
+		}
+		event = events[eventID]
+
+		var openRequests int64
+		if err := tx.Model(&models.SwapRequest{}).
+			Where("(requester_event_id = ? OR responder_event_id = ?) AND status IN ?", eventID, eventID, []models.SwapStatus{models.PENDING, models.COUNTERED}).
+			Count(&openRequests).Error; err != nil {
+			return err
+		}
+
+		var openCycles int64
+		if err := tx.Model(&models.SwapCycleLeg{}).
+			Joins("JOIN swap_cycles ON swap_cycles.id = swap_cycle_legs.cycle_id").
+			Where("swap_cycle_legs.event_id = ? AND swap_cycles.status = ?", eventID, models.SwapCyclePending).
+			Count(&openCycles).Error; err != nil {
+			return err
+		}
+
+		if openRequests > 0 || openCycles > 0 {
+			return ErrEventHeldBySwap
+		}
+
+		previous := event.Status
+		if previous == status {
+			return nil
+		}
+
+		event.Status = status
+		if err := tx.Save(event).Error; err != nil {
+			return err
+		}
+
+		details := fmt.Sprintf("%s -> %s", previous, status)
+		if reason != "" {
+			details += ": " + reason
+		}
+		return recordAdminAudit(tx, actorID, models.AdminAuditEventStatusReset, "event", event.ID, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// GetAdminAuditLog returns admin actions, newest first, optionally limited to
+// one target.
+func GetAdminAuditLog(targetType string, targetID uint, page, limit int) ([]models.AdminAuditEntry, int64, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, 0, errors.New("database connection is nil")
+	}
+
+	query := db.DB.Model(&models.AdminAuditEntry{})
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+		if targetID != 0 {
+			query = query.Where("target_id = ?", targetID)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	_, limit, offset := normalizePage(page, limit)
+	var entries []models.AdminAuditEntry
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePage(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		page, limit, offset := normalizePage(0, 0)
+		assert.Equal(t, 1, page)
+		assert.Equal(t, defaultPageSize, limit)
+		assert.Equal(t, 0, offset)
+	})
+
+	t.Run("Clamps Limit", func(t *testing.T) {
+		_, limit, offset := normalizePage(3, 1000)
+		assert.Equal(t, maxPageSize, limit)
+		assert.Equal(t, 2*maxPageSize, offset)
+	})
+}
+
+func TestRoleRank(t *testing.T) {
+	assert.Greater(t, roleRank(models.RoleAdmin), roleRank(models.RoleModerator))
+	assert.Greater(t, roleRank(models.RoleModerator), roleRank(models.RoleUser))
+	assert.Equal(t, roleRank(models.RoleUser), roleRank(""))
+}
+
+func TestAdminUserViewHidesSecrets(t *testing.T) {
+	now := time.Now()
+	view := toAdminUserView(&models.User{ID: 1, Email: "a@example.com", Password: "hash", TOTPSecret: "secret", TOTPEnabledAt: &now, Role: models.RoleModerator})
+
+	assert.True(t, view.MFAEnabled)
+	assert.Equal(t, models.RoleModerator, view.Role)
+}
+
+func TestPromoteAdminsNeedsVerifiedEmail(t *testing.T) {
+	conn := useTestDB(t)
+
+	now := time.Now()
+	verified := models.User{Name: "Verified", Email: "admin@example.com", Password: "x", Role: models.RoleUser, EmailVerifiedAt: &now}
+	unverified := models.User{Name: "Squatter", Email: "ops@example.com", Password: "x", Role: models.RoleUser}
+	require.NoError(t, conn.Create(&verified).Error)
+	require.NoError(t, conn.Create(&unverified).Error)
+
+	require.NoError(t, PromoteAdmins([]string{" admin@example.com", "ops@example.com", ""}))
+
+	require.NoError(t, conn.First(&verified, verified.ID).Error)
+	require.NoError(t, conn.First(&unverified, unverified.ID).Error)
+	assert.Equal(t, models.RoleAdmin, verified.Role)
+	assert.Equal(t, models.RoleUser, unverified.Role, "an unverified address is not proof of ownership")
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrTooManyAPIKeys = fmt.Errorf("you cannot have more than %d active API keys", maxAPIKeysPerUser)
+)
+
+const (
+	maxAPIKeysPerUser = 25
+	// apiKeyTouchInterval limits how often last_used_at is written, so a
+	// busy script does not cause a write on every request.
+	apiKeyTouchInterval = time.Minute
+)
+
+var apiKeyScopes = map[string]bool{
+	models.ScopeEventsRead:  true,
+	models.ScopeEventsWrite: true,
+	models.ScopeSwapsRead:   true,
+	models.ScopeSwapsWrite:  true,
+	models.ScopeOrgsRead:    true,
+	models.ScopeOrgsWrite:   true,
+}
+
+// normalizeAPIKeyScopes validates requested scopes and returns them sorted,
+// de-duplicated and comma-separated.
+func normalizeAPIKeyScopes(scopes []string, errs *ValidationError) string {
+	seen := make(map[string]bool, len(scopes))
+	var cleaned []string
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !apiKeyScopes[scope] {
+			errs.add("scopes", fmt.Sprintf("unknown scope %q", scope))
+			continue
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			cleaned = append(cleaned, scope)
+		}
+	}
+	sort.Strings(cleaned)
+	return strings.Join(cleaned, ",")
+}
+
+// APIKeyAllows reports whether a key's comma-separated scopes grant scope.
+// A write scope also grants reading the same resource.
+func APIKeyAllows(scopes string, scope string) bool {
+	resource, _, _ := strings.Cut(scope, ":")
+	for _, granted := range strings.Split(scopes, ",") {
+		if granted == scope || (granted == resource+":write" && scope == resource+":read") {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKey issues a personal API key. The plain key is returned only
+// here; the stored row keeps its hash and display prefix.
+func CreateAPIKey(userID uint, input *models.APIKeyInput) (*models.APIKey, string, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, "", errors.New("database connection is nil")
+	}
+
+	errs := &ValidationError{}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		errs.add("name", "is required")
+	}
+	scopes := normalizeAPIKeyScopes(input.Scopes, errs)
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		errs.add("expiresAt", "must be in the future")
+	}
+	if err := errs.errOrNil(); err != nil {
+		return nil, "", err
+	}
+
+	key, prefix, err := pkg.NewAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey := models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   pkg.HashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var active int64
+		if err := tx.Model(&models.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active >= maxAPIKeysPerUser {
+			return ErrTooManyAPIKeys
+		}
+
+		return tx.Create(&apiKey).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	logger.Info(fmt.Sprintf("API key %d (%s) created for user %d with scopes %s", apiKey.ID, apiKey.Prefix, userID, scopes))
+	return &apiKey, key, nil
+}
+
+// ListAPIKeys returns the user's keys, including revoked and expired ones,
+// newest first.
+func ListAPIKeys(userID uint) ([]models.APIKey, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var keys []models.APIKey
+	if err := db.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops one of the user's keys from working.
+func RevokeAPIKey(userID, keyID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	result := db.DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	logger.Info(fmt.Sprintf("API key %d revoked by user %d", keyID, userID))
+	return nil
+}
+
+// AuthenticateAPIKey resolves a presented key to its row and owner. Revoked
+// and expired keys, and keys of disabled accounts, are refused.
+func AuthenticateAPIKey(key string) (*models.APIKey, *models.User, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, nil, errors.New("database connection is nil")
+	}
+
+	var apiKey models.APIKey
+	if err := db.DB.Preload("User").Where("key_hash = ?", pkg.HashToken(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now)) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if apiKey.User.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := db.DB.Model(&apiKey).Update("last_used_at", now).Error; err != nil {
+			logger.Error(fmt.Sprintf("Failed to record use of API key %d: %s", apiKey.ID, err.Error()))
+		}
+	}
+
+	return &apiKey, &apiKey.User, nil
+}
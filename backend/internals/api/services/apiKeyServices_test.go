@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAPIKeyScopes(t *testing.T) {
+	t.Run("Sorted And De-duplicated", func(t *testing.T) {
+		errs := &ValidationError{}
+		scopes := normalizeAPIKeyScopes([]string{"swaps:write", " EVENTS:read", "swaps:write"}, errs)
+		assert.NoError(t, errs.errOrNil())
+		assert.Equal(t, "events:read,swaps:write", scopes)
+	})
+
+	t.Run("Unknown Scope", func(t *testing.T) {
+		errs := &ValidationError{}
+		normalizeAPIKeyScopes([]string{"events:read", "admin"}, errs)
+		assert.Error(t, errs.errOrNil())
+		assert.Len(t, errs.Fields, 1)
+		assert.Equal(t, "scopes", errs.Fields[0].Field)
+	})
+}
+
+func TestAPIKeyAllows(t *testing.T) {
+	assert.True(t, APIKeyAllows("events:read", "events:read"))
+	assert.False(t, APIKeyAllows("events:read", "events:write"))
+	assert.True(t, APIKeyAllows("events:read,swaps:write", "swaps:read"))
+	assert.False(t, APIKeyAllows("swaps:write", "events:read"))
+	assert.False(t, APIKeyAllows("", "events:read"))
+}
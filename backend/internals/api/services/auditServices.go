@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
+)
+
+// recordSwapRequestAudit appends an audit entry for request inside tx, after
+// request has been saved in its new status.
+func recordSwapRequestAudit(tx *gorm.DB, request *models.SwapRequest, action models.SwapAuditAction, actorID *uint, fromStatus models.SwapStatus, details string) error {
+	entry := models.SwapAuditEntry{
+		SwapRequestID: &request.ID,
+		Action:        action,
+		ActorID:       actorID,
+		FromStatus:    string(fromStatus),
+		ToStatus:      string(request.Status),
+		Details:       details,
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		logger.Error("Failed to record swap request audit entry: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// recordSwapCycleAudit is recordSwapRequestAudit for swap cycles.
+func recordSwapCycleAudit(tx *gorm.DB, cycle *models.SwapCycle, action models.SwapAuditAction, actorID *uint, fromStatus models.SwapCycleStatus, details string) error {
+	entry := models.SwapAuditEntry{
+		SwapCycleID: &cycle.ID,
+		Action:      action,
+		ActorID:     actorID,
+		FromStatus:  string(fromStatus),
+		ToStatus:    string(cycle.Status),
+		Details:     details,
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		logger.Error("Failed to record swap cycle audit entry: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// recordOwnershipChange appends an ownership history row for an event whose
+// OwnerID has just been changed from previousOwnerID inside tx.
+func recordOwnershipChange(tx *gorm.DB, event *models.Event, previousOwnerID uint, requestID *uint, cycleID *uint) error {
+	// Calendar feeds of both owners see the event change hands.
+	event.Sequence++
+	if err := tx.Model(event).UpdateColumn("sequence", event.Sequence).Error; err != nil {
+		logger.Error("Failed to bump event sequence: " + err.Error())
+		return err
+	}
+
+	history := models.EventOwnershipHistory{
+		EventID:         event.ID,
+		PreviousOwnerID: previousOwnerID,
+		NewOwnerID:      event.OwnerID,
+		SwapRequestID:   requestID,
+		SwapCycleID:     cycleID,
+		ChangedAt:       event.UpdatedAt,
+	}
+
+	if err := tx.Create(&history).Error; err != nil {
+		logger.Error("Failed to record event ownership change: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetEventOwnershipHistory returns the ownership changes of an event, oldest
+// first. The current owner and anyone who has owned the event may read it.
+func GetEventOwnershipHistory(eventID uint, userID uint) ([]models.EventOwnershipHistory, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	event, err := GetEventByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []models.EventOwnershipHistory
+	if err := db.DB.Where("event_id = ?", eventID).Order("changed_at ASC, id ASC").Find(&history).Error; err != nil {
+		logger.Error("Failed to fetch event ownership history: " + err.Error())
+		return nil, err
+	}
+
+	allowed := event.OwnerID == userID
+	for _, change := range history {
+		if change.PreviousOwnerID == userID || change.NewOwnerID == userID {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return nil, errors.New("event not found")
+	}
+
+	return history, nil
+}
+
+// GetSwapRequestTimeline returns the audit entries of a swap request and of
+// every request in its counter-offer chain, oldest first.
+func GetSwapRequestTimeline(requestID uint, userID uint) ([]models.SwapAuditEntry, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var request models.SwapRequest
+	if err := db.DB.First(&request, requestID).Error; err != nil {
+		return nil, errors.New("swap request not found")
+	}
+
+	if request.RequesterID != userID && request.ResponderID != userID {
+		return nil, errors.New("swap request not found")
+	}
+
+	chain := []uint{request.ID}
+	for parentID := request.ParentRequestID; parentID != nil; {
+		var parent models.SwapRequest
+		if err := db.DB.Select("id", "parent_request_id").First(&parent, *parentID).Error; err != nil {
+			break
+		}
+		chain = append(chain, parent.ID)
+		parentID = parent.ParentRequestID
+	}
+
+	for childOf := request.ID; ; {
+		var child models.SwapRequest
+		if err := db.DB.Select("id").Where("parent_request_id = ?", childOf).First(&child).Error; err != nil {
+			break
+		}
+		chain = append(chain, child.ID)
+		childOf = child.ID
+	}
+
+	var entries []models.SwapAuditEntry
+	if err := db.DB.Where("swap_request_id IN ?", chain).Order("created_at ASC, id ASC").Find(&entries).Error; err != nil {
+		logger.Error("Failed to fetch swap request timeline: " + err.Error())
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func auditActions(entries []models.SwapAuditEntry) []models.SwapAuditAction {
+	actions := make([]models.SwapAuditAction, len(entries))
+	for i, entry := range entries {
+		actions[i] = entry.Action
+	}
+	return actions
+}
+
+func TestSwapRequestAuditTrail(t *testing.T) {
+	conn := useTestDB(t)
+
+	requester := createTestUser(t, conn, "Requester")
+	responder := createTestUser(t, conn, "Responder")
+
+	t.Run("Rejected", func(t *testing.T) {
+		offered := createTestEvent(t, conn, requester.ID, 24)
+		wanted := createTestEvent(t, conn, responder.ID, 26)
+		request, err := CreateSwapRequest(requester.ID, offered.ID, wanted.ID, time.Hour, true)
+		require.NoError(t, err)
+		require.NoError(t, RespondToSwapRequest(request.ID, responder.ID, false, true))
+
+		entries, err := GetSwapRequestTimeline(request.ID, requester.ID)
+		require.NoError(t, err)
+		require.Equal(t, []models.SwapAuditAction{models.SwapAuditCreated, models.SwapAuditRejected}, auditActions(entries))
+		assert.Equal(t, string(models.PENDING), entries[1].FromStatus)
+		assert.Equal(t, string(models.REJECTED), entries[1].ToStatus)
+		assert.Equal(t, responder.ID, *entries[1].ActorID)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		offered := createTestEvent(t, conn, requester.ID, 28)
+		wanted := createTestEvent(t, conn, responder.ID, 30)
+		request, err := CreateSwapRequest(requester.ID, offered.ID, wanted.ID, time.Hour, true)
+		require.NoError(t, err)
+		require.NoError(t, CancelSwapRequest(request.ID, requester.ID))
+
+		entries, err := GetSwapRequestTimeline(request.ID, responder.ID)
+		require.NoError(t, err)
+		require.Equal(t, []models.SwapAuditAction{models.SwapAuditCreated, models.SwapAuditCancelled}, auditActions(entries))
+		assert.Equal(t, string(models.CANCELLED), entries[1].ToStatus)
+		assert.Equal(t, requester.ID, *entries[1].ActorID)
+	})
+
+	t.Run("Outsiders See Nothing", func(t *testing.T) {
+		outsider := createTestUser(t, conn, "Outsider")
+		offered := createTestEvent(t, conn, requester.ID, 32)
+		wanted := createTestEvent(t, conn, responder.ID, 34)
+		request, err := CreateSwapRequest(requester.ID, offered.ID, wanted.ID, time.Hour, true)
+		require.NoError(t, err)
+
+		_, err = GetSwapRequestTimeline(request.ID, outsider.ID)
+		assert.EqualError(t, err, "swap request not found")
+	})
+}
+
+func TestSwapRequestTimelineFollowsCounterOffers(t *testing.T) {
+	conn := useTestDB(t)
+
+	requester := createTestUser(t, conn, "Requester")
+	responder := createTestUser(t, conn, "Responder")
+	offered := createTestEvent(t, conn, requester.ID, 24)
+	offeredInstead := createTestEvent(t, conn, requester.ID, 26)
+	wanted := createTestEvent(t, conn, responder.ID, 28)
+	alternative := createTestEvent(t, conn, responder.ID, 30)
+
+	original, err := CreateSwapRequest(requester.ID, offered.ID, wanted.ID, time.Hour, true)
+	require.NoError(t, err)
+	counter, err := CounterSwapRequest(original.ID, responder.ID, alternative.ID, time.Hour)
+	require.NoError(t, err)
+	counterBack, err := CounterSwapRequest(counter.ID, requester.ID, offeredInstead.ID, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, RespondToSwapRequest(counterBack.ID, responder.ID, true, true))
+
+	want := []models.SwapAuditAction{
+		models.SwapAuditCreated, models.SwapAuditCountered,
+		models.SwapAuditCreated, models.SwapAuditCountered,
+		models.SwapAuditCreated, models.SwapAuditAccepted,
+	}
+	// The whole chain is returned from whichever request it is asked for.
+	for _, requestID := range []uint{original.ID, counter.ID, counterBack.ID} {
+		entries, err := GetSwapRequestTimeline(requestID, requester.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want, auditActions(entries), "timeline of request %d", requestID)
+	}
+
+	entries, err := GetSwapRequestTimeline(original.ID, responder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, *entries[0].SwapRequestID)
+	assert.Equal(t, string(models.SUPERSEDED), entries[1].ToStatus)
+	assert.Equal(t, counterBack.ID, *entries[5].SwapRequestID)
+}
+
+func TestEventOwnershipHistory(t *testing.T) {
+	conn := useTestDB(t)
+
+	requester := createTestUser(t, conn, "Requester")
+	responder := createTestUser(t, conn, "Responder")
+	outsider := createTestUser(t, conn, "Outsider")
+	offered := createTestEvent(t, conn, requester.ID, 24)
+	wanted := createTestEvent(t, conn, responder.ID, 26)
+
+	request, err := CreateSwapRequest(requester.ID, offered.ID, wanted.ID, time.Hour, true)
+	require.NoError(t, err)
+	require.NoError(t, RespondToSwapRequest(request.ID, responder.ID, true, true))
+
+	for _, userID := range []uint{requester.ID, responder.ID} {
+		history, err := GetEventOwnershipHistory(offered.ID, userID)
+		require.NoError(t, err, "user %d", userID)
+		require.Len(t, history, 1)
+		assert.Equal(t, requester.ID, history[0].PreviousOwnerID)
+		assert.Equal(t, responder.ID, history[0].NewOwnerID)
+		assert.Equal(t, request.ID, *history[0].SwapRequestID)
+	}
+
+	var event models.Event
+	require.NoError(t, conn.First(&event, wanted.ID).Error)
+	assert.Equal(t, wanted.Sequence+1, event.Sequence, "calendar feeds see the change of hands")
+
+	_, err = GetEventOwnershipHistory(offered.ID, outsider.ID)
+	assert.EqualError(t, err, "event not found")
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/ical"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRecurringResource  = errors.New("recurring events cannot be written over CalDAV; use /api/event-series")
+)
+
+// CalDAVResource is an event as a CalDAV calendar object. Name is its file
+// name in the collection: the event's UID followed by ".ics".
+type CalDAVResource struct {
+	Name  string
+	ETag  string
+	Data  []byte
+	Start time.Time
+	End   time.Time
+}
+
+// CalDAVConditions are the If-Match and If-None-Match headers of a write.
+type CalDAVConditions struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// renderCalDAVResource renders an event as its own calendar. DTSTAMP is the
+// last update, so the data, and the ETag taken from it, change only when
+// the event does.
+func renderCalDAVResource(event *models.Event) CalDAVResource {
+	cal := &ical.Calendar{ProdID: calendarProdID, Events: []ical.Event{eventToICal(event)}}
+	data := cal.Marshal(event.UpdatedAt)
+	sum := sha256.Sum256(data)
+	return CalDAVResource{
+		Name:  eventUID(event) + ".ics",
+		ETag:  `"` + hex.EncodeToString(sum[:16]) + `"`,
+		Data:  data,
+		Start: event.StartTime,
+		End:   event.EndTime,
+	}
+}
+
+// CalDAVCollectionTag changes whenever a resource of the collection is
+// added, changed or removed. Clients poll it before listing the collection.
+func CalDAVCollectionTag(resources []CalDAVResource) string {
+	entries := make([]string, len(resources))
+	for i, resource := range resources {
+		entries[i] = resource.Name + " " + resource.ETag
+	}
+	sort.Strings(entries)
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists etag
+// or is "*".
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// check applies the conditions to the resource as it is now, nil when it does
+// not exist.
+func (c CalDAVConditions) check(current *CalDAVResource) error {
+	if c.IfNoneMatch != "" && current != nil && etagMatches(c.IfNoneMatch, current.ETag) {
+		return ErrPreconditionFailed
+	}
+	if c.IfMatch != "" && (current == nil || !etagMatches(c.IfMatch, current.ETag)) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// findCalDAVEvent finds the user's event behind a resource name, or nil.
+// Events that were not imported or created over CalDAV are named after
+// their ID.
+func findCalDAVEvent(tx *gorm.DB, userID uint, name string) (*models.Event, error) {
+	uid, ok := strings.CutSuffix(name, ".ics")
+	if !ok || uid == "" {
+		return nil, nil
+	}
+
+	query := tx.Where("owner_id = ?", userID)
+	if id, ok := exportedEventID(uid); ok {
+		query = query.Where("ical_uid = ? OR (id = ? AND COALESCE(ical_uid, '') = '')", uid, id)
+	} else {
+		query = query.Where("ical_uid = ?", uid)
+	}
+
+	var events []models.Event
+	if err := query.Order("id").Limit(1).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+// lockCalDAVEvent is findCalDAVEvent for a write: it locks the user's row,
+// which also covers resources that do not exist yet, and then the event's.
+func lockCalDAVEvent(tx *gorm.DB, userID uint, name string) (*models.Event, error) {
+	var user models.User
+	if err := tx.Clauses(forUpdate).Select("id").First(&user, userID).Error; err != nil {
+		logger.Error("User not found: " + err.Error())
+		return nil, ErrUserNotFound
+	}
+	return findCalDAVEvent(tx.Clauses(forUpdate), userID, name)
+}
+
+// ListCalDAVResources lists the user's events overlapping from-to as CalDAV
+// resources. A zero bound leaves that side open.
+func ListCalDAVResources(userID uint, from, to time.Time) ([]CalDAVResource, error) {
+	events, err := GetUserEventsBetween(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]CalDAVResource, len(events))
+	for i := range events {
+		resources[i] = renderCalDAVResource(&events[i])
+	}
+	return resources, nil
+}
+
+func GetCalDAVResource(userID uint, name string) (*CalDAVResource, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	event, err := findCalDAVEvent(db.DB, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, ErrEventNotFound
+	}
+	resource := renderCalDAVResource(event)
+	return &resource, nil
+}
+
+// readCalDAVEvent reads the single VEVENT of a calendar object resource,
+// with the SlotSwapper status a client sent back, if any. Floating times are
+// read in loc.
+func readCalDAVEvent(body io.Reader, name string, loc *time.Location) (*ical.VEvent, string, error) {
+	cal, err := ical.Parse(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidCalendar, err)
+	}
+
+	vevents := ical.Events(cal, loc)
+	for _, v := range vevents {
+		if v.RRule != "" || v.RecurrenceID != nil {
+			return nil, "", ErrRecurringResource
+		}
+	}
+	if len(vevents) != 1 {
+		return nil, "", fmt.Errorf("%w: a calendar object must hold exactly one event", ErrInvalidCalendar)
+	}
+	v := &vevents[0]
+	if v.Err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidCalendar, v.Err)
+	}
+	if v.UID+".ics" != name {
+		return nil, "", fmt.Errorf("%w: UID %q does not match the resource name", ErrInvalidCalendar, v.UID)
+	}
+
+	var status string
+	for _, child := range cal.Children {
+		if child.Name == "VEVENT" {
+			status = strings.TrimSpace(child.Value("X-SLOTSWAPPER-STATUS"))
+		}
+	}
+	return v, status, nil
+}
+
+// formatCalDAVTime writes a time read from a resource the way the event
+// inputs take it: a date for all-day events, RFC3339 in UTC otherwise.
+func formatCalDAVTime(t time.Time, allDay bool) string {
+	if allDay {
+		return t.Format(dateLayout)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// PutCalDAVResource creates or replaces the event behind a resource with the
+// rules of CreateEvent and UpdateEventPartial: a new event goes into the
+// user's only team, and events in a pending swap cannot be changed. Only the
+// fields that differ are passed on, so saving an occurrence of a series
+// unchanged does not detach it. Times in UTC, as the server sends them, leave
+// the event's time zone alone. The conditions are checked and the write made
+// in one transaction, holding the user's row so concurrent writes to the
+// collection queue behind it. It reports whether the event was created.
+func PutCalDAVResource(userID uint, name string, body io.Reader, conditions CalDAVConditions, limits EventLimits) (*CalDAVResource, bool, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, false, errors.New("database connection is nil")
+	}
+
+	v, status, err := readCalDAVEvent(body, name, zoneOrUTC(userTimeZone(db.DB, userID)))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var resource CalDAVResource
+	created := false
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		event, err := lockCalDAVEvent(tx, userID, name)
+		if err != nil {
+			return err
+		}
+		var current *CalDAVResource
+		if event != nil {
+			existing := renderCalDAVResource(event)
+			current = &existing
+		}
+		if err := conditions.check(current); err != nil {
+			return err
+		}
+
+		start, end := entryTimes(v)
+		if event == nil {
+			input := &models.CreateEventInput{
+				Title:     v.Summary,
+				StartTime: formatCalDAVTime(start, v.AllDay),
+				EndTime:   formatCalDAVTime(end, v.AllDay),
+				AllDay:    v.AllDay,
+				ICalUID:   v.UID,
+			}
+			if v.Zone != nil {
+				zone := v.Zone.String()
+				input.TimeZone = &zone
+			}
+			if status != "" {
+				input.Status = &status
+			}
+			inserted, err := createEvent(tx, userID, input, limits)
+			if err != nil {
+				return err
+			}
+			resource, created = renderCalDAVResource(inserted), true
+			return nil
+		}
+
+		input := &models.UpdateEventInput{}
+		if v.Summary != event.Title {
+			input.Title = &v.Summary
+		}
+		allDayChanged := v.AllDay != event.AllDay
+		if allDayChanged {
+			input.AllDay = &v.AllDay
+		}
+		if allDayChanged || !start.Equal(event.StartTime) {
+			value := formatCalDAVTime(start, v.AllDay)
+			input.StartTime = &value
+		}
+		if allDayChanged || !end.Equal(event.EndTime) {
+			value := formatCalDAVTime(end, v.AllDay)
+			input.EndTime = &value
+		}
+		if v.Zone != nil && v.Zone.String() != event.TimeZone {
+			zone := v.Zone.String()
+			input.TimeZone = &zone
+		}
+		if status != "" && models.EventStatus(status) != event.Status {
+			input.Status = &status
+		}
+
+		updated, err := updateEventPartial(tx, event, userID, input, limits)
+		if err != nil {
+			return err
+		}
+		resource = renderCalDAVResource(updated)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &resource, created, nil
+}
+
+// DeleteCalDAVResource deletes the event behind a resource, checking the
+// conditions in the same transaction as the delete. Events held by a pending
+// swap request or cycle cannot be deleted.
+func DeleteCalDAVResource(userID uint, name string, conditions CalDAVConditions) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		event, err := lockCalDAVEvent(tx, userID, name)
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return ErrEventNotFound
+		}
+		resource := renderCalDAVResource(event)
+		if err := conditions.check(&resource); err != nil {
+			return err
+		}
+		if event.Status == models.EventStatusSwapPending {
+			return ErrDeleteSwapPending
+		}
+		return deleteEvent(tx, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Deleted CalDAV resource %s of user %d", name, userID))
+	return nil
+}
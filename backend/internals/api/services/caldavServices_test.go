@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderCalDAVResource(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	event := &models.Event{ID: 7, Title: "On-call", StartTime: start, EndTime: start.Add(time.Hour), Status: models.EventStatusBusy, UpdatedAt: start}
+
+	resource := renderCalDAVResource(event)
+	assert.Equal(t, "event-7@slotswapper.ics", resource.Name)
+	assert.NotContains(t, string(resource.Data), "METHOD:")
+	assert.Equal(t, resource, renderCalDAVResource(event))
+
+	t.Run("ETag Follows The Event", func(t *testing.T) {
+		changed := *event
+		changed.Status = models.EventStatusSwappable
+		assert.NotEqual(t, resource.ETag, renderCalDAVResource(&changed).ETag)
+	})
+
+	t.Run("Collection Tag Ignores Order", func(t *testing.T) {
+		other := *event
+		other.ID = 8
+		a, b := renderCalDAVResource(event), renderCalDAVResource(&other)
+		assert.Equal(t, CalDAVCollectionTag([]CalDAVResource{a, b}), CalDAVCollectionTag([]CalDAVResource{b, a}))
+		assert.NotEqual(t, CalDAVCollectionTag([]CalDAVResource{a, b}), CalDAVCollectionTag([]CalDAVResource{a}))
+	})
+}
+
+func TestCalDAVConditions(t *testing.T) {
+	current := &CalDAVResource{ETag: `"abc"`}
+
+	assert.NoError(t, CalDAVConditions{}.check(nil))
+	assert.NoError(t, CalDAVConditions{IfNoneMatch: "*"}.check(nil))
+	assert.ErrorIs(t, CalDAVConditions{IfNoneMatch: "*"}.check(current), ErrPreconditionFailed)
+	assert.NoError(t, CalDAVConditions{IfMatch: `"xyz", "abc"`}.check(current))
+	assert.NoError(t, CalDAVConditions{IfMatch: `W/"abc"`}.check(current))
+	assert.ErrorIs(t, CalDAVConditions{IfMatch: `"xyz"`}.check(current), ErrPreconditionFailed)
+	assert.ErrorIs(t, CalDAVConditions{IfMatch: "*"}.check(nil), ErrPreconditionFailed)
+}
+
+func calendarObject(lines ...string) *strings.Reader {
+	return strings.NewReader("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCALENDAR\r\n")
+}
+
+func TestReadCalDAVEvent(t *testing.T) {
+	v, status, err := readCalDAVEvent(calendarObject(
+		"BEGIN:VEVENT", "UID:abc", "SUMMARY:Standup", "DTSTART:20250602T090000Z", "DURATION:PT15M",
+		"X-SLOTSWAPPER-STATUS:SWAPPABLE", "END:VEVENT"), "abc.ics", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, "Standup", v.Summary)
+	assert.Equal(t, 15*time.Minute, v.End.Sub(v.Start))
+	assert.Equal(t, "SWAPPABLE", status)
+
+	_, _, err = readCalDAVEvent(calendarObject(
+		"BEGIN:VEVENT", "UID:abc", "DTSTART:20250602T090000Z", "RRULE:FREQ=DAILY", "END:VEVENT"), "abc.ics", time.UTC)
+	assert.ErrorIs(t, err, ErrRecurringResource)
+
+	_, _, err = readCalDAVEvent(calendarObject(
+		"BEGIN:VEVENT", "UID:abc", "DTSTART:20250602T090000Z", "END:VEVENT"), "other.ics", time.UTC)
+	assert.True(t, errors.Is(err, ErrInvalidCalendar))
+
+	_, _, err = readCalDAVEvent(calendarObject(
+		"BEGIN:VEVENT", "UID:a", "DTSTART:20250602T090000Z", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:b", "DTSTART:20250602T090000Z", "END:VEVENT"), "a.ics", time.UTC)
+	assert.True(t, errors.Is(err, ErrInvalidCalendar))
+
+	t.Run("Floating Times Are Read In The User's Zone", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		v, _, err := readCalDAVEvent(calendarObject(
+			"BEGIN:VEVENT", "UID:abc", "DTSTART:20250602T090000", "DTEND:20250602T100000", "END:VEVENT"), "abc.ics", berlin)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC), v.Start.UTC())
+		assert.Equal(t, berlin, v.Zone)
+	})
+}
+
+func TestCalDAVWritesCheckConditions(t *testing.T) {
+	conn := useTestDB(t)
+
+	user := models.User{Name: "Calendar", Email: "calendar@example.com", Password: "x"}
+	require.NoError(t, conn.Create(&user).Error)
+
+	object := func(summary string) *strings.Reader {
+		return calendarObject("BEGIN:VEVENT", "UID:abc", "SUMMARY:"+summary, "DTSTART:20250602T090000Z", "DURATION:PT1H", "END:VEVENT")
+	}
+
+	resource, created, err := PutCalDAVResource(user.ID, "abc.ics", object("Standup"), CalDAVConditions{IfNoneMatch: "*"}, EventLimits{})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	_, _, err = PutCalDAVResource(user.ID, "abc.ics", object("Again"), CalDAVConditions{IfNoneMatch: "*"}, EventLimits{})
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	updated, created, err := PutCalDAVResource(user.ID, "abc.ics", object("Retro"), CalDAVConditions{IfMatch: resource.ETag}, EventLimits{})
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	_, _, err = PutCalDAVResource(user.ID, "abc.ics", object("Stale"), CalDAVConditions{IfMatch: resource.ETag}, EventLimits{})
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.ErrorIs(t, DeleteCalDAVResource(user.ID, "abc.ics", CalDAVConditions{IfMatch: resource.ETag}), ErrPreconditionFailed)
+
+	require.NoError(t, DeleteCalDAVResource(user.ID, "abc.ics", CalDAVConditions{IfMatch: updated.ETag}))
+	var count int64
+	require.NoError(t, conn.Model(&models.Event{}).Where("owner_id = ?", user.ID).Count(&count).Error)
+	assert.Zero(t, count)
+
+	t.Run("Swap Pending Events", func(t *testing.T) {
+		_, _, err := PutCalDAVResource(user.ID, "held.ics", calendarObject("BEGIN:VEVENT", "UID:held", "SUMMARY:Held", "DTSTART:20250603T090000Z", "DURATION:PT1H", "END:VEVENT"), CalDAVConditions{}, EventLimits{})
+		require.NoError(t, err)
+		require.NoError(t, conn.Model(&models.Event{}).Where("ical_uid = ?", "held").Update("status", models.EventStatusSwapPending).Error)
+
+		assert.ErrorIs(t, DeleteCalDAVResource(user.ID, "held.ics", CalDAVConditions{}), ErrDeleteSwapPending)
+
+		var count int64
+		require.NoError(t, conn.Model(&models.Event{}).Where("ical_uid = ?", "held").Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("All Day Events", func(t *testing.T) {
+		offsite := calendarObject("BEGIN:VEVENT", "UID:offsite", "SUMMARY:Offsite", "DTSTART;VALUE=DATE:20250602", "DTEND;VALUE=DATE:20250603", "END:VEVENT")
+		resource, _, err := PutCalDAVResource(user.ID, "offsite.ics", offsite, CalDAVConditions{}, EventLimits{})
+		require.NoError(t, err)
+		assert.Contains(t, string(resource.Data), "DTSTART;VALUE=DATE:20250602\r\nDTEND;VALUE=DATE:20250603\r\n")
+	})
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/ical"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidFeedToken     = errors.New("invalid calendar feed token")
+	ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+)
+
+const (
+	calendarProdID = "-//SlotSwapper//Calendar//EN"
+	// calendarRefreshInterval is how often subscribed clients are asked to
+	// poll the feed.
+	calendarRefreshInterval = time.Hour
+)
+
+// eventUID is the iCalendar UID of an event. It depends only on the event's
+// ID, so it survives edits and changes of owner. Imported events keep the
+// UID they were imported with.
+func eventUID(event *models.Event) string {
+	if event.ICalUID != "" {
+		return event.ICalUID
+	}
+	return fmt.Sprintf("event-%d@slotswapper", event.ID)
+}
+
+// eventToICal renders an event as a VEVENT. A slot in a pending swap may
+// change hands and is shown as tentative; BUSY and SWAPPABLE slots are the
+// owner's until a swap is accepted.
+func eventToICal(event *models.Event) ical.Event {
+	status := ical.StatusConfirmed
+	if event.Status == models.EventStatusSwapPending {
+		status = ical.StatusTentative
+	}
+
+	return ical.Event{
+		UID:          eventUID(event),
+		Summary:      event.Title,
+		Start:        event.StartTime,
+		End:          event.EndTime,
+		AllDay:       event.AllDay,
+		Status:       status,
+		Sequence:     event.Sequence,
+		Created:      event.CreatedAt,
+		LastModified: event.UpdatedAt,
+		Categories:   []string{string(event.Status)},
+		Extra:        []ical.Property{{Name: "X-SLOTSWAPPER-STATUS", Value: string(event.Status)}},
+	}
+}
+
+func renderUserCalendar(user *models.User, events []models.Event, now time.Time) []byte {
+	cal := &ical.Calendar{
+		ProdID:          calendarProdID,
+		Method:          ical.MethodPublish,
+		Name:            "SlotSwapper - " + user.Name,
+		RefreshInterval: calendarRefreshInterval,
+		Events:          make([]ical.Event, len(events)),
+	}
+	for i := range events {
+		cal.Events[i] = eventToICal(&events[i])
+	}
+	return cal.Marshal(now)
+}
+
+func exportUserCalendar(user *models.User) ([]byte, error) {
+	events, err := GetUserEventsBetween(user.ID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return renderUserCalendar(user, events, time.Now()), nil
+}
+
+// ExportUserCalendar renders all of the user's events as an iCalendar file.
+func ExportUserCalendar(userID uint) ([]byte, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var user models.User
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	return exportUserCalendar(&user)
+}
+
+// CreateCalendarFeedToken issues the user's feed token, replacing any earlier
+// one so old subscription URLs stop working. The plain token is returned
+// only here.
+func CreateCalendarFeedToken(userID uint) (string, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return "", errors.New("database connection is nil")
+	}
+
+	token, err := pkg.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.CalendarFeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CalendarFeedToken{
+			UserID:    userID,
+			TokenHash: pkg.HashToken(token),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info(fmt.Sprintf("Calendar feed token issued for user %d", userID))
+	return token, nil
+}
+
+// GetCalendarFeedToken returns the user's feed token row, without the token.
+func GetCalendarFeedToken(userID uint) (*models.CalendarFeedToken, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var feed models.CalendarFeedToken
+	if err := db.DB.Where("user_id = ?", userID).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func RevokeCalendarFeedToken(userID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	result := db.DB.Where("user_id = ?", userID).Delete(&models.CalendarFeedToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCalendarFeedNotFound
+	}
+
+	logger.Info(fmt.Sprintf("Calendar feed token revoked for user %d", userID))
+	return nil
+}
+
+// CalendarFeed renders the calendar of the user a feed token belongs to.
+// Tokens of disabled accounts are refused.
+func CalendarFeed(token string) ([]byte, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var feed models.CalendarFeedToken
+	if err := db.DB.Preload("User").Where("token_hash = ?", pkg.HashToken(token)).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidFeedToken
+		}
+		return nil, err
+	}
+	if feed.User.ID == 0 || feed.User.DisabledAt != nil {
+		return nil, ErrInvalidFeedToken
+	}
+
+	if err := db.DB.Model(&feed).Update("last_used_at", time.Now()).Error; err != nil {
+		logger.Error(fmt.Sprintf("Failed to record use of calendar feed %d: %s", feed.ID, err.Error()))
+	}
+
+	return exportUserCalendar(&feed.User)
+}
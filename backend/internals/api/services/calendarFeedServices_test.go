@@ -0,0 +1,61 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/ical"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventToICal(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	event := &models.Event{ID: 7, Title: "On-call", StartTime: start, EndTime: start.Add(time.Hour), Sequence: 3}
+
+	for status, want := range map[models.EventStatus]string{
+		models.EventStatusBusy:        ical.StatusConfirmed,
+		models.EventStatusSwappable:   ical.StatusConfirmed,
+		models.EventStatusSwapPending: ical.StatusTentative,
+	} {
+		event.Status = status
+		vevent := eventToICal(event)
+		assert.Equal(t, want, vevent.Status, status)
+		assert.Equal(t, "event-7@slotswapper", vevent.UID)
+		assert.Equal(t, 3, vevent.Sequence)
+	}
+
+	t.Run("UID Survives A Change Of Owner", func(t *testing.T) {
+		swapped := *event
+		swapped.OwnerID = 2
+		assert.Equal(t, eventUID(event), eventUID(&swapped))
+	})
+
+	t.Run("All Day Events", func(t *testing.T) {
+		day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+		allDay := models.Event{ID: 8, Title: "Offsite", StartTime: day, EndTime: day.AddDate(0, 0, 1), AllDay: true}
+		assert.True(t, eventToICal(&allDay).AllDay)
+		assert.False(t, eventToICal(event).AllDay)
+	})
+
+	t.Run("Imported Events Keep Their UID", func(t *testing.T) {
+		imported := *event
+		imported.ICalUID = "standup@example.com"
+		assert.Equal(t, "standup@example.com", eventToICal(&imported).UID)
+	})
+}
+
+func TestRenderUserCalendar(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	events := []models.Event{
+		{ID: 1, Title: "Standup", StartTime: start, EndTime: start.Add(15 * time.Minute), Status: models.EventStatusBusy},
+		{ID: 2, Title: "On-call", StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour), Status: models.EventStatusSwappable},
+	}
+
+	out := string(renderUserCalendar(&models.User{Name: "Sam"}, events, start))
+
+	assert.Equal(t, 2, strings.Count(out, "BEGIN:VEVENT"))
+	assert.Contains(t, out, "X-WR-CALNAME:SlotSwapper - Sam\r\n")
+	assert.Contains(t, out, "X-SLOTSWAPPER-STATUS:SWAPPABLE\r\n")
+}
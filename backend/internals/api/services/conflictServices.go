@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
+)
+
+// ConflictError is returned when a swap would give UserID a slot that
+// overlaps their calendar. Blocking is true when the user's policy refuses the
+// swap outright, false when they may retry with ignore_conflicts.
+type ConflictError struct {
+	UserID    uint
+	Blocking  bool
+	Conflicts []models.EventConflict
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("swap conflicts with %d existing event(s) on the recipient's calendar", len(e.Conflicts))
+}
+
+func eventsOverlap(a *models.Event, b *models.Event) bool {
+	return a.StartTime.Before(b.EndTime) && b.StartTime.Before(a.EndTime)
+}
+
+// findConflicts returns the events of calendar that overlap incoming, leaving
+// out incoming itself and the event with ID skipID.
+func findConflicts(incoming *models.Event, calendar []models.Event, skipID uint) []models.EventConflict {
+	var conflicts []models.EventConflict
+	for i := range calendar {
+		existing := &calendar[i]
+		if existing.ID == incoming.ID || existing.ID == skipID || !eventsOverlap(incoming, existing) {
+			continue
+		}
+
+		start := existing.StartTime
+		if incoming.StartTime.After(start) {
+			start = incoming.StartTime
+		}
+		end := existing.EndTime
+		if incoming.EndTime.Before(end) {
+			end = incoming.EndTime
+		}
+
+		conflicts = append(conflicts, models.EventConflict{
+			EventID:          incoming.ID,
+			ConflictingID:    existing.ID,
+			ConflictingTitle: existing.Title,
+			ConflictingStart: existing.StartTime,
+			ConflictingEnd:   existing.EndTime,
+			OverlapMinutes:   int(end.Sub(start).Minutes()),
+		})
+	}
+	return conflicts
+}
+
+// enforceConflictPolicy checks incoming against the BUSY events of
+// recipientID, ignoring the event they give away in the same swap. The acting
+// user's WARN policy can be overridden with ignoreConflicts; the other
+// party's calendar is only enforced when their policy is BLOCK.
+func enforceConflictPolicy(tx *gorm.DB, recipientID uint, incoming *models.Event, givenAwayID uint, isActor bool, ignoreConflicts bool) error {
+	var recipient models.User
+	if err := tx.Select("id", "conflict_policy").First(&recipient, recipientID).Error; err != nil {
+		logger.Error("Failed to load conflict policy: " + err.Error())
+		return err
+	}
+
+	switch recipient.ConflictPolicy {
+	case models.ConflictPolicyAllow:
+		return nil
+	case models.ConflictPolicyBlock:
+	default:
+		if !isActor || ignoreConflicts {
+			return nil
+		}
+	}
+
+	var calendar []models.Event
+	if err := tx.Where("owner_id = ? AND status = ? AND start_time < ? AND end_time > ?",
+		recipientID, models.EventStatusBusy, incoming.EndTime, incoming.StartTime).Find(&calendar).Error; err != nil {
+		logger.Error("Failed to fetch calendar for conflict check: " + err.Error())
+		return err
+	}
+
+	conflicts := findConflicts(incoming, calendar, givenAwayID)
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	return &ConflictError{
+		UserID:    recipientID,
+		Blocking:  recipient.ConflictPolicy == models.ConflictPolicyBlock,
+		Conflicts: conflicts,
+	}
+}
+
+// GetEventConflicts reports overlaps on the user's calendar. With an eventID
+// it checks that single event, typically a marketplace slot, against the
+// user's BUSY events; otherwise it lists every overlapping pair of the user's
+// own events. Events the user may not view are reported as not found.
+func GetEventConflicts(userID uint, eventID *uint) ([]models.EventConflict, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	if eventID != nil {
+		event, err := GetEventByID(*eventID)
+		if err != nil {
+			return nil, err
+		}
+
+		scope, err := loadTeamScope(db.DB, userID)
+		if err != nil {
+			logger.Error("Failed to load team scope: " + err.Error())
+			return nil, err
+		}
+		if !scope.canView(userID, event) {
+			return nil, ErrEventNotFound
+		}
+
+		var calendar []models.Event
+		if err := db.DB.Where("owner_id = ? AND status = ?", userID, models.EventStatusBusy).Find(&calendar).Error; err != nil {
+			logger.Error("Failed to fetch calendar for conflict check: " + err.Error())
+			return nil, err
+		}
+
+		return findConflicts(event, calendar, 0), nil
+	}
+
+	events, err := GetUserEvents(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []models.EventConflict
+	for i := range events {
+		conflicts = append(conflicts, findConflicts(&events[i], events[i+1:], 0)...)
+	}
+
+	return conflicts, nil
+}
+
+func UpdateConflictPolicy(userID uint, policy models.ConflictPolicy) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	if err := db.DB.Model(&models.User{}).Where("id = ?", userID).Update("conflict_policy", policy).Error; err != nil {
+		logger.Error("Failed to update conflict policy: " + err.Error())
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("User %d conflict policy set to %s", userID, policy))
+	return nil
+}
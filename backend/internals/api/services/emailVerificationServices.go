@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrEmailNotVerified         = errors.New("email address not verified")
+	ErrEmailTaken               = errors.New("email already exists")
+)
+
+func emailVerificationMessage(user *models.User, to, link string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: "Confirm your SlotSwapper email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that this is your email address by opening the link below within %s:\n\n%s\n\n"+
+			"If you didn't create a SlotSwapper account, you can ignore this email.\n",
+			user.Name, humanDuration(ttl), link),
+	}
+}
+
+// SendEmailVerification mails user a link confirming their current email
+// address. Earlier links for the user stop working.
+func SendEmailVerification(user *models.User, cfg *config.Config, m mailer.Mailer) error {
+	return sendEmailVerificationTo(user, user.Email, cfg, m)
+}
+
+// sendEmailVerificationTo mails a verification link for email, which is
+// either the user's current address or the one they are changing to.
+func sendEmailVerificationTo(user *models.User, email string, cfg *config.Config, m mailer.Mailer) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	token, err := pkg.NewTokenID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.EmailVerificationToken{
+			UserID:    user.ID,
+			Email:     email,
+			TokenHash: pkg.HashToken(token),
+			ExpiresAt: now.Add(cfg.EMAIL_VERIFICATION_TTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link := appLink(cfg.APP_BASE_URL, "/verify-email", token)
+	if err := m.Send(emailVerificationMessage(user, email, link, cfg.EMAIL_VERIFICATION_TTL)); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Verification email sent to user %d", user.ID))
+	return nil
+}
+
+// ResendEmailVerification sends a fresh verification link to a user who has
+// not verified their email yet, or to the address they are changing to.
+func ResendEmailVerification(userID uint, cfg *config.Config, m mailer.Mailer) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	var user models.User
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	if user.PendingEmail != "" {
+		return sendEmailVerificationTo(&user, user.PendingEmail, cfg, m)
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	return SendEmailVerification(&user, cfg, m)
+}
+
+// VerifyEmail consumes a verification token and marks the user's email as
+// verified. The token only counts if it was sent to the user's current email
+// or to the address they asked to change to; the latter then becomes their
+// email.
+func VerifyEmail(token string) (*models.User, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	now := time.Now()
+	var user models.User
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var verification models.EmailVerificationToken
+		if err := tx.Clauses(forUpdate).
+			Where("token_hash = ?", pkg.HashToken(token)).
+			First(&verification).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+
+		if verification.UsedAt != nil || !now.Before(verification.ExpiresAt) {
+			return ErrInvalidVerificationToken
+		}
+
+		if err := tx.Clauses(forUpdate).First(&user, verification.UserID).Error; err != nil {
+			return ErrInvalidVerificationToken
+		}
+		changing := user.PendingEmail != "" && user.PendingEmail == verification.Email
+		if user.Email != verification.Email && !changing {
+			return ErrInvalidVerificationToken
+		}
+
+		if err := tx.Model(&verification).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		if changing {
+			var taken int64
+			if err := tx.Model(&models.User{}).Where("email = ? AND id <> ?", verification.Email, user.ID).Count(&taken).Error; err != nil {
+				return err
+			}
+			if taken > 0 {
+				return ErrEmailTaken
+			}
+
+			previous := user.Email
+			user.Email, user.PendingEmail, user.EmailVerifiedAt = verification.Email, "", &now
+			if err := tx.Model(&user).Updates(map[string]interface{}{
+				"email":             user.Email,
+				"pending_email":     "",
+				"email_verified_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			logger.Info(fmt.Sprintf("User %d changed email from %s to %s", user.ID, previous, user.Email))
+			return nil
+		}
+
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &now
+			if err := tx.Model(&user).Update("email_verified_at", now).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Email verified for user %d", user.ID))
+	return &user, nil
+}
+
+// IsEmailVerified reports whether the user has confirmed their email.
+func IsEmailVerified(userID uint) (bool, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return false, errors.New("database connection is nil")
+	}
+
+	var user models.User
+	if err := db.DB.Select("id", "email_verified_at").First(&user, userID).Error; err != nil {
+		return false, errors.New("user not found")
+	}
+	return user.EmailVerifiedAt != nil, nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestEmailVerificationMessage(t *testing.T) {
+	user := &models.User{Name: "Sam", Email: "sam@example.com"}
+	link := appLink("https://app.example.com", "/verify-email", "abc")
+	msg := emailVerificationMessage(user, user.Email, link, 48*time.Hour)
+
+	assert.Equal(t, "sam@example.com", msg.To)
+	assert.Contains(t, msg.Body, "https://app.example.com/verify-email?token=abc")
+	assert.Contains(t, msg.Body, "within 48 hours")
+}
+
+// createVerificationToken stores a verification link for email and returns
+// its token.
+func createVerificationToken(t *testing.T, conn *gorm.DB, userID uint, email string) string {
+	t.Helper()
+	token, err := pkg.NewTokenID()
+	require.NoError(t, err)
+	require.NoError(t, conn.Create(&models.EmailVerificationToken{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: pkg.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}).Error)
+	return token
+}
+
+func TestVerifyEmailAppliesPendingChange(t *testing.T) {
+	conn := useTestDB(t)
+	user := createTestUser(t, conn, "Mover")
+	require.NoError(t, conn.Model(&user).Update("pending_email", "mover@new.example.com").Error)
+	token := createVerificationToken(t, conn, user.ID, "mover@new.example.com")
+
+	verified, err := VerifyEmail(token)
+	require.NoError(t, err)
+	assert.Equal(t, "mover@new.example.com", verified.Email)
+	assert.Empty(t, verified.PendingEmail)
+	assert.NotNil(t, verified.EmailVerifiedAt)
+
+	var stored models.User
+	require.NoError(t, conn.First(&stored, user.ID).Error)
+	assert.Equal(t, "mover@new.example.com", stored.Email)
+	assert.Empty(t, stored.PendingEmail)
+
+	_, err = VerifyEmail(token)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken, "a link works once")
+}
+
+func TestVerifyEmailRejectsTakenAddress(t *testing.T) {
+	conn := useTestDB(t)
+	user := createTestUser(t, conn, "Mover")
+	require.NoError(t, conn.Model(&user).Update("pending_email", "squatter@example.com").Error)
+	token := createVerificationToken(t, conn, user.ID, "squatter@example.com")
+	createTestUser(t, conn, "Squatter")
+
+	_, err := VerifyEmail(token)
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	var stored models.User
+	require.NoError(t, conn.First(&stored, user.ID).Error)
+	assert.Equal(t, "mover@example.com", stored.Email)
+	assert.Equal(t, "squatter@example.com", stored.PendingEmail)
+}
@@ -0,0 +1,506 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/ical"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCalendar = errors.New("invalid iCalendar file")
+
+// maxImportEvents bounds how many VEVENTs one import may hold.
+const maxImportEvents = 1000
+
+// eventImporter imports the VEVENTs of one file for one user. Every event
+// goes into the same team, which is resolved once for the whole file.
+type eventImporter struct {
+	userID  uint
+	teamID  *uint
+	orgID   *uint
+	zone    string
+	limits  EventLimits
+	horizon time.Duration
+	now     time.Time
+}
+
+// exportedEventID reads the event ID back out of a UID made by eventUID, so
+// re-importing an exported calendar updates the events it came from.
+func exportedEventID(uid string) (uint, bool) {
+	rest, ok := strings.CutPrefix(uid, "event-")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, "@slotswapper")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(rest, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// validationReason is the report reason for a validation error, without the
+// generic prefix.
+func validationReason(err *ValidationError) string {
+	return strings.TrimPrefix(err.Error(), "validation failed: ")
+}
+
+// entryZone is the name of the zone an entry's start was written in, or
+// fallback when it was written in UTC.
+func entryZone(v *ical.VEvent, fallback string) string {
+	if v.Zone == nil {
+		return fallback
+	}
+	return v.Zone.String()
+}
+
+// entryTimes are the times an entry is stored with: its dates as midnight UTC
+// when it is all-day, its instants otherwise.
+func entryTimes(v *ical.VEvent) (time.Time, time.Time) {
+	if v.AllDay {
+		return floatingDate(v.Start), floatingDate(v.End)
+	}
+	return v.Start.UTC(), v.End.UTC()
+}
+
+func sameTimes(event *models.Event, start, end time.Time) bool {
+	return event.StartTime.Equal(start) && event.EndTime.Equal(end)
+}
+
+// mergeExDates adds the dates of extra that exDates does not have yet and
+// reports whether it added any.
+func mergeExDates(exDates []time.Time, extra []time.Time) ([]time.Time, bool) {
+	have := make(map[int64]bool, len(exDates))
+	for _, exDate := range exDates {
+		have[exDate.Unix()] = true
+	}
+	added := false
+	for _, exDate := range extra {
+		if !have[exDate.Unix()] {
+			exDates = append(exDates, exDate.UTC())
+			have[exDate.Unix()] = true
+			added = true
+		}
+	}
+	return exDates, added
+}
+
+// ImportCalendar imports the events of an iCalendar file into the user's
+// calendar. Events are matched to earlier imports by UID, so importing the
+// same file again updates what changed instead of copying it. Each VEVENT
+// is imported in its own transaction and reported on its own; an error is
+// returned only when the file cannot be read at all. Floating times and
+// all-day events are read in the calendar's X-WR-TIMEZONE, or else the
+// user's time zone.
+func ImportCalendar(userID uint, r io.Reader, teamID *uint, limits EventLimits, horizon time.Duration) (*models.EventImportReport, error) {
+	cal, err := ical.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCalendar, err)
+	}
+	count := 0
+	for _, child := range cal.Children {
+		if child.Name == "VEVENT" {
+			count++
+		}
+	}
+	if count > maxImportEvents {
+		return nil, fmt.Errorf("%w: more than %d events", ErrInvalidCalendar, maxImportEvents)
+	}
+
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	zone := userTimeZone(db.DB, userID)
+	vevents := ical.Events(cal, zoneOrUTC(zone))
+
+	errs := &ValidationError{}
+	if teamID == nil {
+		teamID = defaultEventTeam(db.DB, userID, errs)
+	}
+	var orgID *uint
+	if teamID != nil {
+		orgID = resolveEventTeam(db.DB, userID, *teamID, errs)
+	}
+	if err := errs.errOrNil(); err != nil {
+		return nil, err
+	}
+
+	imp := &eventImporter{
+		userID:  userID,
+		teamID:  teamID,
+		orgID:   orgID,
+		zone:    zone,
+		limits:  limits,
+		horizon: horizon,
+		now:     time.Now(),
+	}
+
+	report := &models.EventImportReport{Items: make([]models.EventImportItem, len(vevents))}
+	for i := range vevents {
+		item := &report.Items[i]
+		item.UID, item.Summary = vevents[i].UID, vevents[i].Summary
+		if vevents[i].RecurrenceID != nil {
+			item.RecurrenceID = ical.FormatTime(*vevents[i].RecurrenceID)
+		}
+	}
+
+	// Recurring events are imported before the entries that change one of
+	// their occurrences, wherever those appear in the file.
+	seen := map[string]bool{}
+	for _, overrides := range []bool{false, true} {
+		for i := range vevents {
+			if (vevents[i].RecurrenceID != nil) == overrides {
+				imp.importItem(&vevents[i], &report.Items[i], seen)
+			}
+		}
+	}
+
+	for _, item := range report.Items {
+		switch item.Result {
+		case models.EventImportCreated:
+			report.Created++
+		case models.EventImportUpdated:
+			report.Updated++
+		case models.EventImportSkipped:
+			report.Skipped++
+		default:
+			report.Invalid++
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Calendar imported for user %d: %d created, %d updated, %d skipped, %d invalid",
+		userID, report.Created, report.Updated, report.Skipped, report.Invalid))
+	return report, nil
+}
+
+func (imp *eventImporter) importItem(v *ical.VEvent, item *models.EventImportItem, seen map[string]bool) {
+	if v.Err != nil {
+		item.Result, item.Reason = models.EventImportInvalid, v.Err.Error()
+		return
+	}
+
+	key := v.UID + "|" + item.RecurrenceID
+	if seen[key] {
+		item.Result, item.Reason = models.EventImportSkipped, "UID appears more than once in the file"
+		return
+	}
+	seen[key] = true
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		switch {
+		case v.RecurrenceID != nil:
+			return imp.importOccurrence(tx, v, item)
+		case v.RRule != "":
+			return imp.importSeries(tx, v, item)
+		default:
+			return imp.importEvent(tx, v, item)
+		}
+	})
+	if err == nil {
+		return
+	}
+
+	item.EventID, item.SeriesID = nil, nil
+	item.Result = models.EventImportInvalid
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		item.Reason = validationReason(validationErr)
+		return
+	}
+	logger.Error(fmt.Sprintf("Failed to import event %s for user %d: %s", v.UID, imp.userID, err.Error()))
+	item.Reason = "could not be saved"
+}
+
+// findImportedEvent finds the event a UID was imported as, or the exported
+// event it names. Events the user has since swapped away are found too, so
+// they are not imported a second time.
+func (imp *eventImporter) findImportedEvent(tx *gorm.DB, uid string) (*models.Event, error) {
+	query := tx.Clauses(forUpdate).Where("series_id IS NULL AND ical_uid = ?", uid)
+	if id, ok := exportedEventID(uid); ok {
+		query = tx.Clauses(forUpdate).Where("(series_id IS NULL AND ical_uid = ?) OR id = ?", uid, id)
+	}
+	previouslyOwned := tx.Model(&models.EventOwnershipHistory{}).Select("event_id").Where("previous_owner_id = ?", imp.userID)
+
+	var events []models.Event
+	if err := query.Where("owner_id = ? OR id IN (?)", imp.userID, previouslyOwned).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	for i := range events {
+		if events[i].OwnerID == imp.userID {
+			return &events[i], nil
+		}
+	}
+	if len(events) > 0 {
+		return &events[0], nil
+	}
+	return nil, nil
+}
+
+func (imp *eventImporter) findImportedSeries(tx *gorm.DB, uid string) (*models.EventSeries, error) {
+	var series models.EventSeries
+	err := tx.Clauses(forUpdate).Where("ical_uid = ? AND owner_id = ?", uid, imp.userID).First(&series).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// importEvent creates or updates a single, non-recurring event.
+func (imp *eventImporter) importEvent(tx *gorm.DB, v *ical.VEvent, item *models.EventImportItem) error {
+	if v.Status == ical.StatusCancelled {
+		item.Result, item.Reason = models.EventImportSkipped, "event is cancelled"
+		return nil
+	}
+
+	start, end := entryTimes(v)
+	errs := &ValidationError{}
+	validateEventTitle(v.Summary, errs)
+	validateEventTimes(start, end, v.AllDay, imp.limits, errs)
+	if err := errs.errOrNil(); err != nil {
+		return err
+	}
+
+	if series, err := imp.findImportedSeries(tx, v.UID); err != nil {
+		return err
+	} else if series != nil {
+		item.SeriesID = &series.ID
+		item.Result, item.Reason = models.EventImportSkipped, "was imported as a recurring event"
+		return nil
+	}
+
+	event, err := imp.findImportedEvent(tx, v.UID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		event = &models.Event{
+			Title:          v.Summary,
+			StartTime:      start,
+			EndTime:        end,
+			AllDay:         v.AllDay,
+			TimeZone:       entryZone(v, imp.zone),
+			Status:         models.EventStatusBusy,
+			OwnerID:        imp.userID,
+			TeamID:         imp.teamID,
+			OrganizationID: imp.orgID,
+			ICalUID:        v.UID,
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		item.EventID = &event.ID
+		item.Result = models.EventImportCreated
+		return nil
+	}
+
+	item.EventID = &event.ID
+	zone := entryZone(v, event.TimeZone)
+	switch {
+	case event.OwnerID != imp.userID:
+		item.Result, item.Reason = models.EventImportSkipped, "event was swapped to another user"
+		return nil
+	case event.Status == models.EventStatusSwapPending:
+		item.Result, item.Reason = models.EventImportSkipped, "event has a pending swap"
+		return nil
+	case event.Title == v.Summary && sameTimes(event, start, end) && event.AllDay == v.AllDay && event.TimeZone == zone:
+		item.Result, item.Reason = models.EventImportSkipped, "unchanged"
+		return nil
+	}
+
+	if !sameTimes(event, start, end) || event.AllDay != v.AllDay {
+		event.Sequence++
+	}
+	event.Title, event.StartTime, event.EndTime, event.AllDay, event.TimeZone = v.Summary, start, end, v.AllDay, zone
+	event.Overridden = event.SeriesID != nil
+	if err := tx.Save(event).Error; err != nil {
+		return err
+	}
+	item.Result = models.EventImportUpdated
+	return nil
+}
+
+// importSeries creates or updates a recurring event. Exception dates are
+// added to the ones the series already has, so occurrences the user deleted
+// here are not brought back.
+func (imp *eventImporter) importSeries(tx *gorm.DB, v *ical.VEvent, item *models.EventImportItem) error {
+	if v.Status == ical.StatusCancelled {
+		item.Result, item.Reason = models.EventImportSkipped, "event is cancelled"
+		return nil
+	}
+
+	errs := &ValidationError{}
+	validateEventTitle(v.Summary, errs)
+	validateEventTimes(v.Start, v.End, false, imp.limits, errs)
+	rule := parseSeriesRule(v.RRule, errs)
+	if err := errs.errOrNil(); err != nil {
+		return err
+	}
+	start, end := v.Start.UTC(), v.End.UTC()
+
+	if event, err := imp.findImportedEvent(tx, v.UID); err != nil {
+		return err
+	} else if event != nil {
+		item.EventID = &event.ID
+		item.Result, item.Reason = models.EventImportSkipped, "was imported as a single event"
+		return nil
+	}
+
+	series, err := imp.findImportedSeries(tx, v.UID)
+	if err != nil {
+		return err
+	}
+	// A rule written in UTC is expanded in UTC.
+	zone := entryZone(v, time.UTC.String())
+	if series == nil {
+		series = &models.EventSeries{
+			Title:          v.Summary,
+			StartTime:      start,
+			EndTime:        end,
+			TimeZone:       zone,
+			RRule:          rule.String(),
+			ExDates:        normalizeExDates(v.ExDates),
+			Status:         models.EventStatusBusy,
+			OwnerID:        imp.userID,
+			TeamID:         imp.teamID,
+			OrganizationID: imp.orgID,
+			ICalUID:        v.UID,
+		}
+		validateSeriesRule(series, rule, errs)
+		if err := errs.errOrNil(); err != nil {
+			return err
+		}
+		if err := tx.Create(series).Error; err != nil {
+			return err
+		}
+		if _, err := syncSeriesOccurrences(tx, series, rule, time.Time{}, imp.now.Add(imp.horizon), false); err != nil {
+			return err
+		}
+		item.SeriesID = &series.ID
+		item.Result = models.EventImportCreated
+		return nil
+	}
+
+	item.SeriesID = &series.ID
+	exDates, exDatesAdded := mergeExDates(series.ExDates, v.ExDates)
+	if series.Title == v.Summary && series.StartTime.Equal(start) && series.EndTime.Equal(end) &&
+		zoneOrUTC(series.TimeZone).String() == zone && series.RRule == rule.String() && !exDatesAdded {
+		item.Result, item.Reason = models.EventImportSkipped, "unchanged"
+		return nil
+	}
+
+	series.Title, series.StartTime, series.EndTime, series.TimeZone = v.Summary, start, end, zone
+	series.RRule, series.ExDates = rule.String(), exDates
+	validateSeriesRule(series, rule, errs)
+	if err := errs.errOrNil(); err != nil {
+		return err
+	}
+	if err := tx.Save(series).Error; err != nil {
+		return err
+	}
+	if _, err := syncSeriesOccurrences(tx, series, rule, imp.now, imp.now.Add(imp.horizon), true); err != nil {
+		return err
+	}
+	item.Result = models.EventImportUpdated
+	return nil
+}
+
+// importOccurrence applies an entry with a RECURRENCE-ID to the occurrence
+// of an imported series it names. A cancelled entry removes the occurrence
+// and keeps the series from creating it again.
+func (imp *eventImporter) importOccurrence(tx *gorm.DB, v *ical.VEvent, item *models.EventImportItem) error {
+	series, err := imp.findImportedSeries(tx, v.UID)
+	if err != nil {
+		return err
+	}
+	if series == nil {
+		item.Result, item.Reason = models.EventImportInvalid, "recurring event with this UID was not imported"
+		return nil
+	}
+	item.SeriesID = &series.ID
+	occurrenceStart := v.RecurrenceID.UTC()
+
+	var event *models.Event
+	var occurrences []models.Event
+	if err := tx.Clauses(forUpdate).Where("series_id = ? AND occurrence_start = ?", series.ID, occurrenceStart).
+		Limit(1).Find(&occurrences).Error; err != nil {
+		return err
+	}
+	if len(occurrences) > 0 {
+		event = &occurrences[0]
+		item.EventID = &event.ID
+	}
+
+	switch {
+	case event != nil && event.OwnerID != imp.userID:
+		item.Result, item.Reason = models.EventImportSkipped, "occurrence was swapped to another user"
+		return nil
+	case event != nil && event.Status == models.EventStatusSwapPending:
+		item.Result, item.Reason = models.EventImportSkipped, "occurrence has a pending swap"
+		return nil
+	}
+
+	if v.Status == ical.StatusCancelled {
+		exDates, added := mergeExDates(series.ExDates, []time.Time{occurrenceStart})
+		if !added && event == nil {
+			item.Result, item.Reason = models.EventImportSkipped, "unchanged"
+			return nil
+		}
+		series.ExDates = exDates
+		if err := tx.Save(series).Error; err != nil {
+			return err
+		}
+		if event != nil {
+			if err := tx.Delete(event).Error; err != nil {
+				return err
+			}
+		}
+		item.EventID = nil
+		item.Result = models.EventImportUpdated
+		return nil
+	}
+
+	if event == nil {
+		item.Result, item.Reason = models.EventImportSkipped, "occurrence is not on the calendar yet"
+		return nil
+	}
+
+	errs := &ValidationError{}
+	validateEventTitle(v.Summary, errs)
+	validateEventTimes(v.Start, v.End, false, imp.limits, errs)
+	if err := errs.errOrNil(); err != nil {
+		return err
+	}
+	start, end := v.Start.UTC(), v.End.UTC()
+	zone := entryZone(v, event.TimeZone)
+	if event.Title == v.Summary && sameTimes(event, start, end) && event.TimeZone == zone {
+		item.Result, item.Reason = models.EventImportSkipped, "unchanged"
+		return nil
+	}
+
+	if !sameTimes(event, start, end) {
+		event.Sequence++
+	}
+	event.Title, event.StartTime, event.EndTime, event.TimeZone = v.Summary, start, end, zone
+	event.Overridden = true
+	if err := tx.Save(event).Error; err != nil {
+		return err
+	}
+	item.Result = models.EventImportUpdated
+	return nil
+}
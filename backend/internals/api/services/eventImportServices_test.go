@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportedEventID(t *testing.T) {
+	id, ok := exportedEventID(eventUID(&models.Event{ID: 42}))
+	assert.True(t, ok)
+	assert.Equal(t, uint(42), id)
+
+	for _, uid := range []string{"42@slotswapper", "event-42@example.com", "event-x@slotswapper", "event-@slotswapper"} {
+		_, ok := exportedEventID(uid)
+		assert.False(t, ok, uid)
+	}
+}
+
+func TestMergeExDates(t *testing.T) {
+	day := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	berlin := time.FixedZone("CEST", 2*60*60)
+
+	merged, added := mergeExDates([]time.Time{day}, []time.Time{day.In(berlin)})
+	assert.False(t, added)
+	assert.Len(t, merged, 1)
+
+	merged, added = mergeExDates([]time.Time{day}, []time.Time{day.AddDate(0, 0, 1).In(berlin), day.AddDate(0, 0, 1)})
+	assert.True(t, added)
+	assert.Equal(t, []time.Time{day, day.AddDate(0, 0, 1)}, merged)
+}
+
+func TestImportCalendarRejectsUnreadableFiles(t *testing.T) {
+	_, err := ImportCalendar(1, strings.NewReader("BEGIN:VEVENT\r\nEND:VEVENT\r\n"), nil, EventLimits{}, time.Hour)
+	assert.True(t, errors.Is(err, ErrInvalidCalendar))
+
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	for i := 0; i <= maxImportEvents; i++ {
+		b.WriteString("BEGIN:VEVENT\r\nUID:x\r\nEND:VEVENT\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	_, err = ImportCalendar(1, strings.NewReader(b.String()), nil, EventLimits{}, time.Hour)
+	assert.True(t, errors.Is(err, ErrInvalidCalendar))
+}
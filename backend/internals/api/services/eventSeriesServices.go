@@ -0,0 +1,472 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/rrule"
+	"gorm.io/gorm"
+)
+
+var ErrEventSeriesNotFound = errors.New("event series not found")
+
+func parseSeriesRule(s string, errs *ValidationError) rrule.Rule {
+	rule, err := rrule.Parse(s)
+	if err != nil {
+		errs.add("rrule", err.Error())
+	}
+	return rule
+}
+
+// seriesOccurrenceStarts expands the series up to end, leaving out its
+// exception dates. Rules are expanded in the series' time zone, so an
+// occurrence keeps its wall-clock time when daylight saving time starts or
+// ends; the starts are returned in UTC.
+func seriesOccurrenceStarts(series *models.EventSeries, rule rrule.Rule, end time.Time) []time.Time {
+	skip := make(map[int64]bool, len(series.ExDates))
+	for _, exDate := range series.ExDates {
+		skip[exDate.Unix()] = true
+	}
+
+	var starts []time.Time
+	for _, start := range rule.Expand(series.StartTime.In(zoneOrUTC(series.TimeZone)), end) {
+		if !skip[start.Unix()] {
+			starts = append(starts, start.UTC())
+		}
+	}
+	return starts
+}
+
+// validateSeriesRule refuses a bounded rule that has no occurrences left once
+// the exception dates are taken out.
+func validateSeriesRule(series *models.EventSeries, rule rrule.Rule, errs *ValidationError) {
+	if rule.Bounded() && len(seriesOccurrenceStarts(series, rule, time.Time{})) == 0 {
+		errs.add("rrule", "produces no occurrences")
+	}
+}
+
+// seriesControls reports whether changes to the series still apply to one of
+// its occurrences: not once it has been edited on its own, is part of a
+// pending swap, or has been swapped to someone else.
+func seriesControls(series *models.EventSeries, event *models.Event) bool {
+	return event.OwnerID == series.OwnerID && !event.Overridden && event.Status != models.EventStatusSwapPending
+}
+
+// syncSeriesOccurrences creates the occurrences the series is missing from
+// from up to end. With refresh set, occurrences the series still controls
+// are also moved and renamed to match it, or removed if the rule no longer
+// gives them. Nothing before from is touched.
+func syncSeriesOccurrences(tx *gorm.DB, series *models.EventSeries, rule rrule.Rule, from, end time.Time, refresh bool) (int, error) {
+	starts := seriesOccurrenceStarts(series, rule, end)
+	wanted := make(map[int64]time.Time, len(starts))
+	for _, start := range starts {
+		wanted[start.Unix()] = start
+	}
+
+	var existing []models.Event
+	if err := tx.Clauses(forUpdate).Where("series_id = ?", series.ID).Find(&existing).Error; err != nil {
+		return 0, err
+	}
+
+	duration := series.EndTime.Sub(series.StartTime)
+	have := make(map[int64]bool, len(existing))
+	for i := range existing {
+		event := &existing[i]
+		if event.OccurrenceStart == nil {
+			continue
+		}
+		key := event.OccurrenceStart.Unix()
+		have[key] = true
+
+		if !refresh || event.OccurrenceStart.Before(from) || !seriesControls(series, event) {
+			continue
+		}
+		start, ok := wanted[key]
+		if !ok {
+			if err := tx.Delete(event).Error; err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if !event.StartTime.Equal(start) || !event.EndTime.Equal(start.Add(duration)) {
+			event.Sequence++
+		}
+		event.Title = series.Title
+		event.StartTime = start
+		event.EndTime = start.Add(duration)
+		event.TimeZone = series.TimeZone
+		event.TeamID, event.OrganizationID, event.OrgWide = series.TeamID, series.OrganizationID, series.OrgWide
+		if err := tx.Save(event).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	created := 0
+	for _, start := range starts {
+		if have[start.Unix()] || start.Before(from) {
+			continue
+		}
+		occurrenceStart := start
+		event := models.Event{
+			Title:           series.Title,
+			StartTime:       start,
+			EndTime:         start.Add(duration),
+			TimeZone:        series.TimeZone,
+			Status:          series.Status,
+			OwnerID:         series.OwnerID,
+			TeamID:          series.TeamID,
+			OrganizationID:  series.OrganizationID,
+			OrgWide:         series.OrgWide,
+			SeriesID:        &series.ID,
+			OccurrenceStart: &occurrenceStart,
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			return 0, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+// CreateEventSeries creates a recurring series and its occurrences up to
+// horizon from now. Later occurrences are added by the series extender.
+func CreateEventSeries(ownerID uint, input *models.EventSeriesInput, limits EventLimits, horizon time.Duration) (*models.EventSeries, error) {
+	errs := &ValidationError{}
+	validateEventTitle(input.Title, errs)
+	validateEventTimes(input.StartTime, input.EndTime, false, limits, errs)
+	rule := parseSeriesRule(input.RRule, errs)
+
+	status := models.EventStatusBusy
+	if input.Status != nil {
+		status = validateClientStatus(nil, *input.Status, errs)
+	}
+
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var timeZone string
+	if input.TimeZone != nil {
+		timeZone = validateTimeZone("timeZone", *input.TimeZone, errs)
+	} else {
+		timeZone = userTimeZone(db.DB, ownerID)
+	}
+
+	teamID := input.TeamID
+	if teamID == nil {
+		teamID = defaultEventTeam(db.DB, ownerID, errs)
+	}
+	var orgID *uint
+	if teamID != nil {
+		orgID = resolveEventTeam(db.DB, ownerID, *teamID, errs)
+	} else if input.OrgWide {
+		errs.add("orgWide", "requires a team")
+	}
+
+	series := models.EventSeries{
+		Title:          input.Title,
+		StartTime:      input.StartTime.UTC(),
+		EndTime:        input.EndTime.UTC(),
+		TimeZone:       timeZone,
+		ExDates:        normalizeExDates(input.ExDates),
+		Status:         status,
+		OwnerID:        ownerID,
+		TeamID:         teamID,
+		OrganizationID: orgID,
+		OrgWide:        input.OrgWide,
+	}
+	if len(errs.Fields) == 0 {
+		series.RRule = rule.String()
+		validateSeriesRule(&series, rule, errs)
+	}
+	if err := errs.errOrNil(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		_, err := syncSeriesOccurrences(tx, &series, rule, time.Time{}, now.Add(horizon), false)
+		return err
+	})
+	if err != nil {
+		logger.Error("Failed to create event series: " + err.Error())
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Event series %d created for user %d with rule %s", series.ID, ownerID, series.RRule))
+	return loadEventSeries(db.DB, ownerID, series.ID)
+}
+
+func normalizeExDates(exDates []time.Time) []time.Time {
+	normalized := make([]time.Time, len(exDates))
+	for i, exDate := range exDates {
+		normalized[i] = exDate.UTC()
+	}
+	return normalized
+}
+
+// loadEventSeries returns one of the user's series with the occurrences they
+// still own, in order.
+func loadEventSeries(tx *gorm.DB, userID, seriesID uint) (*models.EventSeries, error) {
+	var series models.EventSeries
+	err := tx.Preload("Occurrences", func(q *gorm.DB) *gorm.DB {
+		return q.Where("owner_id = ?", userID).Order("start_time")
+	}).Where("id = ? AND owner_id = ?", seriesID, userID).First(&series).Error
+	if err != nil {
+		return nil, ErrEventSeriesNotFound
+	}
+	return &series, nil
+}
+
+func GetUserEventSeries(userID uint) ([]models.EventSeries, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var series []models.EventSeries
+	if err := db.DB.Where("owner_id = ?", userID).Order("start_time").Find(&series).Error; err != nil {
+		logger.Error("Failed to fetch event series: " + err.Error())
+		return nil, err
+	}
+	return series, nil
+}
+
+func GetEventSeries(userID, seriesID uint) (*models.EventSeries, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+	return loadEventSeries(db.DB, userID, seriesID)
+}
+
+// UpdateEventSeries changes a series and brings its upcoming occurrences in
+// line. Occurrences that already started, were edited on their own, are
+// part of a pending swap or were swapped away keep their own details; a
+// status change applies to every upcoming occurrence the user still owns
+// that is not part of a pending swap.
+func UpdateEventSeries(userID, seriesID uint, input *models.UpdateEventSeriesInput, limits EventLimits, horizon time.Duration) (*models.EventSeries, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	now := time.Now()
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var series models.EventSeries
+		if err := tx.Clauses(forUpdate).Where("id = ? AND owner_id = ?", seriesID, userID).First(&series).Error; err != nil {
+			return ErrEventSeriesNotFound
+		}
+
+		errs := &ValidationError{}
+		if input.Title != nil {
+			validateEventTitle(*input.Title, errs)
+			series.Title = *input.Title
+		}
+		if input.StartTime != nil || input.EndTime != nil {
+			if input.StartTime != nil {
+				series.StartTime = input.StartTime.UTC()
+			}
+			if input.EndTime != nil {
+				series.EndTime = input.EndTime.UTC()
+			}
+			validateEventTimes(series.StartTime, series.EndTime, false, limits, errs)
+		}
+		if input.TimeZone != nil {
+			series.TimeZone = validateTimeZone("timeZone", *input.TimeZone, errs)
+		}
+		ruleText := series.RRule
+		if input.RRule != nil {
+			ruleText = *input.RRule
+		}
+		rule := parseSeriesRule(ruleText, errs)
+		if input.ExDates != nil {
+			series.ExDates = normalizeExDates(*input.ExDates)
+		}
+
+		statusChanged := false
+		if input.Status != nil {
+			status := validateClientStatus(&series.Status, *input.Status, errs)
+			statusChanged = status != series.Status
+			series.Status = status
+		}
+
+		if input.TeamID != nil {
+			if *input.TeamID == 0 {
+				series.TeamID, series.OrganizationID, series.OrgWide = nil, nil, false
+			} else if orgID := resolveEventTeam(tx, userID, *input.TeamID, errs); orgID != nil {
+				teamID := *input.TeamID
+				series.TeamID, series.OrganizationID = &teamID, orgID
+			}
+		}
+		if input.OrgWide != nil {
+			if *input.OrgWide && series.TeamID == nil {
+				errs.add("orgWide", "requires a team")
+			}
+			series.OrgWide = *input.OrgWide
+		}
+
+		if len(errs.Fields) == 0 {
+			series.RRule = rule.String()
+			validateSeriesRule(&series, rule, errs)
+		}
+		if err := errs.errOrNil(); err != nil {
+			return err
+		}
+
+		if err := tx.Save(&series).Error; err != nil {
+			return err
+		}
+		if _, err := syncSeriesOccurrences(tx, &series, rule, now, now.Add(horizon), true); err != nil {
+			return err
+		}
+
+		if statusChanged {
+			return tx.Model(&models.Event{}).
+				Where("series_id = ? AND owner_id = ? AND status <> ? AND start_time >= ?", series.ID, userID, models.EventStatusSwapPending, now).
+				Update("status", series.Status).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Event series %d updated by user %d", seriesID, userID))
+	return loadEventSeries(db.DB, userID, seriesID)
+}
+
+// DeleteEventSeries deletes a series with every occurrence the user still
+// owns. Occurrences in a pending swap are kept as standalone events so the
+// swap can still be answered.
+func DeleteEventSeries(userID, seriesID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var series models.EventSeries
+		if err := tx.Clauses(forUpdate).Where("id = ? AND owner_id = ?", seriesID, userID).First(&series).Error; err != nil {
+			return ErrEventSeriesNotFound
+		}
+
+		if err := tx.Model(&models.Event{}).
+			Where("series_id = ? AND owner_id = ? AND status = ?", series.ID, userID, models.EventStatusSwapPending).
+			Updates(map[string]interface{}{"series_id": nil, "occurrence_start": nil, "overridden": false}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("series_id = ? AND owner_id = ?", series.ID, userID).Delete(&models.Event{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&series).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Event series %d deleted by user %d", seriesID, userID))
+	return nil
+}
+
+// addSeriesException records a deleted occurrence as an exception date of its
+// series, so the series does not create it again. Occurrences that were
+// swapped to someone else are no longer the series owner's to skip.
+func addSeriesException(tx *gorm.DB, event *models.Event) error {
+	if event.SeriesID == nil || event.OccurrenceStart == nil {
+		return nil
+	}
+
+	var series models.EventSeries
+	if err := tx.Clauses(forUpdate).First(&series, *event.SeriesID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if series.OwnerID != event.OwnerID {
+		return nil
+	}
+
+	series.ExDates = append(series.ExDates, event.OccurrenceStart.UTC())
+	return tx.Save(&series).Error
+}
+
+// ExtendEventSeries creates the occurrences that have come within horizon of
+// now. Each series is extended in its own transaction.
+func ExtendEventSeries(now time.Time, horizon time.Duration) (int, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return 0, errors.New("database connection is nil")
+	}
+
+	var ids []uint
+	if err := db.DB.Model(&models.EventSeries{}).Pluck("id", &ids).Error; err != nil {
+		logger.Error("Failed to fetch event series: " + err.Error())
+		return 0, err
+	}
+
+	total := 0
+	for _, id := range ids {
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			var series models.EventSeries
+			if err := tx.Clauses(forUpdate).First(&series, id).Error; err != nil {
+				return err
+			}
+			rule, err := rrule.Parse(series.RRule)
+			if err != nil {
+				return err
+			}
+			created, err := syncSeriesOccurrences(tx, &series, rule, now, now.Add(horizon), false)
+			total += created
+			return err
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to extend event series %d: %s", id, err.Error()))
+		}
+	}
+
+	return total, nil
+}
+
+// StartEventSeriesExtender runs ExtendEventSeries every interval in a
+// background goroutine. The returned function stops it.
+func StartEventSeriesExtender(horizon, interval time.Duration) func() {
+	if interval <= 0 || horizon <= 0 {
+		logger.Warn("Event series extender disabled: horizon and interval must be positive")
+		return func() {}
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				count, err := ExtendEventSeries(now, horizon)
+				if err != nil {
+					logger.Error("Event series extension failed: " + err.Error())
+				} else if count > 0 {
+					logger.Info(fmt.Sprintf("Created %d upcoming series occurrences", count))
+				}
+			}
+		}
+	}()
+
+	logger.Info("Event series extender started with interval " + interval.String())
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
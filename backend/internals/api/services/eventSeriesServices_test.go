@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/rrule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesOccurrenceStarts(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	series := &models.EventSeries{
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		ExDates:   []time.Time{start.AddDate(0, 0, 7)},
+	}
+	rule, err := rrule.Parse("FREQ=WEEKLY;COUNT=3")
+	require.NoError(t, err)
+
+	starts := seriesOccurrenceStarts(series, rule, time.Time{})
+	assert.Equal(t, []time.Time{start, start.AddDate(0, 0, 14)}, starts)
+
+	t.Run("Exception Dates Can Empty A Series", func(t *testing.T) {
+		series.ExDates = append(series.ExDates, start, start.AddDate(0, 0, 14))
+		errs := &ValidationError{}
+		validateSeriesRule(series, rule, errs)
+		assert.Error(t, errs.errOrNil())
+	})
+
+	t.Run("Rules Keep Wall-Clock Time Across Daylight Saving", func(t *testing.T) {
+		// Monday 24 March 2025, 09:00 in Berlin; summer time starts on the 30th.
+		start := time.Date(2025, 3, 24, 8, 0, 0, 0, time.UTC)
+		series := &models.EventSeries{StartTime: start, EndTime: start.Add(time.Hour), TimeZone: "Europe/Berlin"}
+		rule, err := rrule.Parse("FREQ=WEEKLY;COUNT=2")
+		require.NoError(t, err)
+
+		starts := seriesOccurrenceStarts(series, rule, time.Time{})
+		assert.Equal(t, []time.Time{start, time.Date(2025, 3, 31, 7, 0, 0, 0, time.UTC)}, starts)
+
+		series.TimeZone = ""
+		starts = seriesOccurrenceStarts(series, rule, time.Time{})
+		assert.Equal(t, start.AddDate(0, 0, 7), starts[1], "series without a zone are expanded in UTC")
+	})
+}
+
+func TestSeriesControls(t *testing.T) {
+	series := &models.EventSeries{OwnerID: 1}
+
+	assert.True(t, seriesControls(series, &models.Event{OwnerID: 1, Status: models.EventStatusSwappable}))
+	assert.False(t, seriesControls(series, &models.Event{OwnerID: 1, Overridden: true}), "edited on its own")
+	assert.False(t, seriesControls(series, &models.Event{OwnerID: 1, Status: models.EventStatusSwapPending}), "pending swap")
+	assert.False(t, seriesControls(series, &models.Event{OwnerID: 2}), "swapped away")
+}
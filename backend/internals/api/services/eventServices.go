@@ -8,16 +8,88 @@ import (
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
 	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
 )
 
-func CreateEvent(input *models.Event) (*models.Event, error) {
-	if err := db.DB.Create(input).Error; err != nil {
+// ErrEventNotFound is returned for events that do not exist or that the user
+// may not see.
+var ErrEventNotFound = errors.New("event not found")
+
+// ErrEventSwapPending refuses edits to an event held by a pending swap.
+var ErrEventSwapPending = errors.New("cannot update event while swap request is pending")
+
+// ErrDeleteSwapPending refuses deleting an event held by a pending swap.
+var ErrDeleteSwapPending = errors.New("cannot delete event while swap request is pending")
+
+func CreateEvent(ownerID uint, input *models.CreateEventInput, limits EventLimits) (*models.Event, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	return createEvent(db.DB, ownerID, input, limits)
+}
+
+// createEvent is CreateEvent within tx.
+func createEvent(tx *gorm.DB, ownerID uint, input *models.CreateEventInput, limits EventLimits) (*models.Event, error) {
+	errs := &ValidationError{}
+	validateEventTitle(input.Title, errs)
+
+	status := models.EventStatusBusy
+	if input.Status != nil {
+		status = validateClientStatus(nil, *input.Status, errs)
+	}
+
+	var timeZone string
+	if input.TimeZone != nil {
+		timeZone = validateTimeZone("timeZone", *input.TimeZone, errs)
+	} else {
+		timeZone = userTimeZone(tx, ownerID)
+	}
+
+	loc := zoneOrUTC(timeZone)
+	startTime, startOK := readEventTime("startTime", input.StartTime, input.AllDay, loc, errs)
+	endTime, endOK := readEventTime("endTime", input.EndTime, input.AllDay, loc, errs)
+	if startOK && endOK {
+		validateEventTimes(startTime, endTime, input.AllDay, limits, errs)
+	}
+
+	teamID := input.TeamID
+	if teamID == nil {
+		teamID = defaultEventTeam(tx, ownerID, errs)
+	}
+	var orgID *uint
+	if teamID != nil {
+		orgID = resolveEventTeam(tx, ownerID, *teamID, errs)
+	} else if input.OrgWide {
+		errs.add("orgWide", "requires a team")
+	}
+
+	if err := errs.errOrNil(); err != nil {
+		return nil, err
+	}
+
+	event := models.Event{
+		Title:          input.Title,
+		StartTime:      startTime,
+		EndTime:        endTime,
+		AllDay:         input.AllDay,
+		TimeZone:       timeZone,
+		Status:         status,
+		OwnerID:        ownerID,
+		TeamID:         teamID,
+		OrganizationID: orgID,
+		OrgWide:        input.OrgWide,
+		ICalUID:        input.ICalUID,
+	}
+
+	if err := tx.Create(&event).Error; err != nil {
 		logger.Error("Failed to create event in database: " + err.Error())
 		return nil, err
 	}
 
-	logger.Info("Event created in database with title: " + input.Title)
-	return input, nil
+	logger.Info("Event created in database with title: " + event.Title)
+	return &event, nil
 }
 
 func GetUserEvents(userID uint) ([]models.Event, error) {
@@ -30,22 +102,60 @@ func GetUserEvents(userID uint) ([]models.Event, error) {
 	return events, nil
 }
 
-func UpdateEvent(eventID uint, userID uint, input *models.Event) (*models.Event, error) {
+// GetUserEventsBetween lists the user's events, series occurrences included,
+// that overlap the range from-to in start order. A zero bound leaves that
+// side open.
+func GetUserEventsBetween(userID uint, from, to time.Time) ([]models.Event, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	query := db.DB.Where("owner_id = ?", userID)
+	if !from.IsZero() {
+		query = query.Where("end_time > ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("start_time < ?", to)
+	}
+
+	var events []models.Event
+	if err := query.Order("start_time").Find(&events).Error; err != nil {
+		logger.Error("Failed to fetch user events: " + err.Error())
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func UpdateEvent(eventID uint, userID uint, input *models.Event, limits EventLimits) (*models.Event, error) {
 	var event models.Event
 	if err := db.DB.Where("id = ? AND owner_id = ?", eventID, userID).First(&event).Error; err != nil {
 		logger.Error("Event not found or not owned by user: " + err.Error())
-		return nil, errors.New("event not found")
+		return nil, ErrEventNotFound
 	}
 
 	if event.Status == models.EventStatusSwapPending {
 		logger.Error("Cannot update event while swap request is pending")
-		return nil, errors.New("cannot update event while swap request is pending")
+		return nil, ErrEventSwapPending
+	}
+
+	errs := &ValidationError{}
+	validateEventTitle(input.Title, errs)
+	validateEventTimes(input.StartTime, input.EndTime, event.AllDay, limits, errs)
+	status := validateClientStatus(&event.Status, string(input.Status), errs)
+	if err := errs.errOrNil(); err != nil {
+		return nil, err
 	}
 
+	if !event.StartTime.Equal(input.StartTime) || !event.EndTime.Equal(input.EndTime) {
+		event.Sequence++
+	}
 	event.Title = input.Title
 	event.StartTime = input.StartTime
 	event.EndTime = input.EndTime
-	event.Status = input.Status
+	event.Status = status
+	event.Overridden = event.SeriesID != nil
 
 	if err := db.DB.Save(&event).Error; err != nil {
 		logger.Error("Failed to update event: " + err.Error())
@@ -56,43 +166,106 @@ func UpdateEvent(eventID uint, userID uint, input *models.Event) (*models.Event,
 	return &event, nil
 }
 
-func UpdateEventPartial(eventID uint, userID uint, input *models.UpdateEventInput) (*models.Event, error) {
+func UpdateEventPartial(eventID uint, userID uint, input *models.UpdateEventInput, limits EventLimits) (*models.Event, error) {
 	var event models.Event
 	if err := db.DB.Where("id = ? AND owner_id = ?", eventID, userID).First(&event).Error; err != nil {
 		logger.Error("Event not found or not owned by user: " + err.Error())
-		return nil, errors.New("event not found")
+		return nil, ErrEventNotFound
 	}
 
+	return updateEventPartial(db.DB, &event, userID, input, limits)
+}
+
+// updateEventPartial applies input to an event of userID already loaded
+// from tx and saves it there.
+func updateEventPartial(tx *gorm.DB, event *models.Event, userID uint, input *models.UpdateEventInput, limits EventLimits) (*models.Event, error) {
+	eventID := event.ID
 	if event.Status == models.EventStatusSwapPending {
 		logger.Error("Cannot update event while swap request is pending")
-		return nil, errors.New("cannot update event while swap request is pending")
+		return nil, ErrEventSwapPending
 	}
 
+	errs := &ValidationError{}
 	if input.Title != nil {
+		validateEventTitle(*input.Title, errs)
 		event.Title = *input.Title
 	}
+
+	if input.TimeZone != nil {
+		event.TimeZone = validateTimeZone("timeZone", *input.TimeZone, errs)
+	}
+
+	// Times without an offset are read in the event's zone, or the owner's
+	// for events stored before zones were recorded.
+	loc := time.UTC
+	if input.StartTime != nil || input.EndTime != nil {
+		zone := event.TimeZone
+		if zone == "" {
+			zone = userTimeZone(tx, userID)
+		}
+		loc = zoneOrUTC(zone)
+	}
+
+	// Switching between all-day and timed changes what the stored times mean,
+	// so both have to be given again.
+	if input.AllDay != nil && *input.AllDay != event.AllDay {
+		if input.StartTime == nil || input.EndTime == nil {
+			errs.add("allDay", "can only change together with startTime and endTime")
+		}
+		event.AllDay = *input.AllDay
+	}
+
+	timesChanged := false
 	if input.StartTime != nil {
-		startTime, err := time.Parse(time.RFC3339, *input.StartTime)
-		if err != nil {
-			return nil, errors.New("invalid start time format")
+		if startTime, ok := readEventTime("startTime", *input.StartTime, event.AllDay, loc, errs); ok {
+			event.StartTime = startTime
+			timesChanged = true
 		}
-		event.StartTime = startTime
 	}
 	if input.EndTime != nil {
-		endTime, err := time.Parse(time.RFC3339, *input.EndTime)
-		if err != nil {
-			return nil, errors.New("invalid end time format")
+		if endTime, ok := readEventTime("endTime", *input.EndTime, event.AllDay, loc, errs); ok {
+			event.EndTime = endTime
+			timesChanged = true
 		}
-		event.EndTime = endTime
 	}
+	if timesChanged {
+		validateEventTimes(event.StartTime, event.EndTime, event.AllDay, limits, errs)
+		event.Sequence++
+	}
+
 	if input.Status != nil {
 		logger.Info(fmt.Sprintf("Updating event %d status from %s to %s", eventID, event.Status, *input.Status))
-		event.Status = models.EventStatus(*input.Status)
+		event.Status = validateClientStatus(&event.Status, *input.Status, errs)
+	}
+
+	if input.TeamID != nil {
+		if *input.TeamID == 0 {
+			event.TeamID, event.OrganizationID, event.OrgWide = nil, nil, false
+		} else if orgID := resolveEventTeam(tx, userID, *input.TeamID, errs); orgID != nil {
+			teamID := *input.TeamID
+			event.TeamID, event.OrganizationID = &teamID, orgID
+		}
+	}
+	if input.OrgWide != nil {
+		if *input.OrgWide && event.TeamID == nil {
+			errs.add("orgWide", "requires a team")
+		}
+		event.OrgWide = *input.OrgWide
+	}
+
+	if err := errs.errOrNil(); err != nil {
+		return nil, err
+	}
+
+	// Editing one occurrence of a series detaches its details from the series;
+	// a status change alone does not.
+	if event.SeriesID != nil && (input.Title != nil || timesChanged || input.TimeZone != nil || input.TeamID != nil || input.OrgWide != nil) {
+		event.Overridden = true
 	}
 
 	logger.Info(fmt.Sprintf("About to save event %d with status: %s", eventID, event.Status))
 
-	if err := db.DB.Save(&event).Error; err != nil {
+	if err := tx.Save(event).Error; err != nil {
 		logger.Error("Failed to update event: " + err.Error())
 		return nil, err
 	}
@@ -100,21 +273,24 @@ func UpdateEventPartial(eventID uint, userID uint, input *models.UpdateEventInpu
 	logger.Info(fmt.Sprintf("Event %d successfully saved to database with status: %s", eventID, event.Status))
 
 	var verifyEvent models.Event
-	if err := db.DB.First(&verifyEvent, eventID).Error; err == nil {
+	if err := tx.First(&verifyEvent, eventID).Error; err == nil {
 		logger.Info(fmt.Sprintf("Verification: Event %d in DB has status: %s", eventID, verifyEvent.Status))
 	}
 
-	return &event, nil
+	return event, nil
 }
 
 func DeleteEvent(eventID uint, userID uint) error {
 	var event models.Event
 	if err := db.DB.Where("id = ? AND owner_id = ?", eventID, userID).First(&event).Error; err != nil {
 		logger.Error("Event not found or not owned by user: " + err.Error())
-		return errors.New("event not found")
+		return ErrEventNotFound
 	}
 
-	if err := db.DB.Delete(&event).Error; err != nil {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteEvent(tx, &event)
+	})
+	if err != nil {
 		logger.Error("Failed to delete event: " + err.Error())
 		return err
 	}
@@ -123,14 +299,37 @@ func DeleteEvent(eventID uint, userID uint) error {
 	return nil
 }
 
-func GetSwappableSlots(userID uint) ([]models.Event, error) {
+// deleteEvent deletes an event loaded from tx, keeping a series from
+// producing it again.
+func deleteEvent(tx *gorm.DB, event *models.Event) error {
+	if err := addSeriesException(tx, event); err != nil {
+		return err
+	}
+	return tx.Delete(event).Error
+}
+
+// GetSwappableSlots lists the marketplace as userID sees it: slots without a
+// team, slots of the user's teams and org-wide slots of their organizations.
+// A non-zero teamID narrows the list to that team.
+func GetSwappableSlots(userID uint, teamID uint) ([]models.Event, error) {
 	var events []models.Event
 
 	if db.DB == nil {
 		logger.Error("Database connection is nil")
 		return nil, errors.New("database connection is nil")
 	}
-	if err := db.DB.Preload("Owner").Where("owner_id != ? AND status = ?", userID, models.EventStatusSwappable).Find(&events).Error; err != nil {
+
+	scope, err := loadTeamScope(db.DB, userID)
+	if err != nil {
+		logger.Error("Failed to load team scope: " + err.Error())
+		return nil, err
+	}
+
+	query := scopeMarketplace(db.DB.Preload("Owner"), scope)
+	if teamID != 0 {
+		query = query.Where("events.team_id = ?", teamID)
+	}
+	if err := query.Where("owner_id != ? AND status = ?", userID, models.EventStatusSwappable).Find(&events).Error; err != nil {
 		logger.Error("Failed to fetch swappable slots: " + err.Error())
 		return nil, err
 	}
@@ -138,7 +337,7 @@ func GetSwappableSlots(userID uint) ([]models.Event, error) {
 	var filteredEvents []models.Event
 	for _, event := range events {
 		var count int64
-		if err := db.DB.Model(&models.SwapRequest{}).Where("status = ? AND (requester_event_id = ? OR responder_event_id = ?)", models.PENDING, event.ID, event.ID).Count(&count).Error; err != nil {
+		if err := db.DB.Model(&models.SwapRequest{}).Where("status IN ? AND (requester_event_id = ? OR responder_event_id = ?)", []models.SwapStatus{models.PENDING, models.COUNTERED}, event.ID, event.ID).Count(&count).Error; err != nil {
 			logger.Error("Failed to check swap requests for event: " + err.Error())
 			continue
 		}
@@ -155,7 +354,7 @@ func GetEventByID(eventID uint) (*models.Event, error) {
 	var event models.Event
 	if err := db.DB.First(&event, eventID).Error; err != nil {
 		logger.Error("Event not found: " + err.Error())
-		return nil, errors.New("event not found")
+		return nil, ErrEventNotFound
 	}
 
 	return &event, nil
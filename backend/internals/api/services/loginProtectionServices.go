@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidUnlockToken = errors.New("invalid or expired unlock token")
+
+// loginAttemptStore keeps sign-in failures in the database so lockouts hold
+// across restarts and server instances.
+type loginAttemptStore struct{}
+
+func NewLoginAttemptStore() loginguard.Store {
+	return loginAttemptStore{}
+}
+
+func toLoginRecord(row *models.LoginAttempt) loginguard.Record {
+	return loginguard.Record{
+		Failures:      row.Failures,
+		LastFailureAt: row.LastFailureAt,
+		BlockedUntil:  row.BlockedUntil,
+		Locked:        row.Locked,
+	}
+}
+
+func (loginAttemptStore) Get(key string) (loginguard.Record, error) {
+	if db.DB == nil {
+		return loginguard.Record{}, errors.New("database connection is nil")
+	}
+
+	var row models.LoginAttempt
+	if err := db.DB.Where("key = ?", key).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return loginguard.Record{}, nil
+		}
+		return loginguard.Record{}, err
+	}
+	return toLoginRecord(&row), nil
+}
+
+func (loginAttemptStore) Update(key string, fn func(rec *loginguard.Record)) (loginguard.Record, error) {
+	if db.DB == nil {
+		return loginguard.Record{}, errors.New("database connection is nil")
+	}
+
+	var rec loginguard.Record
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginAttempt{Key: key}).Error; err != nil {
+			return err
+		}
+
+		var row models.LoginAttempt
+		if err := tx.Clauses(forUpdate).Where("key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+
+		rec = toLoginRecord(&row)
+		fn(&rec)
+
+		row.Failures = rec.Failures
+		row.LastFailureAt = rec.LastFailureAt
+		row.BlockedUntil = rec.BlockedUntil
+		row.Locked = rec.Locked
+		return tx.Save(&row).Error
+	})
+	return rec, err
+}
+
+func (loginAttemptStore) Delete(key string) error {
+	if db.DB == nil {
+		return errors.New("database connection is nil")
+	}
+	return db.DB.Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func accountUnlockMessage(user *models.User, link string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Your SlotSwapper account has been locked",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We locked your SlotSwapper account after several failed sign-in attempts. "+
+			"If these were you, use the link below within %s to unlock it now:\n\n%s\n\n"+
+			"Otherwise the lock lifts on its own, but consider changing your password, "+
+			"since someone may be trying to guess it.\n",
+			user.Name, humanDuration(ttl), link),
+	}
+}
+
+// SendAccountUnlockEmail mails an unlock link to the account registered
+// under email. Lockouts are tracked for unknown emails too, so nothing is
+// sent and no error is returned for those.
+func SendAccountUnlockEmail(email string, cfg *config.Config, m mailer.Mailer) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	var user models.User
+	if err := db.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := pkg.NewTokenID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AccountUnlockToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.AccountUnlockToken{
+			UserID:    user.ID,
+			TokenHash: pkg.HashToken(token),
+			ExpiresAt: now.Add(cfg.ACCOUNT_UNLOCK_TTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link := appLink(cfg.APP_BASE_URL, "/unlock-account", token)
+	if err := m.Send(accountUnlockMessage(&user, link, cfg.ACCOUNT_UNLOCK_TTL)); err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Account unlock email sent to user %d", user.ID))
+	return nil
+}
+
+// UnlockAccount lifts a sign-in lockout with a token from the unlock email.
+func UnlockAccount(token string, guard *loginguard.Guard) (*models.User, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var user models.User
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var unlock models.AccountUnlockToken
+		if err := tx.Clauses(forUpdate).
+			Where("token_hash = ? AND used_at IS NULL", pkg.HashToken(token)).
+			First(&unlock).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidUnlockToken
+			}
+			return err
+		}
+
+		now := time.Now()
+		if !unlock.ExpiresAt.After(now) {
+			return ErrInvalidUnlockToken
+		}
+
+		if err := tx.First(&user, unlock.UserID).Error; err != nil {
+			return ErrInvalidUnlockToken
+		}
+
+		return tx.Model(&unlock).Update("used_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := guard.Unlock(user.Email); err != nil {
+		return nil, err
+	}
+
+	logger.Security("account_unlocked", map[string]interface{}{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"method":  "email",
+	})
+	return &user, nil
+}
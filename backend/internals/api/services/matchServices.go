@@ -0,0 +1,420 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+)
+
+const (
+	maxMatchCycleLength = 4
+	maxMatchesPerUser   = 50
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// normalizeExcludedDays accepts day names such as "Saturday" or "sat" and
+// returns them as a sorted, comma separated list of three-letter names.
+func normalizeExcludedDays(days []string) (string, error) {
+	seen := make(map[time.Weekday]bool, len(days))
+	for _, day := range days {
+		key := strings.ToLower(strings.TrimSpace(day))
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		weekday, ok := weekdayNames[key]
+		if !ok {
+			return "", fmt.Errorf("invalid excluded day: %s", day)
+		}
+		seen[weekday] = true
+	}
+
+	var names []string
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if seen[weekday] {
+			names = append(names, strings.ToLower(weekday.String()[:3]))
+		}
+	}
+
+	return strings.Join(names, ","), nil
+}
+
+// eventWeekday is the day the event starts on in its own time zone. All-day
+// events are stored as midnight UTC of their first date, so UTC gives it.
+func eventWeekday(event *models.Event) time.Weekday {
+	if event.AllDay {
+		return event.StartTime.UTC().Weekday()
+	}
+	return event.StartTime.In(zoneOrUTC(event.TimeZone)).Weekday()
+}
+
+// wantAccepts reports whether the owner of want would take candidate in
+// exchange for the event the want is attached to.
+func wantAccepts(want *models.SwapWant, candidate *models.Event) bool {
+	if want.EarliestStart != nil && candidate.StartTime.Before(*want.EarliestStart) {
+		return false
+	}
+
+	if want.LatestEnd != nil && candidate.EndTime.After(*want.LatestEnd) {
+		return false
+	}
+
+	duration := candidate.EndTime.Sub(candidate.StartTime)
+	if want.MinDurationMinutes > 0 && duration < time.Duration(want.MinDurationMinutes)*time.Minute {
+		return false
+	}
+
+	if want.MaxDurationMinutes > 0 && duration > time.Duration(want.MaxDurationMinutes)*time.Minute {
+		return false
+	}
+
+	if want.ExcludedDays != "" {
+		weekday := strings.ToLower(eventWeekday(candidate).String()[:3])
+		for _, excluded := range strings.Split(want.ExcludedDays, ",") {
+			if excluded == weekday {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// buildMatchGraph links each wanted event to every other user's event its
+// owner would accept in exchange and is allowed to see given their teams. An
+// edge e -> f means e's owner would take f.
+func buildMatchGraph(events []models.Event, wants []models.SwapWant, scopes map[uint]*TeamScope) (map[uint]*models.Event, map[uint][]uint) {
+	eventsByID := make(map[uint]*models.Event, len(events))
+	for i := range events {
+		eventsByID[events[i].ID] = &events[i]
+	}
+
+	edges := make(map[uint]map[uint]bool)
+	for i := range wants {
+		want := &wants[i]
+		offered, ok := eventsByID[want.EventID]
+		if !ok || offered.OwnerID != want.UserID {
+			continue
+		}
+
+		for j := range events {
+			candidate := &events[j]
+			if candidate.OwnerID == offered.OwnerID || !wantAccepts(want, candidate) || !scopes[offered.OwnerID].canSee(candidate) {
+				continue
+			}
+			if edges[offered.ID] == nil {
+				edges[offered.ID] = make(map[uint]bool)
+			}
+			edges[offered.ID][candidate.ID] = true
+		}
+	}
+
+	graph := make(map[uint][]uint, len(edges))
+	for from, targets := range edges {
+		for to := range targets {
+			graph[from] = append(graph[from], to)
+		}
+		sort.Slice(graph[from], func(i, j int) bool { return graph[from][i] < graph[from][j] })
+	}
+
+	return eventsByID, graph
+}
+
+// scoreMatch favours trades between slots of similar length and penalises
+// longer cycles, which need more people to agree.
+func scoreMatch(eventsByID map[uint]*models.Event, cycle []uint) float64 {
+	total := 0.0
+	for i, id := range cycle {
+		received := eventsByID[id]
+		given := eventsByID[cycle[(i+len(cycle)-1)%len(cycle)]]
+		receivedLength := received.EndTime.Sub(received.StartTime).Minutes()
+		givenLength := given.EndTime.Sub(given.StartTime).Minutes()
+		if receivedLength <= 0 || givenLength <= 0 {
+			continue
+		}
+		total += math.Min(receivedLength, givenLength) / math.Max(receivedLength, givenLength)
+	}
+
+	fit := total / float64(len(cycle))
+	lengthFactor := 1 - 0.1*float64(len(cycle)-2)
+	return math.Round(fit*lengthFactor*1000) / 10
+}
+
+func newMatch(eventsByID map[uint]*models.Event, cycle []uint) models.Match {
+	ids := make([]string, len(cycle))
+	legs := make([]models.MatchLeg, len(cycle))
+	for i, id := range cycle {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+		legs[i] = models.MatchLeg{
+			EventID:    id,
+			GiverID:    eventsByID[id].OwnerID,
+			ReceiverID: eventsByID[cycle[(i+len(cycle)-1)%len(cycle)]].OwnerID,
+		}
+	}
+
+	kind := models.MatchCycle
+	if len(cycle) == 2 {
+		kind = models.MatchPairwise
+	}
+
+	return models.Match{
+		ID:       strings.Join(ids, "-"),
+		Kind:     kind,
+		EventIDs: append([]uint(nil), cycle...),
+		Legs:     legs,
+		Score:    scoreMatch(eventsByID, cycle),
+	}
+}
+
+// findMatches returns every trade, pairwise or cyclic up to
+// maxMatchCycleLength participants, in which userID gives one of their
+// wanted events and every participant receives a slot they asked for.
+func findMatches(userID uint, events []models.Event, wants []models.SwapWant, scopes map[uint]*TeamScope) []models.Match {
+	eventsByID, graph := buildMatchGraph(events, wants, scopes)
+
+	var starts []uint
+	for id := range graph {
+		if eventsByID[id].OwnerID == userID {
+			starts = append(starts, id)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	var matches []models.Match
+	for _, start := range starts {
+		path := []uint{start}
+		owners := map[uint]bool{userID: true}
+
+		var walk func()
+		walk = func() {
+			for _, next := range graph[path[len(path)-1]] {
+				if next == start {
+					if len(path) >= 2 {
+						matches = append(matches, newMatch(eventsByID, path))
+					}
+					continue
+				}
+
+				owner := eventsByID[next].OwnerID
+				if len(path) >= maxMatchCycleLength || owners[owner] {
+					continue
+				}
+
+				owners[owner] = true
+				path = append(path, next)
+				walk()
+				path = path[:len(path)-1]
+				delete(owners, owner)
+			}
+		}
+		walk()
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return len(matches[i].EventIDs) < len(matches[j].EventIDs)
+	})
+
+	if len(matches) > maxMatchesPerUser {
+		matches = matches[:maxMatchesPerUser]
+	}
+
+	return matches
+}
+
+func parseOptionalTime(value *string, field string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s format", field)
+	}
+	return &parsed, nil
+}
+
+func CreateSwapWant(userID uint, input *models.SwapWantInput) (*models.SwapWant, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	event, err := GetEventByID(input.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.OwnerID != userID {
+		return nil, errors.New("event not found")
+	}
+
+	if event.Status != models.EventStatusSwappable {
+		return nil, errors.New("wants can only be registered against swappable events")
+	}
+
+	earliestStart, err := parseOptionalTime(input.EarliestStart, "earliest start")
+	if err != nil {
+		return nil, err
+	}
+
+	latestEnd, err := parseOptionalTime(input.LatestEnd, "latest end")
+	if err != nil {
+		return nil, err
+	}
+
+	if earliestStart != nil && latestEnd != nil && !earliestStart.Before(*latestEnd) {
+		return nil, errors.New("earliest start must be before latest end")
+	}
+
+	if input.MinDurationMinutes < 0 || input.MaxDurationMinutes < 0 {
+		return nil, errors.New("durations cannot be negative")
+	}
+
+	if input.MaxDurationMinutes > 0 && input.MinDurationMinutes > input.MaxDurationMinutes {
+		return nil, errors.New("minimum duration cannot exceed maximum duration")
+	}
+
+	excludedDays, err := normalizeExcludedDays(input.ExcludedDays)
+	if err != nil {
+		return nil, err
+	}
+
+	want := models.SwapWant{
+		UserID:             userID,
+		EventID:            event.ID,
+		EarliestStart:      earliestStart,
+		LatestEnd:          latestEnd,
+		MinDurationMinutes: input.MinDurationMinutes,
+		MaxDurationMinutes: input.MaxDurationMinutes,
+		ExcludedDays:       excludedDays,
+	}
+
+	if err := db.DB.Create(&want).Error; err != nil {
+		logger.Error("Failed to create swap want: " + err.Error())
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Swap want %d registered by user %d for event %d", want.ID, userID, event.ID))
+	return &want, nil
+}
+
+func GetUserSwapWants(userID uint) ([]models.SwapWant, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var wants []models.SwapWant
+	if err := db.DB.Preload("Event").Where("user_id = ?", userID).Find(&wants).Error; err != nil {
+		logger.Error("Failed to fetch swap wants: " + err.Error())
+		return nil, err
+	}
+
+	return wants, nil
+}
+
+func DeleteSwapWant(wantID uint, userID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	result := db.DB.Where("id = ? AND user_id = ?", wantID, userID).Delete(&models.SwapWant{})
+	if result.Error != nil {
+		logger.Error("Failed to delete swap want: " + result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("swap want not found")
+	}
+
+	return nil
+}
+
+func GetMatches(userID uint) ([]models.Match, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var events []models.Event
+	if err := db.DB.Where("status = ?", models.EventStatusSwappable).Find(&events).Error; err != nil {
+		logger.Error("Failed to fetch swappable events for matching: " + err.Error())
+		return nil, err
+	}
+
+	var wants []models.SwapWant
+	if err := db.DB.Where("event_id IN (?)", db.DB.Model(&models.Event{}).Select("id").Where("status = ?", models.EventStatusSwappable)).
+		Find(&wants).Error; err != nil {
+		logger.Error("Failed to fetch swap wants for matching: " + err.Error())
+		return nil, err
+	}
+
+	ownerIDs := make([]uint, 0, len(wants))
+	for _, want := range wants {
+		ownerIDs = append(ownerIDs, want.UserID)
+	}
+	scopes, err := loadTeamScopes(db.DB, ownerIDs...)
+	if err != nil {
+		logger.Error("Failed to load team scopes for matching: " + err.Error())
+		return nil, err
+	}
+
+	matches := findMatches(userID, events, wants, scopes)
+	logger.Info(fmt.Sprintf("Found %d matches for user %d", len(matches), userID))
+	return matches, nil
+}
+
+// AcceptMatch re-runs matching to make sure the suggestion still holds, then
+// opens a swap request for a pairwise match or a swap cycle for a longer one.
+func AcceptMatch(userID uint, matchID string, ttl time.Duration) (*models.MatchAcceptance, error) {
+	matches, err := GetMatches(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *models.Match
+	for i := range matches {
+		if matches[i].ID == matchID {
+			match = &matches[i]
+			break
+		}
+	}
+
+	if match == nil {
+		return nil, errors.New("match is no longer available")
+	}
+
+	if match.Kind == models.MatchPairwise {
+		request, err := CreateSwapRequest(userID, match.EventIDs[0], match.EventIDs[1], ttl, false)
+		if err != nil {
+			return nil, err
+		}
+		return &models.MatchAcceptance{SwapRequest: request}, nil
+	}
+
+	cycle, err := ProposeSwapCycle(userID, match.EventIDs, ttl, false)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MatchAcceptance{SwapCycle: cycle}, nil
+}
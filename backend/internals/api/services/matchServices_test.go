@@ -0,0 +1,98 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeExcludedDays(t *testing.T) {
+	days, err := normalizeExcludedDays([]string{"Sunday", "sat", " MON "})
+	assert.NoError(t, err)
+	assert.Equal(t, "sun,mon,sat", days)
+
+	_, err = normalizeExcludedDays([]string{"someday"})
+	assert.Error(t, err)
+}
+
+func TestWantExcludedDaysUseTheEventsZone(t *testing.T) {
+	want := &models.SwapWant{ExcludedDays: "sat"}
+
+	// Friday 22:30 UTC is Saturday 00:30 in Berlin.
+	start := time.Date(2025, 6, 6, 22, 30, 0, 0, time.UTC)
+	berlin := &models.Event{StartTime: start, EndTime: start.Add(time.Hour), TimeZone: "Europe/Berlin"}
+	assert.False(t, wantAccepts(want, berlin))
+
+	utc := &models.Event{StartTime: start, EndTime: start.Add(time.Hour)}
+	assert.True(t, wantAccepts(want, utc), "events without a zone are read in UTC")
+
+	// An all-day Saturday keeps its date whatever its zone.
+	saturday := time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)
+	allDay := &models.Event{StartTime: saturday, EndTime: saturday.AddDate(0, 0, 1), AllDay: true, TimeZone: "America/New_York"}
+	assert.False(t, wantAccepts(want, allDay))
+}
+
+func TestFindMatches(t *testing.T) {
+	// Monday 2025-06-02 09:00 UTC
+	monday := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	slot := func(id uint, owner uint, start time.Time, hours int) models.Event {
+		return models.Event{
+			ID:        id,
+			OwnerID:   owner,
+			Status:    models.EventStatusSwappable,
+			StartTime: start,
+			EndTime:   start.Add(time.Duration(hours) * time.Hour),
+		}
+	}
+
+	t.Run("Pairwise", func(t *testing.T) {
+		events := []models.Event{slot(10, 1, monday, 1), slot(20, 2, monday.Add(24*time.Hour), 1)}
+		wants := []models.SwapWant{{UserID: 1, EventID: 10}, {UserID: 2, EventID: 20}}
+
+		matches := findMatches(1, events, wants, nil)
+		assert.Len(t, matches, 1)
+		assert.Equal(t, models.MatchPairwise, matches[0].Kind)
+		assert.Equal(t, "10-20", matches[0].ID)
+		assert.Equal(t, 100.0, matches[0].Score)
+	})
+
+	t.Run("One Sided Interest Is Not A Match", func(t *testing.T) {
+		events := []models.Event{slot(10, 1, monday, 1), slot(20, 2, monday, 1)}
+		wants := []models.SwapWant{{UserID: 1, EventID: 10}}
+
+		assert.Empty(t, findMatches(1, events, wants, nil))
+	})
+
+	t.Run("Three Way Cycle", func(t *testing.T) {
+		events := []models.Event{
+			slot(10, 1, monday, 1),
+			slot(20, 2, monday.Add(24*time.Hour), 1),
+			slot(30, 3, monday.Add(48*time.Hour), 1),
+		}
+		// 1 only takes Tuesday's slot, 2 only Wednesday's, 3 only Monday's.
+		wants := []models.SwapWant{
+			{UserID: 1, EventID: 10, ExcludedDays: "mon,wed"},
+			{UserID: 2, EventID: 20, ExcludedDays: "mon,tue"},
+			{UserID: 3, EventID: 30, ExcludedDays: "tue,wed"},
+		}
+
+		matches := findMatches(1, events, wants, nil)
+		assert.Len(t, matches, 1)
+		assert.Equal(t, models.MatchCycle, matches[0].Kind)
+		assert.Equal(t, []uint{10, 20, 30}, matches[0].EventIDs)
+		assert.Equal(t, uint(1), matches[0].Legs[1].ReceiverID)
+		assert.Less(t, matches[0].Score, 100.0)
+	})
+
+	t.Run("Duration Limits", func(t *testing.T) {
+		events := []models.Event{slot(10, 1, monday, 1), slot(20, 2, monday, 3)}
+		wants := []models.SwapWant{
+			{UserID: 1, EventID: 10, MaxDurationMinutes: 120},
+			{UserID: 2, EventID: 20},
+		}
+
+		assert.Empty(t, findMatches(1, events, wants, nil))
+	})
+}
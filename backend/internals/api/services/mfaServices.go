@@ -0,0 +1,312 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMFAAlreadyEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled         = errors.New("two-factor authentication is not enabled")
+	ErrMFAEnrollmentNotFound = errors.New("two-factor enrolment has not been started")
+	ErrInvalidMFACode        = errors.New("invalid two-factor code")
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew is how many 30 second steps either side of now are accepted.
+	totpSkew = 1
+)
+
+// mfaClock is the time source for TOTP checks; tests replace it with a fixed
+// clock.
+var mfaClock = time.Now
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// checkTOTP validates a TOTP code for user at now. A code from a step at or
+// before the last one accepted is refused, so each code works only once.
+func checkTOTP(user *models.User, code string, now time.Time) (int64, bool) {
+	counter, ok := pkg.ValidateTOTPCode(user.TOTPSecret, code, now, totpSkew)
+	if !ok || counter <= user.TOTPLastCounter {
+		return 0, false
+	}
+	return counter, true
+}
+
+func isTOTPFormat(code string) bool {
+	if len(code) != pkg.TOTPDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// lockMFAUser loads user for update so concurrent checks cannot both accept
+// the same TOTP step or recovery code.
+func lockMFAUser(tx *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := tx.Clauses(forUpdate).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// consumeMFACode accepts a TOTP code, or a recovery code when allowRecovery
+// is set, and records it as used.
+func consumeMFACode(tx *gorm.DB, user *models.User, code string, allowRecovery bool, now time.Time) error {
+	if isTOTPFormat(code) {
+		counter, ok := checkTOTP(user, code, now)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		user.TOTPLastCounter = counter
+		return tx.Model(user).Update("totp_last_counter", counter).Error
+	}
+
+	if !allowRecovery {
+		return ErrInvalidMFACode
+	}
+
+	result := tx.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, pkg.HashToken(pkg.NormalizeRecoveryCode(code))).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+
+	logger.Warn(fmt.Sprintf("Recovery code used by user %d", user.ID))
+	return nil
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and returns a new
+// set. The plain codes are only ever returned here.
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.MFARecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := pkg.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, models.MFARecoveryCode{UserID: userID, CodeHash: pkg.HashToken(pkg.NormalizeRecoveryCode(code))})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// BeginMFAEnrollment generates a TOTP secret for the user. Two-factor is not
+// enforced until ConfirmMFAEnrollment proves the authenticator works;
+// starting again replaces an unconfirmed secret.
+func BeginMFAEnrollment(userID uint, issuer string) (*MFAEnrollment, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var enrollment *MFAEnrollment
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockMFAUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.TOTPEnabledAt != nil {
+			return ErrMFAAlreadyEnabled
+		}
+
+		secret, err := pkg.NewTOTPSecret()
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":       secret,
+			"totp_last_counter": 0,
+		}).Error; err != nil {
+			return err
+		}
+
+		enrollment = &MFAEnrollment{Secret: secret, URI: pkg.TOTPURI(issuer, user.Email, secret)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// ConfirmMFAEnrollment turns two-factor on once the user enters a code from
+// their authenticator, and returns their recovery codes.
+func ConfirmMFAEnrollment(userID uint, code string) ([]string, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	now := mfaClock()
+	var codes []string
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockMFAUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.TOTPEnabledAt != nil {
+			return ErrMFAAlreadyEnabled
+		}
+		if user.TOTPSecret == "" {
+			return ErrMFAEnrollmentNotFound
+		}
+
+		if err := consumeMFACode(tx, user, code, false, now); err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Update("totp_enabled_at", now).Error; err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Two-factor authentication enabled for user %d", userID))
+	return codes, nil
+}
+
+// VerifyMFASignIn checks the second factor of a sign-in. Recovery codes are
+// accepted here so a user without their device can still get in.
+func VerifyMFASignIn(userID uint, code string) (*models.User, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	now := mfaClock()
+	var user *models.User
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = lockMFAUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.DisabledAt != nil {
+			return ErrAccountDisabled
+		}
+		if user.TOTPEnabledAt == nil {
+			return ErrMFANotEnabled
+		}
+
+		return consumeMFACode(tx, user, code, true, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DisableMFA turns two-factor off after checking a current TOTP or recovery
+// code, and discards the secret and recovery codes.
+func DisableMFA(userID uint, code string) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	now := mfaClock()
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockMFAUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.TOTPEnabledAt == nil {
+			return ErrMFANotEnabled
+		}
+
+		if err := consumeMFACode(tx, user, code, true, now); err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":       "",
+			"totp_enabled_at":   nil,
+			"totp_last_counter": 0,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Two-factor authentication disabled for user %d", userID))
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes. It needs a TOTP
+// code, since a leaked recovery code must not be enough to mint new ones.
+func RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	now := mfaClock()
+	var codes []string
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockMFAUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.TOTPEnabledAt == nil {
+			return ErrMFANotEnabled
+		}
+
+		if err := consumeMFACode(tx, user, code, false, now); err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Recovery codes regenerated for user %d", userID))
+	return codes, nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTOTP(t *testing.T) {
+	secret, err := pkg.NewTOTPSecret()
+	assert.NoError(t, err)
+
+	// A fixed clock keeps the test independent of when it runs.
+	now := time.Date(2025, 3, 1, 9, 0, 15, 0, time.UTC)
+	code, err := pkg.GenerateTOTPCode(secret, now)
+	assert.NoError(t, err)
+
+	t.Run("Accepts Current Code", func(t *testing.T) {
+		user := &models.User{TOTPSecret: secret}
+		counter, ok := checkTOTP(user, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, pkg.TOTPCounter(now), counter)
+	})
+
+	t.Run("Accepts Code From Previous Step", func(t *testing.T) {
+		user := &models.User{TOTPSecret: secret}
+		_, ok := checkTOTP(user, code, now.Add(pkg.TOTPPeriod))
+		assert.True(t, ok)
+	})
+
+	t.Run("Rejects Code Outside Window", func(t *testing.T) {
+		user := &models.User{TOTPSecret: secret}
+		_, ok := checkTOTP(user, code, now.Add(2*pkg.TOTPPeriod))
+		assert.False(t, ok)
+	})
+
+	t.Run("Rejects Replayed Code", func(t *testing.T) {
+		user := &models.User{TOTPSecret: secret, TOTPLastCounter: pkg.TOTPCounter(now)}
+		_, ok := checkTOTP(user, code, now)
+		assert.False(t, ok)
+	})
+
+	t.Run("Code Format", func(t *testing.T) {
+		assert.True(t, isTOTPFormat("012345"))
+		assert.False(t, isTOTPFormat("01234a"))
+		assert.False(t, isTOTPFormat("abcde-fghij"))
+	})
+}
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotOrganizationManager     = errors.New("organization owner or admin role required")
+	ErrInvalidInvitation          = errors.New("invalid or expired invitation")
+	ErrOrganizationNotFound       = errors.New("organization not found")
+	ErrTeamNotFound               = errors.New("team not found")
+	ErrOrganizationMemberNotFound = errors.New("organization member not found")
+	ErrTeamMemberNotFound         = errors.New("team member not found")
+	ErrInvitationNotFound         = errors.New("invitation not found")
+	ErrNotOrganizationMember      = errors.New("user is not a member of this organization")
+	ErrAlreadyOrganizationMember  = errors.New("user is already a member of this organization")
+	ErrLastOrganizationOwner      = errors.New("you cannot remove the last owner of an organization")
+	ErrInvitationForOtherEmail    = errors.New("invitation was sent to a different email address")
+)
+
+// OrganizationDetail is an organization as shown to its members.
+type OrganizationDetail struct {
+	models.Organization
+	Role    models.OrgRole                  `json:"role"`
+	Members []models.OrganizationMemberView `json:"members"`
+}
+
+// orgMembership returns userID's membership of orgID. Non-members get "not
+// found" so the API does not reveal which organizations exist.
+func orgMembership(tx *gorm.DB, orgID, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	if err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func isOrgManager(role models.OrgRole) bool {
+	return role == models.OrgRoleOwner || role == models.OrgRoleAdmin
+}
+
+// requireOrgManager checks that userID is an owner or admin of orgID.
+func requireOrgManager(tx *gorm.DB, orgID, userID uint) (*models.OrganizationMember, error) {
+	member, err := orgMembership(tx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isOrgManager(member.Role) {
+		return nil, ErrNotOrganizationManager
+	}
+	return member, nil
+}
+
+func findOrgTeam(tx *gorm.DB, orgID, teamID uint) (*models.Team, error) {
+	var team models.Team
+	if err := tx.Where("id = ? AND organization_id = ?", teamID, orgID).First(&team).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, err
+	}
+	return &team, nil
+}
+
+func invitationMessage(org *models.Organization, inviter *models.User, email, link string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      email,
+		Subject: fmt.Sprintf("You're invited to join %s on SlotSwapper", org.Name),
+		Body: fmt.Sprintf("Hi,\n\n"+
+			"%s has invited you to join %s on SlotSwapper. "+
+			"Sign in or create an account with this email address, then use the link below within %s to accept:\n\n%s\n\n"+
+			"If you weren't expecting this, you can ignore this email.\n",
+			inviter.Name, org.Name, humanDuration(ttl), link),
+	}
+}
+
+// CreateOrganization creates an organization owned by userID.
+func CreateOrganization(userID uint, name string) (*models.Organization, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	org := models.Organization{Name: strings.TrimSpace(name), CreatedByID: userID}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         userID,
+			Role:           models.OrgRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		logger.Error("Failed to create organization: " + err.Error())
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Organization %d created by user %d", org.ID, userID))
+	return &org, nil
+}
+
+// GetUserOrganizations lists the organizations userID belongs to, with their
+// teams.
+func GetUserOrganizations(userID uint) ([]models.Organization, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var orgs []models.Organization
+	if err := db.DB.Preload("Teams").
+		Joins("JOIN organization_members ON organization_members.organization_id = organizations.id").
+		Where("organization_members.user_id = ?", userID).
+		Order("organizations.name").
+		Find(&orgs).Error; err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// GetOrganization returns an organization with its teams and members.
+func GetOrganization(userID, orgID uint) (*OrganizationDetail, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	member, err := orgMembership(db.DB, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := OrganizationDetail{Role: member.Role}
+	if err := db.DB.Preload("Teams").First(&detail.Organization, orgID).Error; err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	var members []models.OrganizationMember
+	if err := db.DB.Preload("User").Where("organization_id = ?", orgID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	teamIDs := make([]uint, 0, len(detail.Teams))
+	for _, team := range detail.Teams {
+		teamIDs = append(teamIDs, team.ID)
+	}
+	var teamMembers []models.TeamMember
+	if len(teamIDs) > 0 {
+		if err := db.DB.Where("team_id IN ?", teamIDs).Find(&teamMembers).Error; err != nil {
+			return nil, err
+		}
+	}
+	userTeams := make(map[uint][]uint)
+	for _, tm := range teamMembers {
+		userTeams[tm.UserID] = append(userTeams[tm.UserID], tm.TeamID)
+	}
+
+	detail.Members = make([]models.OrganizationMemberView, 0, len(members))
+	for _, m := range members {
+		teams := userTeams[m.UserID]
+		if teams == nil {
+			teams = []uint{}
+		}
+		detail.Members = append(detail.Members, models.OrganizationMemberView{
+			UserID:  m.UserID,
+			Name:    m.User.Name,
+			Email:   m.User.Email,
+			Role:    m.Role,
+			TeamIDs: teams,
+		})
+	}
+
+	return &detail, nil
+}
+
+// CreateTeam adds a team to an organization. The creator is not added to it
+// automatically.
+func CreateTeam(actorID, orgID uint, name string) (*models.Team, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	if _, err := requireOrgManager(db.DB, orgID, actorID); err != nil {
+		return nil, err
+	}
+
+	team := models.Team{OrganizationID: orgID, Name: strings.TrimSpace(name)}
+	if err := db.DB.Create(&team).Error; err != nil {
+		logger.Error("Failed to create team: " + err.Error())
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Team %d created in organization %d by user %d", team.ID, orgID, actorID))
+	return &team, nil
+}
+
+// ListTeams returns an organization's teams to one of its members.
+func ListTeams(userID, orgID uint) ([]models.Team, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	if _, err := orgMembership(db.DB, orgID, userID); err != nil {
+		return nil, err
+	}
+
+	var teams []models.Team
+	if err := db.DB.Where("organization_id = ?", orgID).Order("name").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// AddTeamMember puts a member of the organization on one of its teams.
+func AddTeamMember(actorID, orgID, teamID, userID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := requireOrgManager(tx, orgID, actorID); err != nil {
+			return err
+		}
+		if _, err := findOrgTeam(tx, orgID, teamID); err != nil {
+			return err
+		}
+		if _, err := orgMembership(tx, orgID, userID); err != nil {
+			return ErrNotOrganizationMember
+		}
+
+		member := models.TeamMember{TeamID: teamID, UserID: userID}
+		return tx.Where(models.TeamMember{TeamID: teamID, UserID: userID}).FirstOrCreate(&member).Error
+	})
+}
+
+// RemoveTeamMember takes a user off a team. Managers can remove anyone;
+// members can remove themselves. Events the user scoped to the team keep
+// their scope.
+func RemoveTeamMember(actorID, orgID, teamID, userID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if actorID == userID {
+			if _, err := orgMembership(tx, orgID, actorID); err != nil {
+				return err
+			}
+		} else if _, err := requireOrgManager(tx, orgID, actorID); err != nil {
+			return err
+		}
+		if _, err := findOrgTeam(tx, orgID, teamID); err != nil {
+			return err
+		}
+
+		result := tx.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&models.TeamMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTeamMemberNotFound
+		}
+		return nil
+	})
+}
+
+// RemoveOrganizationMember removes a user from an organization and all of its
+// teams. Members may leave on their own; only owners can remove another
+// owner, and the last owner cannot leave.
+func RemoveOrganizationMember(actorID, orgID, userID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		actor, err := orgMembership(tx, orgID, actorID)
+		if err != nil {
+			return err
+		}
+		if actorID != userID && !isOrgManager(actor.Role) {
+			return ErrNotOrganizationManager
+		}
+
+		var target models.OrganizationMember
+		if err := tx.Clauses(forUpdate).
+			Where("organization_id = ? AND user_id = ?", orgID, userID).
+			First(&target).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrganizationMemberNotFound
+			}
+			return err
+		}
+
+		if target.Role == models.OrgRoleOwner {
+			if actor.Role != models.OrgRoleOwner {
+				return ErrNotOrganizationManager
+			}
+			var owners int64
+			if err := tx.Model(&models.OrganizationMember{}).
+				Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).
+				Count(&owners).Error; err != nil {
+				return err
+			}
+			if owners <= 1 {
+				return ErrLastOrganizationOwner
+			}
+		}
+
+		if err := tx.Where("user_id = ? AND team_id IN (?)", userID,
+			tx.Model(&models.Team{}).Select("id").Where("organization_id = ?", orgID)).
+			Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&target).Error
+	})
+}
+
+// CreateInvitation emails an invitation to join an organization, and
+// optionally one of its teams. A new invitation to the same address replaces
+// any still pending.
+func CreateInvitation(actorID, orgID uint, input *models.InvitationInput, cfg *config.Config, m mailer.Mailer) (*models.Invitation, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	role := input.Role
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+
+	token, err := pkg.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var org models.Organization
+	var inviter models.User
+	invitation := models.Invitation{
+		OrganizationID: orgID,
+		TeamID:         input.TeamID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      pkg.HashToken(token),
+		InvitedByID:    actorID,
+		ExpiresAt:      now.Add(cfg.INVITATION_TTL),
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := requireOrgManager(tx, orgID, actorID); err != nil {
+			return err
+		}
+		if err := tx.First(&org, orgID).Error; err != nil {
+			return ErrOrganizationNotFound
+		}
+		if err := tx.First(&inviter, actorID).Error; err != nil {
+			return err
+		}
+		if input.TeamID != nil {
+			if _, err := findOrgTeam(tx, orgID, *input.TeamID); err != nil {
+				return err
+			}
+		}
+
+		var existing int64
+		if err := tx.Model(&models.OrganizationMember{}).
+			Joins("JOIN users ON users.id = organization_members.user_id").
+			Where("organization_members.organization_id = ? AND LOWER(users.email) = ?", orgID, email).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyOrganizationMember
+		}
+
+		if err := tx.Model(&models.Invitation{}).
+			Where("organization_id = ? AND email = ? AND accepted_at IS NULL AND revoked_at IS NULL", orgID, email).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&invitation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	link := appLink(cfg.APP_BASE_URL, "/invitations/accept", token)
+	if err := m.Send(invitationMessage(&org, &inviter, email, link, cfg.INVITATION_TTL)); err != nil {
+		logger.Error(fmt.Sprintf("Failed to send invitation %d: %s", invitation.ID, err.Error()))
+		return nil, errors.New("failed to send invitation email")
+	}
+
+	logger.Info(fmt.Sprintf("Invitation %d to organization %d sent by user %d", invitation.ID, orgID, actorID))
+	return &invitation, nil
+}
+
+// ListInvitations returns an organization's pending invitations.
+func ListInvitations(actorID, orgID uint) ([]models.Invitation, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	if _, err := requireOrgManager(db.DB, orgID, actorID); err != nil {
+		return nil, err
+	}
+
+	var invitations []models.Invitation
+	if err := db.DB.
+		Where("organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", orgID, time.Now()).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation withdraws a pending invitation.
+func RevokeInvitation(actorID, orgID, invitationID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := requireOrgManager(tx, orgID, actorID); err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID, orgID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationNotFound
+		}
+		return nil
+	})
+}
+
+// AcceptInvitation adds userID to the organization, and team, an invitation
+// was for. The invitation is bound to the address it was sent to, so the
+// accepting account must be registered under that email.
+func AcceptInvitation(userID uint, token string) (*models.Organization, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var org models.Organization
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var invitation models.Invitation
+		if err := tx.Clauses(forUpdate).
+			Where("token_hash = ? AND accepted_at IS NULL AND revoked_at IS NULL", pkg.HashToken(token)).
+			First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidInvitation
+			}
+			return err
+		}
+
+		now := time.Now()
+		if !invitation.ExpiresAt.After(now) {
+			return ErrInvalidInvitation
+		}
+
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return ErrUserNotFound
+		}
+		if !strings.EqualFold(user.Email, invitation.Email) {
+			return ErrInvitationForOtherEmail
+		}
+
+		if err := tx.First(&org, invitation.OrganizationID).Error; err != nil {
+			return ErrInvalidInvitation
+		}
+
+		member := models.OrganizationMember{OrganizationID: org.ID, UserID: userID, Role: invitation.Role}
+		if err := tx.Where(models.OrganizationMember{OrganizationID: org.ID, UserID: userID}).
+			FirstOrCreate(&member).Error; err != nil {
+			return err
+		}
+
+		if invitation.TeamID != nil {
+			if _, err := findOrgTeam(tx, org.ID, *invitation.TeamID); err == nil {
+				teamMember := models.TeamMember{TeamID: *invitation.TeamID, UserID: userID}
+				if err := tx.Where(models.TeamMember{TeamID: *invitation.TeamID, UserID: userID}).
+					FirstOrCreate(&teamMember).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Model(&invitation).Updates(map[string]interface{}{
+			"accepted_at":    now,
+			"accepted_by_id": userID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("User %d joined organization %d", userID, org.ID))
+	return &org, nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// appLink builds a link into the frontend carrying a token as a query
+// parameter.
+func appLink(baseURL, path, token string) string {
+	return strings.TrimRight(baseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// humanDuration renders a token lifetime for an email, e.g. "1 hour".
+func humanDuration(d time.Duration) string {
+	value, unit := int(d/time.Minute), "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		value, unit = int(d/time.Hour), "hour"
+	}
+	if value != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", value, unit)
+}
+
+func passwordResetMessage(user *models.User, link string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your SlotSwapper password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password for your SlotSwapper account. "+
+			"Use the link below within %s to choose a new one:\n\n%s\n\n"+
+			"If this wasn't you, you can ignore this email; your password has not changed.\n",
+			user.Name, humanDuration(ttl), link),
+	}
+}
+
+// revokeUserSessions signs a user out everywhere, e.g. after their password
+// changes.
+func revokeUserSessions(tx *gorm.DB, userID uint, now time.Time) error {
+	var sessions []models.Session
+	if err := tx.Clauses(forUpdate).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		if err := revokeSession(tx, &sessions[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequestPasswordReset mails a reset link to the account registered under
+// email. Unknown addresses are ignored without an error so the endpoint
+// cannot be used to discover which emails have accounts. Requesting a new
+// link invalidates any earlier one.
+func RequestPasswordReset(email string, cfg *config.Config, m mailer.Mailer) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	var user models.User
+	if err := db.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Info("Password reset requested for unknown email: " + email)
+			return nil
+		}
+		return err
+	}
+
+	token, err := pkg.NewTokenID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: pkg.HashToken(token),
+			ExpiresAt: now.Add(cfg.PASSWORD_RESET_TTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link := appLink(cfg.APP_BASE_URL, "/reset-password", token)
+	if err := m.Send(passwordResetMessage(&user, link, cfg.PASSWORD_RESET_TTL)); err != nil {
+		// Reported only in the logs; a different response for known emails
+		// would give their existence away.
+		logger.Error(fmt.Sprintf("Failed to send password reset email to user %d: %s", user.ID, err.Error()))
+		return nil
+	}
+
+	logger.Info(fmt.Sprintf("Password reset email sent to user %d", user.ID))
+	return nil
+}
+
+// ResetPassword sets a new password using a token from RequestPasswordReset.
+// The token is consumed and every session of the user is revoked.
+func ResetPassword(token, newPassword string) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	hashedPassword, err := pkg.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var userID uint
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var resetToken models.PasswordResetToken
+		if err := tx.Clauses(forUpdate).
+			Where("token_hash = ?", pkg.HashToken(token)).
+			First(&resetToken).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+
+		if resetToken.UsedAt != nil || !now.Before(resetToken.ExpiresAt) {
+			return ErrInvalidResetToken
+		}
+		userID = resetToken.UserID
+
+		if err := tx.Model(&resetToken).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", resetToken.UserID).
+			Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		return revokeUserSessions(tx, resetToken.UserID, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Password reset for user %d; all sessions revoked", userID))
+	return nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetMessage(t *testing.T) {
+	t.Run("Link Escapes Token", func(t *testing.T) {
+		link := appLink("https://app.example.com/", "/reset-password", "a b&c")
+		assert.Equal(t, "https://app.example.com/reset-password?token=a+b%26c", link)
+	})
+
+	t.Run("Message Addresses The User", func(t *testing.T) {
+		user := &models.User{Name: "Sam", Email: "sam@example.com"}
+		msg := passwordResetMessage(user, "https://app.example.com/reset-password?token=abc", time.Hour)
+
+		assert.Equal(t, "sam@example.com", msg.To)
+		assert.Contains(t, msg.Body, "Hi Sam")
+		assert.Contains(t, msg.Body, "https://app.example.com/reset-password?token=abc")
+		assert.Contains(t, msg.Body, "within 1 hour")
+	})
+
+	t.Run("Human Duration", func(t *testing.T) {
+		assert.Equal(t, "2 hours", humanDuration(2*time.Hour))
+		assert.Equal(t, "90 minutes", humanDuration(90*time.Minute))
+		assert.Equal(t, "1 minute", humanDuration(time.Minute))
+	})
+}
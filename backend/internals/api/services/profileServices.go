@@ -0,0 +1,310 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrIncorrectPassword = errors.New("current password is incorrect")
+
+const accountDeletedDetails = "participant deleted their account"
+
+func emailChangeNoticeMessage(user *models.User, newEmail string) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Your SlotSwapper email address is changing",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to change the email address of your SlotSwapper account to %s. "+
+			"The change takes effect once the link sent to that address is opened.\n\n"+
+			"If this wasn't you, change your password right away.\n",
+			user.Name, newEmail),
+	}
+}
+
+// deletedEmail frees a deleted account's address for a new sign-up while
+// keeping the original recognisable in the soft-deleted row.
+func deletedEmail(user *models.User) string {
+	return fmt.Sprintf("deleted+%d+%s", user.ID, user.Email)
+}
+
+// UpdateProfile changes the user's name and time zone and starts an email
+// change. A new email is only stored as pending until its verification link
+// is used; the current address is told about the request.
+func UpdateProfile(userID uint, input *models.UpdateProfileInput, cfg *config.Config, m mailer.Mailer) (*models.User, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var user models.User
+	var newEmail string
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(forUpdate).First(&user, userID).Error; err != nil {
+			return errors.New("user not found")
+		}
+
+		errs := &ValidationError{}
+		updates := map[string]interface{}{}
+
+		if input.Name != nil {
+			name := strings.TrimSpace(*input.Name)
+			if name == "" {
+				errs.add("name", "is required")
+			}
+			user.Name = name
+			updates["name"] = name
+		}
+
+		if input.TimeZone != nil {
+			user.TimeZone = validateTimeZone("timeZone", *input.TimeZone, errs)
+			updates["time_zone"] = user.TimeZone
+		}
+
+		if input.Email != nil {
+			email := strings.TrimSpace(*input.Email)
+			switch {
+			case strings.EqualFold(email, user.Email):
+				// Asking for the current address cancels a pending change.
+				user.PendingEmail = ""
+				updates["pending_email"] = ""
+			case input.CurrentPassword == "":
+				errs.add("currentPassword", "is required to change your email")
+			case !pkg.ComparePassword(user.Password, input.CurrentPassword):
+				return ErrIncorrectPassword
+			default:
+				var taken int64
+				if err := tx.Model(&models.User{}).Where("email = ?", email).Count(&taken).Error; err != nil {
+					return err
+				}
+				if taken > 0 {
+					return ErrEmailTaken
+				}
+				newEmail = email
+				user.PendingEmail = email
+				updates["pending_email"] = email
+			}
+		}
+
+		if err := errs.errOrNil(); err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if newEmail != "" {
+		if err := sendEmailVerificationTo(&user, newEmail, cfg, m); err != nil {
+			logger.Error(fmt.Sprintf("Failed to send email change verification to user %d: %s", user.ID, err.Error()))
+			return nil, errors.New("failed to send verification email")
+		}
+		if err := m.Send(emailChangeNoticeMessage(&user, newEmail)); err != nil {
+			logger.Error(fmt.Sprintf("Failed to send email change notice to user %d: %s", user.ID, err.Error()))
+		}
+		logger.Info(fmt.Sprintf("User %d requested an email change", user.ID))
+	}
+
+	return &user, nil
+}
+
+// ChangePassword replaces the user's password after checking the current
+// one, and signs them out of every session.
+func ChangePassword(userID uint, currentPassword, newPassword string) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	hashedPassword, err := pkg.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(forUpdate).First(&user, userID).Error; err != nil {
+			return errors.New("user not found")
+		}
+		if !pkg.ComparePassword(user.Password, currentPassword) {
+			return ErrIncorrectPassword
+		}
+
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		return revokeUserSessions(tx, user.ID, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Password changed for user %d; all sessions revoked", userID))
+	return nil
+}
+
+// handOverOrganizations keeps every organization the user owns alone with
+// an owner: the longest-standing admin, or failing that member, is promoted.
+// Organizations with no other members are deleted.
+func handOverOrganizations(tx *gorm.DB, userID uint) error {
+	var owned []models.OrganizationMember
+	if err := tx.Where("user_id = ? AND role = ?", userID, models.OrgRoleOwner).Find(&owned).Error; err != nil {
+		return err
+	}
+
+	for _, membership := range owned {
+		var owners int64
+		if err := tx.Model(&models.OrganizationMember{}).
+			Where("organization_id = ? AND role = ?", membership.OrganizationID, models.OrgRoleOwner).
+			Count(&owners).Error; err != nil {
+			return err
+		}
+		if owners > 1 {
+			continue
+		}
+
+		var successor models.OrganizationMember
+		err := tx.Where("organization_id = ? AND user_id <> ?", membership.OrganizationID, userID).
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: "CASE WHEN role = ? THEN 0 ELSE 1 END, id", Vars: []interface{}{models.OrgRoleAdmin}}}).
+			Take(&successor).Error
+		if err == nil {
+			if err := tx.Model(&successor).Update("role", models.OrgRoleOwner).Error; err != nil {
+				return err
+			}
+			logger.Info(fmt.Sprintf("User %d promoted to owner of organization %d", successor.UserID, membership.OrganizationID))
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := tx.Where("organization_id = ?", membership.OrganizationID).Delete(&models.Team{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Organization{}, membership.OrganizationID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAccount closes the user's account after checking their password.
+// Open swap requests and pending swap cycles they take part in are cancelled
+// so the other parties' slots return to the marketplace; their events, wants
+// and the account itself are soft-deleted, and every session and API key is
+// revoked.
+func DeleteAccount(userID uint, password string) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	now := time.Now()
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(forUpdate).First(&user, userID).Error; err != nil {
+			return errors.New("user not found")
+		}
+		if !pkg.ComparePassword(user.Password, password) {
+			return ErrIncorrectPassword
+		}
+
+		var requests []models.SwapRequest
+		if err := tx.Clauses(forUpdate).
+			Where("(requester_id = ? OR responder_id = ?) AND status IN ?", userID, userID, []models.SwapStatus{models.PENDING, models.COUNTERED}).
+			Order("id").
+			Find(&requests).Error; err != nil {
+			return err
+		}
+		for i := range requests {
+			if err := forceCancelSwapRequest(tx, &requests[i], userID, accountDeletedDetails); err != nil {
+				return err
+			}
+		}
+
+		var cycleIDs []uint
+		if err := tx.Model(&models.SwapCycleLeg{}).
+			Joins("JOIN swap_cycles ON swap_cycles.id = swap_cycle_legs.cycle_id").
+			Where("swap_cycle_legs.giver_id = ? AND swap_cycles.status = ?", userID, models.SwapCyclePending).
+			Order("swap_cycle_legs.cycle_id").
+			Pluck("swap_cycle_legs.cycle_id", &cycleIDs).Error; err != nil {
+			return err
+		}
+		for _, cycleID := range cycleIDs {
+			var cycle models.SwapCycle
+			if err := tx.Clauses(forUpdate).Preload("Legs", orderLegsByPosition).First(&cycle, cycleID).Error; err != nil {
+				return err
+			}
+			cycle.Status = models.SwapCycleRejected
+			cycle.RejectedByID = &userID
+			if err := releaseSwapCycle(tx, &cycle); err != nil {
+				return err
+			}
+			if err := recordSwapCycleAudit(tx, &cycle, models.SwapAuditRejected, &userID, models.SwapCyclePending, accountDeletedDetails); err != nil {
+				return err
+			}
+		}
+
+		if err := handOverOrganizations(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.OrganizationMember{}).Error; err != nil {
+			return err
+		}
+
+		if err := revokeUserSessions(tx, userID, now); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.CalendarFeedToken{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SwapWant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_id = ?", userID).Delete(&models.Event{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_id = ?", userID).Delete(&models.EventSeries{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":         deletedEmail(&user),
+			"pending_email": "",
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Account %d deleted", userID))
+	return nil
+}
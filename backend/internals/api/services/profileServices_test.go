@@ -0,0 +1,129 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestEmailChangeNoticeGoesToCurrentAddress(t *testing.T) {
+	user := &models.User{Name: "Sam", Email: "sam@example.com", PendingEmail: "sam@new.example.com"}
+	msg := emailChangeNoticeMessage(user, user.PendingEmail)
+
+	assert.Equal(t, "sam@example.com", msg.To)
+	assert.Contains(t, msg.Body, "sam@new.example.com")
+}
+
+func TestDeletedEmailFreesAddress(t *testing.T) {
+	user := &models.User{Email: "sam@example.com"}
+	user.ID = 42
+
+	assert.Equal(t, "deleted+42+sam@example.com", deletedEmail(user))
+	assert.NotEqual(t, user.Email, deletedEmail(user))
+}
+
+// createTestOrganization stores an organization with the given members, in
+// the order given.
+func createTestOrganization(t *testing.T, conn *gorm.DB, name string, members map[uint]models.OrgRole, order ...uint) models.Organization {
+	t.Helper()
+	org := models.Organization{Name: name, CreatedByID: order[0]}
+	require.NoError(t, conn.Create(&org).Error)
+	for _, userID := range order {
+		require.NoError(t, conn.Create(&models.OrganizationMember{OrganizationID: org.ID, UserID: userID, Role: members[userID]}).Error)
+	}
+	return org
+}
+
+func orgRole(t *testing.T, conn *gorm.DB, orgID, userID uint) models.OrgRole {
+	t.Helper()
+	var member models.OrganizationMember
+	require.NoError(t, conn.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error)
+	return member.Role
+}
+
+func TestHandOverOrganizations(t *testing.T) {
+	conn := useTestDB(t)
+	leaver := createTestUser(t, conn, "Leaver")
+	early := createTestUser(t, conn, "Early")
+	late := createTestUser(t, conn, "Late")
+	admin := createTestUser(t, conn, "Admin")
+	coOwner := createTestUser(t, conn, "CoOwner")
+
+	withAdmin := createTestOrganization(t, conn, "With admin", map[uint]models.OrgRole{
+		leaver.ID: models.OrgRoleOwner, early.ID: models.OrgRoleMember, admin.ID: models.OrgRoleAdmin,
+	}, leaver.ID, early.ID, admin.ID)
+	membersOnly := createTestOrganization(t, conn, "Members only", map[uint]models.OrgRole{
+		leaver.ID: models.OrgRoleOwner, early.ID: models.OrgRoleMember, late.ID: models.OrgRoleMember,
+	}, leaver.ID, early.ID, late.ID)
+	shared := createTestOrganization(t, conn, "Shared", map[uint]models.OrgRole{
+		leaver.ID: models.OrgRoleOwner, coOwner.ID: models.OrgRoleOwner, early.ID: models.OrgRoleMember,
+	}, leaver.ID, coOwner.ID, early.ID)
+	alone := createTestOrganization(t, conn, "Alone", map[uint]models.OrgRole{leaver.ID: models.OrgRoleOwner}, leaver.ID)
+	require.NoError(t, conn.Create(&models.Team{OrganizationID: alone.ID, Name: "Solo"}).Error)
+
+	require.NoError(t, conn.Transaction(func(tx *gorm.DB) error {
+		return handOverOrganizations(tx, leaver.ID)
+	}))
+
+	assert.Equal(t, models.OrgRoleOwner, orgRole(t, conn, withAdmin.ID, admin.ID), "admins come before longer-standing members")
+	assert.Equal(t, models.OrgRoleMember, orgRole(t, conn, withAdmin.ID, early.ID))
+	assert.Equal(t, models.OrgRoleOwner, orgRole(t, conn, membersOnly.ID, early.ID), "the longest-standing member is promoted")
+	assert.Equal(t, models.OrgRoleMember, orgRole(t, conn, membersOnly.ID, late.ID))
+	assert.Equal(t, models.OrgRoleMember, orgRole(t, conn, shared.ID, early.ID), "organizations with another owner are left alone")
+
+	var count int64
+	require.NoError(t, conn.Model(&models.Organization{}).Where("id = ?", alone.ID).Count(&count).Error)
+	assert.Zero(t, count, "organizations without other members are deleted")
+	require.NoError(t, conn.Model(&models.Team{}).Where("organization_id = ?", alone.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestDeleteAccountReleasesOtherParties(t *testing.T) {
+	conn := useTestDB(t)
+
+	hash, err := pkg.HashPassword("secret")
+	require.NoError(t, err)
+	leaver := models.User{Name: "Leaver", Email: "leaver@example.com", Password: hash}
+	require.NoError(t, conn.Create(&leaver).Error)
+	requester := createTestUser(t, conn, "Requester")
+	third := createTestUser(t, conn, "Third")
+
+	requesterEvent := createTestEvent(t, conn, requester.ID, 24)
+	leaverEvent := createTestEvent(t, conn, leaver.ID, 26)
+	request, err := CreateSwapRequest(requester.ID, requesterEvent.ID, leaverEvent.ID, time.Hour, true)
+	require.NoError(t, err)
+
+	cycleEvents := []models.Event{
+		createTestEvent(t, conn, requester.ID, 28),
+		createTestEvent(t, conn, third.ID, 30),
+		createTestEvent(t, conn, leaver.ID, 32),
+	}
+	cycle, err := ProposeSwapCycle(requester.ID, []uint{cycleEvents[0].ID, cycleEvents[1].ID, cycleEvents[2].ID}, time.Hour, false)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, DeleteAccount(leaver.ID, "wrong"), ErrIncorrectPassword)
+	require.NoError(t, DeleteAccount(leaver.ID, "secret"))
+
+	require.NoError(t, conn.First(request, request.ID).Error)
+	assert.Equal(t, models.CANCELLED, request.Status)
+	require.NoError(t, conn.First(cycle, cycle.ID).Error)
+	assert.Equal(t, models.SwapCycleRejected, cycle.Status)
+	assert.Equal(t, leaver.ID, *cycle.RejectedByID)
+
+	for _, id := range []uint{requesterEvent.ID, cycleEvents[0].ID, cycleEvents[1].ID} {
+		var event models.Event
+		require.NoError(t, conn.First(&event, id).Error)
+		assert.Equal(t, models.EventStatusSwappable, event.Status, "event %d is back on the marketplace", id)
+	}
+
+	var remaining int64
+	require.NoError(t, conn.Model(&models.Event{}).Where("owner_id = ?", leaver.ID).Count(&remaining).Error)
+	assert.Zero(t, remaining)
+	require.NoError(t, conn.Model(&models.User{}).Where("email = ?", "leaver@example.com").Count(&remaining).Error)
+	assert.Zero(t, remaining, "the address is free for a new sign-up")
+}
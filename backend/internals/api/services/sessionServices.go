@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrAccountDisabled     = errors.New("account disabled")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// sessionIdleLifetime matches the refresh token lifetime: a session that has
+// not refreshed within it can no longer be resumed.
+const sessionIdleLifetime = 7 * 24 * time.Hour
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SessionMeta describes the device a session was opened from.
+type SessionMeta struct {
+	Device    string
+	IP        string
+	UserAgent string
+}
+
+// issueSessionTokens mints a token pair bound to session and records the
+// refresh hash and access jti on it. The caller saves the session.
+func issueSessionTokens(session *models.Session, user *models.User, cfg *config.Config) (*TokenPair, error) {
+	accessToken, accessClaims, err := pkg.GenerateAccessToken(user.ID, user.Email, string(user.Role), session.ID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := pkg.GenerateRefreshToken(user.ID, user.Email, session.ID, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := accessClaims.ExpiresAt.Time
+	session.RefreshTokenHash = pkg.HashToken(refreshToken)
+	session.AccessTokenID = accessClaims.ID
+	session.AccessTokenExpiresAt = &expiresAt
+
+	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// denylistToken records a jti as revoked until it would have expired anyway.
+func denylistToken(tx *gorm.DB, tokenID string, expiresAt *time.Time, now time.Time) error {
+	if tokenID == "" || expiresAt == nil || !expiresAt.After(now) {
+		return nil
+	}
+	entry := models.RevokedToken{TokenID: tokenID, ExpiresAt: *expiresAt}
+	return tx.Where(models.RevokedToken{TokenID: tokenID}).FirstOrCreate(&entry).Error
+}
+
+// revokeSession closes a session and denylists its latest access token.
+func revokeSession(tx *gorm.DB, session *models.Session, now time.Time) error {
+	if session.RevokedAt != nil {
+		return nil
+	}
+	session.RevokedAt = &now
+	if err := tx.Model(session).Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return denylistToken(tx, session.AccessTokenID, session.AccessTokenExpiresAt, now)
+}
+
+func pruneRevokedTokens(tx *gorm.DB, now time.Time) error {
+	return tx.Where("expires_at <= ?", now).Delete(&models.RevokedToken{}).Error
+}
+
+// CreateSession opens a new session for user and returns its first token
+// pair. Each sign-in gets its own session, so several devices can be signed
+// in at once.
+func CreateSession(user *models.User, meta SessionMeta, cfg *config.Config) (*models.Session, *TokenPair, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, nil, errors.New("database connection is nil")
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:     user.ID,
+		Device:     meta.Device,
+		IP:         meta.IP,
+		UserAgent:  meta.UserAgent,
+		LastUsedAt: now,
+	}
+
+	var tokens *TokenPair
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = issueSessionTokens(&session, user, cfg)
+		if err != nil {
+			return err
+		}
+
+		return tx.Save(&session).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Session %d opened for user %d", session.ID, user.ID))
+	return &session, tokens, nil
+}
+
+// RefreshSession exchanges a refresh token for a new pair. The presented
+// token must be the latest one issued for its session; replaying an older
+// token means it was copied, so every session of the user is revoked.
+func RefreshSession(refreshToken string, cfg *config.Config) (*TokenPair, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	claims, err := pkg.ValidateRefreshToken(refreshToken, cfg)
+	if err != nil || claims.SessionID == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	reused := false
+	var tokens *TokenPair
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		if err := tx.Clauses(forUpdate).
+			Where("id = ? AND user_id = ?", claims.SessionID, claims.UserID).
+			First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if session.RevokedAt != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		if session.RefreshTokenHash != pkg.HashToken(refreshToken) {
+			reused = true
+			return revokeUserSessions(tx, session.UserID, now)
+		}
+
+		var user models.User
+		if err := tx.First(&user, session.UserID).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+		if user.DisabledAt != nil {
+			return ErrAccountDisabled
+		}
+
+		// The access token being replaced is no longer needed by the client.
+		if err := denylistToken(tx, session.AccessTokenID, session.AccessTokenExpiresAt, now); err != nil {
+			return err
+		}
+
+		tokens, err = issueSessionTokens(&session, &user, cfg)
+		if err != nil {
+			return err
+		}
+		session.LastUsedAt = now
+
+		return tx.Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		logger.Warn(fmt.Sprintf("Refresh token reuse detected for session %d, all sessions of user %d revoked", claims.SessionID, claims.UserID))
+		return nil, ErrRefreshTokenReused
+	}
+
+	return tokens, nil
+}
+
+// ListSessions returns the user's live sessions, most recently used first,
+// marking the one currentSessionID belongs to.
+func ListSessions(userID, currentSessionID uint) ([]models.Session, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var sessions []models.Session
+	if err := db.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND last_used_at > ?", userID, time.Now().Add(-sessionIdleLifetime)).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's devices out.
+func RevokeSession(sessionID, userID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	now := time.Now()
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		if err := tx.Clauses(forUpdate).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+			First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionNotFound
+			}
+			return err
+		}
+
+		if err := revokeSession(tx, &session, now); err != nil {
+			return err
+		}
+
+		return pruneRevokedTokens(tx, now)
+	})
+}
+
+// Logout ends the session the caller's access token belongs to and
+// denylists that token. Tokens issued before sessions existed carry no
+// session ID; for those only the token itself is revoked.
+func Logout(userID, sessionID uint, tokenID string, tokenExpiresAt time.Time) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	now := time.Now()
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if sessionID != 0 {
+			var session models.Session
+			err := tx.Clauses(forUpdate).
+				Where("id = ? AND user_id = ?", sessionID, userID).
+				First(&session).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				if err := revokeSession(tx, &session, now); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := denylistToken(tx, tokenID, &tokenExpiresAt, now); err != nil {
+			return err
+		}
+
+		return pruneRevokedTokens(tx, now)
+	})
+}
+
+// IsTokenRevoked reports whether an access token's jti has been denylisted.
+func IsTokenRevoked(tokenID string) (bool, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return false, errors.New("database connection is nil")
+	}
+
+	var count int64
+	if err := db.DB.Model(&models.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	pkg "github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/security"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sessionTestConfig = &config.Config{ACCESS_TOKEN_SECRET: "access", REFRESH_TOKEN_SECRET: "refresh"}
+
+// accessTokenID reads the jti of an access token issued with
+// sessionTestConfig.
+func accessTokenID(t *testing.T, token string) string {
+	t.Helper()
+	claims, err := pkg.ValidateAccessToken(token, sessionTestConfig)
+	require.NoError(t, err)
+	return claims.ID
+}
+
+func assertRevoked(t *testing.T, tokenID string, want bool) {
+	t.Helper()
+	revoked, err := IsTokenRevoked(tokenID)
+	require.NoError(t, err)
+	assert.Equal(t, want, revoked)
+}
+
+func TestRefreshSessionRotatesTokens(t *testing.T) {
+	conn := useTestDB(t)
+	user := createTestUser(t, conn, "Refresher")
+
+	session, first, err := CreateSession(&user, SessionMeta{Device: "Laptop"}, sessionTestConfig)
+	require.NoError(t, err)
+
+	second, err := RefreshSession(first.RefreshToken, sessionTestConfig)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.NotEqual(t, first.AccessToken, second.AccessToken)
+
+	assertRevoked(t, accessTokenID(t, first.AccessToken), true)
+	assertRevoked(t, accessTokenID(t, second.AccessToken), false)
+
+	require.NoError(t, conn.First(session, session.ID).Error)
+	assert.Equal(t, pkg.HashToken(second.RefreshToken), session.RefreshTokenHash)
+	assert.Nil(t, session.RevokedAt)
+
+	_, err = RefreshSession("not a token", sessionTestConfig)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRefreshTokenReuseRevokesEverySession(t *testing.T) {
+	conn := useTestDB(t)
+	user := createTestUser(t, conn, "Victim")
+	other := createTestUser(t, conn, "Bystander")
+
+	_, laptop, err := CreateSession(&user, SessionMeta{Device: "Laptop"}, sessionTestConfig)
+	require.NoError(t, err)
+	_, phone, err := CreateSession(&user, SessionMeta{Device: "Phone"}, sessionTestConfig)
+	require.NoError(t, err)
+	_, bystander, err := CreateSession(&other, SessionMeta{Device: "Desktop"}, sessionTestConfig)
+	require.NoError(t, err)
+
+	rotated, err := RefreshSession(laptop.RefreshToken, sessionTestConfig)
+	require.NoError(t, err)
+
+	_, err = RefreshSession(laptop.RefreshToken, sessionTestConfig)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	sessions, err := ListSessions(user.ID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	assertRevoked(t, accessTokenID(t, rotated.AccessToken), true)
+	assertRevoked(t, accessTokenID(t, phone.AccessToken), true)
+
+	_, err = RefreshSession(phone.RefreshToken, sessionTestConfig)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken, "revoked sessions cannot be resumed")
+
+	sessions, err = ListSessions(other.ID, 0)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1, "other users stay signed in")
+	assertRevoked(t, accessTokenID(t, bystander.AccessToken), false)
+}
+
+func TestRevokeSession(t *testing.T) {
+	conn := useTestDB(t)
+	user := createTestUser(t, conn, "Owner")
+	other := createTestUser(t, conn, "Other")
+
+	laptop, laptopTokens, err := CreateSession(&user, SessionMeta{Device: "Laptop"}, sessionTestConfig)
+	require.NoError(t, err)
+	phone, phoneTokens, err := CreateSession(&user, SessionMeta{Device: "Phone"}, sessionTestConfig)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, RevokeSession(phone.ID, other.ID), ErrSessionNotFound)
+
+	require.NoError(t, RevokeSession(phone.ID, user.ID))
+	assertRevoked(t, accessTokenID(t, phoneTokens.AccessToken), true)
+	assertRevoked(t, accessTokenID(t, laptopTokens.AccessToken), false)
+
+	sessions, err := ListSessions(user.ID, laptop.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, laptop.ID, sessions[0].ID)
+	assert.True(t, sessions[0].Current)
+
+	assert.ErrorIs(t, RevokeSession(phone.ID, user.ID), ErrSessionNotFound, "a session is revoked only once")
+	_, err = RefreshSession(phoneTokens.RefreshToken, sessionTestConfig)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	conn := useTestDB(t)
+	user := createTestUser(t, conn, "Leaver")
+
+	session, tokens, err := CreateSession(&user, SessionMeta{}, sessionTestConfig)
+	require.NoError(t, err)
+	tokenID := accessTokenID(t, tokens.AccessToken)
+	assertRevoked(t, tokenID, false)
+
+	require.NoError(t, Logout(user.ID, session.ID, tokenID, time.Now().Add(15*time.Minute)))
+	assertRevoked(t, tokenID, true)
+
+	t.Run("Expired Entries Are Pruned", func(t *testing.T) {
+		require.NoError(t, conn.Create(&models.RevokedToken{TokenID: "stale", ExpiresAt: time.Now().Add(-time.Minute)}).Error)
+		require.NoError(t, Logout(user.ID, 0, "fresh", time.Now().Add(time.Minute)))
+		assertRevoked(t, "stale", false)
+		assertRevoked(t, "fresh", true)
+	})
+
+	t.Run("Expired Tokens Are Not Stored", func(t *testing.T) {
+		require.NoError(t, Logout(user.ID, 0, "expired", time.Now().Add(-time.Minute)))
+		assertRevoked(t, "expired", false)
+	})
+}
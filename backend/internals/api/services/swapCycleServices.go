@@ -0,0 +1,413 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
+)
+
+const minSwapCycleLegs = 3
+
+func orderLegsByPosition(tx *gorm.DB) *gorm.DB {
+	return tx.Order("position ASC")
+}
+
+// buildCycleLegs turns an ordered list of events into cycle legs. Every event
+// must be swappable and belong to a different user, and the proposer must own
+// one of them. Each event is handed to the owner of the event before it.
+func buildCycleLegs(proposerID uint, events []*models.Event) ([]models.SwapCycleLeg, error) {
+	if len(events) < minSwapCycleLegs {
+		return nil, fmt.Errorf("a swap cycle needs at least %d events, use a swap request for two-way swaps", minSwapCycleLegs)
+	}
+
+	owners := make(map[uint]bool, len(events))
+	seenEvents := make(map[uint]bool, len(events))
+	for _, event := range events {
+		if seenEvents[event.ID] {
+			return nil, errors.New("an event can only appear once in a swap cycle")
+		}
+		seenEvents[event.ID] = true
+
+		if owners[event.OwnerID] {
+			return nil, errors.New("each participant can only give one event in a swap cycle")
+		}
+		owners[event.OwnerID] = true
+
+		if event.Status != models.EventStatusSwappable {
+			return nil, fmt.Errorf("event %d is not swappable", event.ID)
+		}
+	}
+
+	if !owners[proposerID] {
+		return nil, errors.New("proposer must give one of the events in the swap cycle")
+	}
+
+	legs := make([]models.SwapCycleLeg, len(events))
+	for i, event := range events {
+		previous := events[(i+len(events)-1)%len(events)]
+		legs[i] = models.SwapCycleLeg{
+			Position:   i,
+			EventID:    event.ID,
+			GiverID:    event.OwnerID,
+			ReceiverID: previous.OwnerID,
+		}
+	}
+
+	return legs, nil
+}
+
+// cycleLegFor returns the leg given by userID, or nil if they are not part of
+// the cycle.
+func cycleLegFor(cycle *models.SwapCycle, userID uint) *models.SwapCycleLeg {
+	for i := range cycle.Legs {
+		if cycle.Legs[i].GiverID == userID {
+			return &cycle.Legs[i]
+		}
+	}
+	return nil
+}
+
+func allLegsApproved(cycle *models.SwapCycle) bool {
+	for _, leg := range cycle.Legs {
+		if leg.ApprovedAt == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func cycleEventIDs(cycle *models.SwapCycle) []uint {
+	ids := make([]uint, len(cycle.Legs))
+	for i, leg := range cycle.Legs {
+		ids[i] = leg.EventID
+	}
+	return ids
+}
+
+// enforceCycleConflictPolicy checks each leg's event against the calendar of
+// the participant receiving it, ignoring the event they give away in the
+// cycle. Like a swap request, only actorID's WARN policy can be overridden
+// with ignoreConflicts; the others are enforced when their policy is BLOCK.
+func enforceCycleConflictPolicy(tx *gorm.DB, legs []models.SwapCycleLeg, events map[uint]*models.Event, actorID uint, ignoreConflicts bool) error {
+	givenAway := make(map[uint]uint, len(legs))
+	for _, leg := range legs {
+		givenAway[leg.GiverID] = leg.EventID
+	}
+
+	for _, leg := range legs {
+		isActor := leg.ReceiverID == actorID
+		if err := enforceConflictPolicy(tx, leg.ReceiverID, events[leg.EventID], givenAway[leg.ReceiverID], isActor, ignoreConflicts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ProposeSwapCycle(proposerID uint, eventIDs []uint, ttl time.Duration, ignoreConflicts bool) (*models.SwapCycle, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var cycle models.SwapCycle
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockEvents(tx, eventIDs...)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("event not found")
+			}
+			return err
+		}
+
+		events := make([]*models.Event, len(eventIDs))
+		for i, id := range eventIDs {
+			events[i] = locked[id]
+		}
+
+		legs, err := buildCycleLegs(proposerID, events)
+		if err != nil {
+			return err
+		}
+
+		if err := validateCycleScope(tx, legs, locked); err != nil {
+			return err
+		}
+
+		if err := enforceCycleConflictPolicy(tx, legs, locked, proposerID, ignoreConflicts); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range legs {
+			if legs[i].GiverID == proposerID {
+				legs[i].ApprovedAt = &now
+			}
+		}
+
+		for _, event := range events {
+			event.Status = models.EventStatusSwapPending
+			if err := tx.Save(event).Error; err != nil {
+				logger.Error("Failed to update cycle event status: " + err.Error())
+				return err
+			}
+		}
+
+		cycle = models.SwapCycle{
+			ProposerID: proposerID,
+			Status:     models.SwapCyclePending,
+			Legs:       legs,
+			ExpiresAt:  swapDeadline(now, ttl),
+		}
+
+		if err := tx.Create(&cycle).Error; err != nil {
+			logger.Error("Failed to create swap cycle: " + err.Error())
+			return err
+		}
+
+		return recordSwapCycleAudit(tx, &cycle, models.SwapAuditCreated, &proposerID, "", "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Swap cycle %d proposed by user %d with %d legs", cycle.ID, proposerID, len(cycle.Legs)))
+	return &cycle, nil
+}
+
+func GetUserSwapCycles(userID uint) ([]models.SwapCycle, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var cycles []models.SwapCycle
+	if err := db.DB.Preload("Legs", orderLegsByPosition).Preload("Legs.Event").
+		Where("id IN (?)", db.DB.Model(&models.SwapCycleLeg{}).Select("cycle_id").Where("giver_id = ?", userID)).
+		Order("created_at DESC").Find(&cycles).Error; err != nil {
+		logger.Error("Failed to fetch swap cycles: " + err.Error())
+		return nil, err
+	}
+
+	return cycles, nil
+}
+
+func GetSwapCycleByID(cycleID uint, userID uint) (*models.SwapCycle, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var cycle models.SwapCycle
+	if err := db.DB.Preload("Legs", orderLegsByPosition).Preload("Legs.Event").First(&cycle, cycleID).Error; err != nil {
+		logger.Error("Swap cycle not found: " + err.Error())
+		return nil, errors.New("swap cycle not found")
+	}
+
+	if cycleLegFor(&cycle, userID) == nil {
+		return nil, errors.New("swap cycle not found")
+	}
+
+	return &cycle, nil
+}
+
+// RespondToSwapCycle records a participant's answer. A rejection releases
+// every event in the cycle; the final approval commits the whole rotation.
+// Every approval checks each participant's calendar again, as they may have
+// added BUSY events since the cycle was proposed.
+func RespondToSwapCycle(cycleID uint, userID uint, accepted bool, ignoreConflicts bool) (*models.SwapCycle, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var cycle models.SwapCycle
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(forUpdate).Preload("Legs", orderLegsByPosition).First(&cycle, cycleID).Error; err != nil {
+			logger.Error("Swap cycle not found: " + err.Error())
+			return errors.New("swap cycle not found")
+		}
+
+		leg := cycleLegFor(&cycle, userID)
+		if leg == nil {
+			return errors.New("unauthorized to respond to this swap cycle")
+		}
+
+		if cycle.Status != models.SwapCyclePending {
+			return errors.New("swap cycle is no longer pending")
+		}
+
+		now := time.Now()
+		if cycle.ExpiresAt != nil && !now.Before(*cycle.ExpiresAt) {
+			return errors.New("swap cycle has expired")
+		}
+
+		if !accepted {
+			cycle.Status = models.SwapCycleRejected
+			cycle.RejectedByID = &userID
+			if err := releaseSwapCycle(tx, &cycle); err != nil {
+				return err
+			}
+			return recordSwapCycleAudit(tx, &cycle, models.SwapAuditRejected, &userID, models.SwapCyclePending, "")
+		}
+
+		if leg.ApprovedAt != nil {
+			return errors.New("you have already accepted this swap cycle")
+		}
+
+		locked, err := lockEvents(tx, cycleEventIDs(&cycle)...)
+		if err != nil {
+			logger.Error("Failed to lock swap cycle events: " + err.Error())
+			return errors.New("swap cycle events not found")
+		}
+		if err := enforceCycleConflictPolicy(tx, cycle.Legs, locked, userID, ignoreConflicts); err != nil {
+			return err
+		}
+
+		leg.ApprovedAt = &now
+		if err := tx.Model(leg).Update("approved_at", now).Error; err != nil {
+			logger.Error("Failed to record swap cycle approval: " + err.Error())
+			return err
+		}
+
+		details := fmt.Sprintf("approved leg %d", leg.Position)
+		if err := recordSwapCycleAudit(tx, &cycle, models.SwapAuditApproved, &userID, cycle.Status, details); err != nil {
+			return err
+		}
+
+		if !allLegsApproved(&cycle) {
+			return nil
+		}
+
+		return commitSwapCycle(tx, &cycle, userID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("User %d responded to swap cycle %d, status now %s", userID, cycleID, cycle.Status))
+	return &cycle, nil
+}
+
+// commitSwapCycle rotates ownership across every leg. It must run inside the
+// transaction holding the cycle lock; any stale leg aborts the whole commit.
+func commitSwapCycle(tx *gorm.DB, cycle *models.SwapCycle, actorID uint, now time.Time) error {
+	locked, err := lockEvents(tx, cycleEventIDs(cycle)...)
+	if err != nil {
+		logger.Error("Failed to lock swap cycle events: " + err.Error())
+		return errors.New("swap cycle events not found")
+	}
+
+	for _, leg := range cycle.Legs {
+		event := locked[leg.EventID]
+		if event.Status != models.EventStatusSwapPending || event.OwnerID != leg.GiverID {
+			return fmt.Errorf("event %d has changed since the swap cycle was proposed", event.ID)
+		}
+	}
+
+	for _, leg := range cycle.Legs {
+		event := locked[leg.EventID]
+		event.OwnerID = leg.ReceiverID
+		event.Status = models.EventStatusBusy
+		if err := tx.Save(event).Error; err != nil {
+			logger.Error("Failed to update swap cycle event: " + err.Error())
+			return err
+		}
+		if err := recordOwnershipChange(tx, event, leg.GiverID, nil, &cycle.ID); err != nil {
+			return err
+		}
+	}
+
+	previousStatus := cycle.Status
+	cycle.Status = models.SwapCycleCommitted
+	cycle.CommittedAt = &now
+	if err := tx.Omit("Legs").Save(cycle).Error; err != nil {
+		logger.Error("Failed to update swap cycle: " + err.Error())
+		return err
+	}
+
+	return recordSwapCycleAudit(tx, cycle, models.SwapAuditCommitted, &actorID, previousStatus, "")
+}
+
+// releaseSwapCycle puts the cycle's still-pending events back on the
+// marketplace and saves the cycle in its new terminal status.
+func releaseSwapCycle(tx *gorm.DB, cycle *models.SwapCycle) error {
+	locked, err := lockEvents(tx, cycleEventIDs(cycle)...)
+	if err != nil {
+		logger.Error("Failed to lock swap cycle events: " + err.Error())
+		return errors.New("swap cycle events not found")
+	}
+
+	for _, leg := range cycle.Legs {
+		event := locked[leg.EventID]
+		if event.Status != models.EventStatusSwapPending || event.OwnerID != leg.GiverID {
+			continue
+		}
+		event.Status = models.EventStatusSwappable
+		if err := tx.Save(event).Error; err != nil {
+			logger.Error("Failed to release swap cycle event: " + err.Error())
+			return err
+		}
+	}
+
+	if err := tx.Omit("Legs").Save(cycle).Error; err != nil {
+		logger.Error("Failed to update swap cycle: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ExpireStaleSwapCycles is the swap cycle counterpart of
+// ExpireStaleSwapRequests.
+func ExpireStaleSwapCycles(now time.Time) (int, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return 0, errors.New("database connection is nil")
+	}
+
+	var ids []uint
+	if err := db.DB.Model(&models.SwapCycle{}).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at <= ?", models.SwapCyclePending, now).
+		Pluck("id", &ids).Error; err != nil {
+		logger.Error("Failed to fetch stale swap cycles: " + err.Error())
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		ok := false
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			var cycle models.SwapCycle
+			if err := tx.Clauses(forUpdate).Preload("Legs", orderLegsByPosition).First(&cycle, id).Error; err != nil {
+				return err
+			}
+			if cycle.Status != models.SwapCyclePending || cycle.ExpiresAt == nil || now.Before(*cycle.ExpiresAt) {
+				return nil
+			}
+
+			cycle.Status = models.SwapCycleExpired
+			if err := releaseSwapCycle(tx, &cycle); err != nil {
+				return err
+			}
+			if err := recordSwapCycleAudit(tx, &cycle, models.SwapAuditExpired, nil, models.SwapCyclePending, ""); err != nil {
+				return err
+			}
+			ok = true
+			return nil
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to expire swap cycle %d: %s", id, err.Error()))
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
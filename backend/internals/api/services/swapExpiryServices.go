@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
+)
+
+// ExpireStaleSwapRequests moves every open request whose deadline has passed
+// to EXPIRED and releases its events. Each request is expired in its own
+// transaction so one failure does not hold back the rest of the batch.
+func ExpireStaleSwapRequests(now time.Time) (int, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return 0, errors.New("database connection is nil")
+	}
+
+	var ids []uint
+	if err := db.DB.Model(&models.SwapRequest{}).
+		Where("status IN ? AND expires_at IS NOT NULL AND expires_at <= ?", []models.SwapStatus{models.PENDING, models.COUNTERED}, now).
+		Pluck("id", &ids).Error; err != nil {
+		logger.Error("Failed to fetch stale swap requests: " + err.Error())
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		ok, err := expireSwapRequest(id, now)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to expire swap request %d: %s", id, err.Error()))
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+func expireSwapRequest(requestID uint, now time.Time) (bool, error) {
+	expired := false
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var request models.SwapRequest
+		if err := tx.Clauses(forUpdate).First(&request, requestID).Error; err != nil {
+			return err
+		}
+
+		// The request may have been answered between the scan and the lock.
+		if !isOpenSwapStatus(request.Status) || !isSwapExpired(&request, now) {
+			return nil
+		}
+
+		requesterEvent, responderEvent, err := lockEventPair(tx, request.RequesterEventID, request.ResponderEventID)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range []*models.Event{requesterEvent, responderEvent} {
+			if event.Status != models.EventStatusSwapPending {
+				continue
+			}
+			event.Status = models.EventStatusSwappable
+			if err := tx.Save(event).Error; err != nil {
+				return err
+			}
+		}
+
+		previousStatus := request.Status
+		request.Status = models.EXPIRED
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+
+		if err := recordSwapRequestAudit(tx, &request, models.SwapAuditExpired, nil, previousStatus, ""); err != nil {
+			return err
+		}
+
+		expired = true
+		return nil
+	})
+
+	return expired, err
+}
+
+// StartSwapExpirySweeper runs ExpireStaleSwapRequests and
+// ExpireStaleSwapCycles every interval in a background goroutine. The
+// returned function stops the sweeper.
+func StartSwapExpirySweeper(interval time.Duration) func() {
+	if interval <= 0 {
+		logger.Warn("Swap expiry sweeper disabled: interval must be positive")
+		return func() {}
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				count, err := ExpireStaleSwapRequests(now)
+				if err != nil {
+					logger.Error("Swap expiry sweep failed: " + err.Error())
+				} else if count > 0 {
+					logger.Info(fmt.Sprintf("Expired %d stale swap requests", count))
+				}
+
+				count, err = ExpireStaleSwapCycles(now)
+				if err != nil {
+					logger.Error("Swap cycle expiry sweep failed: " + err.Error())
+				} else if count > 0 {
+					logger.Info(fmt.Sprintf("Expired %d stale swap cycles", count))
+				}
+			}
+		}
+	}()
+
+	logger.Info("Swap expiry sweeper started with interval " + interval.String())
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
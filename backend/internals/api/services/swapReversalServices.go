@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
+)
+
+// checkReversalAllowed validates that userID may ask to undo request at now.
+func checkReversalAllowed(request *models.SwapRequest, userID uint, now time.Time, window time.Duration) error {
+	if request.RequesterID != userID && request.ResponderID != userID {
+		return errors.New("unauthorized to reverse this swap")
+	}
+
+	if request.Status != models.ACCEPTED {
+		return errors.New("only accepted swaps can be reversed")
+	}
+
+	if request.AcceptedAt == nil || window <= 0 || !now.Before(request.AcceptedAt.Add(window)) {
+		return errors.New("the undo window for this swap has closed")
+	}
+
+	if request.ReversalRequestedByID != nil && *request.ReversalRequestedByID == userID {
+		return errors.New("you have already requested to reverse this swap")
+	}
+
+	return nil
+}
+
+// ReverseSwapRequest asks to undo an accepted swap. Under the unilateral
+// policy, or when the other party has already asked, ownership and statuses
+// are restored from the ownership history recorded at accept time and the
+// request moves to REVERSED. Otherwise the request is marked as awaiting the
+// other party's agreement.
+func ReverseSwapRequest(requestID uint, userID uint, window time.Duration, policy string) (*models.SwapRequest, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var request models.SwapRequest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(forUpdate).First(&request, requestID).Error; err != nil {
+			logger.Error("Swap request not found: " + err.Error())
+			return errors.New("swap request not found")
+		}
+
+		now := time.Now()
+		if err := checkReversalAllowed(&request, userID, now, window); err != nil {
+			return err
+		}
+
+		if policy != models.SwapUndoUnilateral && request.ReversalRequestedByID == nil {
+			request.ReversalRequestedByID = &userID
+			request.ReversalRequestedAt = &now
+			if err := tx.Save(&request).Error; err != nil {
+				logger.Error("Failed to record reversal request: " + err.Error())
+				return err
+			}
+			return recordSwapRequestAudit(tx, &request, models.SwapAuditReversalRequested, &userID, request.Status, "")
+		}
+
+		return restoreSwappedEvents(tx, &request, userID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("User %d reversal of swap request %d, status now %s", userID, requestID, request.Status))
+	return &request, nil
+}
+
+// restoreSwappedEvents hands both events back to the owners they had before
+// request was accepted. Events that have moved on since, for example by being
+// offered in another swap, block the reversal.
+func restoreSwappedEvents(tx *gorm.DB, request *models.SwapRequest, userID uint, now time.Time) error {
+	requesterEvent, responderEvent, err := lockEventPair(tx, request.RequesterEventID, request.ResponderEventID)
+	if err != nil {
+		logger.Error("Failed to lock swap events: " + err.Error())
+		return errors.New("swap events not found")
+	}
+
+	var changes []models.EventOwnershipHistory
+	if err := tx.Where("swap_request_id = ?", request.ID).Find(&changes).Error; err != nil {
+		logger.Error("Failed to fetch ownership history for reversal: " + err.Error())
+		return err
+	}
+
+	// Requests accepted before ownership history existed fall back to the
+	// parties recorded on the request itself.
+	previousOwners := map[uint]uint{
+		requesterEvent.ID: request.RequesterID,
+		responderEvent.ID: request.ResponderID,
+	}
+	currentOwners := map[uint]uint{
+		requesterEvent.ID: request.ResponderID,
+		responderEvent.ID: request.RequesterID,
+	}
+	for _, change := range changes {
+		previousOwners[change.EventID] = change.PreviousOwnerID
+		currentOwners[change.EventID] = change.NewOwnerID
+	}
+
+	for _, event := range []*models.Event{requesterEvent, responderEvent} {
+		if event.OwnerID != currentOwners[event.ID] || event.Status != models.EventStatusBusy {
+			return fmt.Errorf("event %d has changed since the swap and cannot be reversed", event.ID)
+		}
+	}
+
+	for _, event := range []*models.Event{requesterEvent, responderEvent} {
+		swappedOwnerID := event.OwnerID
+		event.OwnerID = previousOwners[event.ID]
+		event.Status = models.EventStatusSwappable
+		if err := tx.Save(event).Error; err != nil {
+			logger.Error("Failed to restore swapped event: " + err.Error())
+			return err
+		}
+		if err := recordOwnershipChange(tx, event, swappedOwnerID, &request.ID, nil); err != nil {
+			return err
+		}
+	}
+
+	previousStatus := request.Status
+	request.Status = models.REVERSED
+	request.ReversedByID = &userID
+	request.ReversedAt = &now
+	if err := tx.Save(request).Error; err != nil {
+		logger.Error("Failed to update swap request: " + err.Error())
+		return err
+	}
+
+	return recordSwapRequestAudit(tx, request, models.SwapAuditReversed, &userID, previousStatus, "")
+}
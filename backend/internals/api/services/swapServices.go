@@ -2,62 +2,194 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"time"
 
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
 	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func CreateSwapRequest(requesterID uint, requesterEventID uint, responderEventID uint) (*models.SwapRequest, error) {
-	if db.DB == nil {
-		logger.Error("Database connection is nil")
-		return nil, errors.New("database connection is nil")
+var (
+	ErrSwapRequestNotFound   = errors.New("swap request not found")
+	ErrSwapRequestNotPending = errors.New("swap request is no longer pending")
+)
+
+// forUpdate adds a SELECT ... FOR UPDATE lock to the query it is applied to.
+var forUpdate = clause.Locking{Strength: clause.LockingStrengthUpdate}
+
+// lockEvents loads and row-locks the given events, keyed by ID. Rows are
+// always locked in ascending ID order so two concurrent swaps touching the
+// same events cannot deadlock each other.
+func lockEvents(tx *gorm.DB, ids ...uint) (map[uint]*models.Event, error) {
+	sorted := append([]uint(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	locked := make(map[uint]*models.Event, len(sorted))
+	for _, id := range sorted {
+		if _, ok := locked[id]; ok {
+			continue
+		}
+		var event models.Event
+		if err := tx.Clauses(forUpdate).First(&event, id).Error; err != nil {
+			return nil, err
+		}
+		locked[id] = &event
 	}
-	requesterEvent, err := GetEventByID(requesterEventID)
+
+	return locked, nil
+}
+
+// lockEventPair is lockEvents for the two events of a single swap.
+func lockEventPair(tx *gorm.DB, firstID uint, secondID uint) (*models.Event, *models.Event, error) {
+	locked, err := lockEvents(tx, firstID, secondID)
 	if err != nil {
-		return nil, errors.New("requester event not found")
+		return nil, nil, err
 	}
 
-	responderEvent, err := GetEventByID(responderEventID)
-	if err != nil {
-		return nil, errors.New("responder event not found")
+	return locked[firstID], locked[secondID], nil
+}
+
+// isOpenSwapStatus reports whether a request in this status still holds its
+// events in SWAP_PENDING.
+func isOpenSwapStatus(status models.SwapStatus) bool {
+	return status == models.PENDING || status == models.COUNTERED
+}
+
+// awaitingResponseFrom returns the user whose turn it is to answer an open
+// request: the responder for PENDING, the original requester for COUNTERED.
+func awaitingResponseFrom(request *models.SwapRequest) uint {
+	if request.Status == models.COUNTERED {
+		return request.RequesterID
+	}
+	return request.ResponderID
+}
+
+// isSwapExpired reports whether an open request has passed its deadline.
+// Requests created before expiry existed have no deadline and never expire.
+func isSwapExpired(request *models.SwapRequest, now time.Time) bool {
+	return request.ExpiresAt != nil && !now.Before(*request.ExpiresAt)
+}
+
+// swapDeadline returns the expiry time for a request opened now, or nil when
+// ttl is not positive.
+func swapDeadline(now time.Time, ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	deadline := now.Add(ttl)
+	return &deadline
+}
+
+// validateSwapCreation checks that a new swap request can be opened between
+// the two events on behalf of requesterID.
+func validateSwapCreation(requesterID uint, requesterEvent *models.Event, responderEvent *models.Event) error {
+	if requesterEvent.ID == responderEvent.ID {
+		return errors.New("cannot swap an event with itself")
 	}
 
 	if requesterEvent.OwnerID != requesterID {
-		return nil, errors.New("requester does not own the event")
+		return errors.New("requester does not own the event")
+	}
+
+	if responderEvent.OwnerID == requesterID {
+		return errors.New("cannot request a swap with your own event")
 	}
 
 	if responderEvent.Status != models.EventStatusSwappable {
-		return nil, errors.New("responder event is not swappable")
+		return errors.New("responder event is not swappable")
 	}
 
 	if requesterEvent.Status != models.EventStatusSwappable {
-		return nil, errors.New("requester event is not swappable")
+		return errors.New("requester event is not swappable")
 	}
 
-	swapRequest := models.SwapRequest{
-		RequesterID:      requesterID,
-		ResponderID:      responderEvent.OwnerID,
-		RequesterEventID: requesterEventID,
-		ResponderEventID: responderEventID,
-		Status:           models.PENDING,
+	return nil
+}
+
+// validatePendingSwap re-checks, under lock, that both events are still held
+// for the request and still belong to the parties that opened it.
+func validatePendingSwap(request *models.SwapRequest, requesterEvent *models.Event, responderEvent *models.Event) error {
+	if !isOpenSwapStatus(request.Status) {
+		return ErrSwapRequestNotPending
 	}
 
-	if err := db.DB.Create(&swapRequest).Error; err != nil {
-		logger.Error("Failed to create swap request: " + err.Error())
-		return nil, err
+	if requesterEvent.Status != models.EventStatusSwapPending || responderEvent.Status != models.EventStatusSwapPending {
+		return errors.New("events are no longer pending swap")
 	}
 
-	requesterEvent.Status = models.EventStatusSwapPending
-	responderEvent.Status = models.EventStatusSwapPending
+	if requesterEvent.OwnerID != request.RequesterID || responderEvent.OwnerID != request.ResponderID {
+		return errors.New("event ownership has changed since the request was made")
+	}
 
-	if err := db.DB.Save(requesterEvent).Error; err != nil {
-		logger.Error("Failed to update requester event status: " + err.Error())
-		return nil, err
+	return nil
+}
+
+func CreateSwapRequest(requesterID uint, requesterEventID uint, responderEventID uint, ttl time.Duration, ignoreConflicts bool) (*models.SwapRequest, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
 	}
 
-	if err := db.DB.Save(responderEvent).Error; err != nil {
-		logger.Error("Failed to update responder event status: " + err.Error())
+	var swapRequest models.SwapRequest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		requesterEvent, responderEvent, err := lockEventPair(tx, requesterEventID, responderEventID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("event not found")
+			}
+			return err
+		}
+
+		if err := validateSwapCreation(requesterID, requesterEvent, responderEvent); err != nil {
+			return err
+		}
+
+		if err := validateSwapScope(tx, requesterID, requesterEvent, responderEvent); err != nil {
+			return err
+		}
+
+		if err := enforceConflictPolicy(tx, requesterID, responderEvent, requesterEvent.ID, true, ignoreConflicts); err != nil {
+			return err
+		}
+
+		if err := enforceConflictPolicy(tx, responderEvent.OwnerID, requesterEvent, responderEvent.ID, false, false); err != nil {
+			return err
+		}
+
+		requesterEvent.Status = models.EventStatusSwapPending
+		responderEvent.Status = models.EventStatusSwapPending
+
+		if err := tx.Save(requesterEvent).Error; err != nil {
+			logger.Error("Failed to update requester event status: " + err.Error())
+			return err
+		}
+
+		if err := tx.Save(responderEvent).Error; err != nil {
+			logger.Error("Failed to update responder event status: " + err.Error())
+			return err
+		}
+
+		swapRequest = models.SwapRequest{
+			RequesterID:      requesterID,
+			ResponderID:      responderEvent.OwnerID,
+			RequesterEventID: requesterEventID,
+			ResponderEventID: responderEventID,
+			Status:           models.PENDING,
+			ExpiresAt:        swapDeadline(time.Now(), ttl),
+		}
+
+		if err := tx.Create(&swapRequest).Error; err != nil {
+			logger.Error("Failed to create swap request: " + err.Error())
+			return err
+		}
+
+		return recordSwapRequestAudit(tx, &swapRequest, models.SwapAuditCreated, &requesterID, "", "")
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -65,14 +197,19 @@ func CreateSwapRequest(requesterID uint, requesterEventID uint, responderEventID
 	return &swapRequest, nil
 }
 
+// GetIncomingSwapRequests lists the requests made to the user, together with
+// the counter-offers waiting on them. A COUNTERED request is answered by its
+// original requester (see awaitingResponseFrom), so it is incoming for them
+// and not for the responder who made it.
 func GetIncomingSwapRequests(userID uint) ([]models.SwapRequest, error) {
 	if db.DB == nil {
 		logger.Error("Database connection is nil")
 		return nil, errors.New("database connection is nil")
 	}
 	var requests []models.SwapRequest
-	if err := db.DB.Preload("Requester").Preload("RequesterEvent").Preload("ResponderEvent").
-		Where("responder_id = ?", userID).Find(&requests).Error; err != nil {
+	if err := db.DB.Preload("Requester").Preload("Responder").Preload("RequesterEvent").Preload("ResponderEvent").
+		Where("(responder_id = ? AND status <> ?) OR (requester_id = ? AND status = ?)", userID, models.COUNTERED, userID, models.COUNTERED).
+		Find(&requests).Error; err != nil {
 		logger.Error("Failed to fetch incoming swap requests: " + err.Error())
 		return nil, err
 	}
@@ -95,61 +232,333 @@ func GetOutgoingSwapRequests(userID uint) ([]models.SwapRequest, error) {
 	return requests, nil
 }
 
-func RespondToSwapRequest(requestID uint, userID uint, accepted bool) error {
+func RespondToSwapRequest(requestID uint, userID uint, accepted bool, ignoreConflicts bool) error {
 	if db.DB == nil {
 		logger.Error("Database connection is nil")
 		return errors.New("database connection is nil")
 	}
-	var request models.SwapRequest
-	if err := db.DB.Preload("RequesterEvent").Preload("ResponderEvent").First(&request, requestID).Error; err != nil {
-		logger.Error("Swap request not found: " + err.Error())
-		return errors.New("swap request not found")
-	}
 
-	if request.ResponderID != userID {
-		return errors.New("unauthorized to respond to this request")
-	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var request models.SwapRequest
+		if err := tx.Clauses(forUpdate).First(&request, requestID).Error; err != nil {
+			logger.Error("Swap request not found: " + err.Error())
+			return ErrSwapRequestNotFound
+		}
+
+		if awaitingResponseFrom(&request) != userID {
+			return errors.New("unauthorized to respond to this request")
+		}
+
+		if isOpenSwapStatus(request.Status) && isSwapExpired(&request, time.Now()) {
+			return errors.New("swap request has expired")
+		}
+
+		requesterEvent, responderEvent, err := lockEventPair(tx, request.RequesterEventID, request.ResponderEventID)
+		if err != nil {
+			logger.Error("Failed to lock swap events: " + err.Error())
+			return errors.New("swap events not found")
+		}
 
-	if accepted {
-		request.Status = models.ACCEPTED
+		if err := validatePendingSwap(&request, requesterEvent, responderEvent); err != nil {
+			return err
+		}
 
-		tempOwnerID := request.RequesterEvent.OwnerID
-		request.RequesterEvent.OwnerID = request.ResponderEvent.OwnerID
-		request.ResponderEvent.OwnerID = tempOwnerID
+		if accepted {
+			if err := enforceConflictPolicy(tx, request.RequesterID, responderEvent, requesterEvent.ID, request.RequesterID == userID, ignoreConflicts); err != nil {
+				return err
+			}
 
-		request.RequesterEvent.Status = models.EventStatusBusy
-		request.ResponderEvent.Status = models.EventStatusBusy
+			if err := enforceConflictPolicy(tx, request.ResponderID, requesterEvent, responderEvent.ID, request.ResponderID == userID, ignoreConflicts); err != nil {
+				return err
+			}
+		}
 
-		if err := db.DB.Save(&request.RequesterEvent).Error; err != nil {
+		previousStatus := request.Status
+		if accepted {
+			now := time.Now()
+			request.Status = models.ACCEPTED
+			request.AcceptedAt = &now
+
+			requesterEvent.OwnerID = request.ResponderID
+			responderEvent.OwnerID = request.RequesterID
+
+			requesterEvent.Status = models.EventStatusBusy
+			responderEvent.Status = models.EventStatusBusy
+		} else {
+			request.Status = models.REJECTED
+			requesterEvent.Status = models.EventStatusSwappable
+			responderEvent.Status = models.EventStatusSwappable
+		}
+
+		if err := tx.Save(requesterEvent).Error; err != nil {
 			logger.Error("Failed to update requester event: " + err.Error())
 			return err
 		}
 
-		if err := db.DB.Save(&request.ResponderEvent).Error; err != nil {
+		if err := tx.Save(responderEvent).Error; err != nil {
 			logger.Error("Failed to update responder event: " + err.Error())
 			return err
 		}
-	} else {
-		request.Status = models.REJECTED
-		request.RequesterEvent.Status = models.EventStatusSwappable
-		request.ResponderEvent.Status = models.EventStatusSwappable
 
-		if err := db.DB.Save(&request.RequesterEvent).Error; err != nil {
+		if err := tx.Save(&request).Error; err != nil {
+			logger.Error("Failed to update swap request: " + err.Error())
+			return err
+		}
+
+		if !accepted {
+			return recordSwapRequestAudit(tx, &request, models.SwapAuditRejected, &userID, previousStatus, "")
+		}
+
+		if err := recordOwnershipChange(tx, requesterEvent, request.RequesterID, &request.ID, nil); err != nil {
+			return err
+		}
+
+		if err := recordOwnershipChange(tx, responderEvent, request.ResponderID, &request.ID, nil); err != nil {
+			return err
+		}
+
+		return recordSwapRequestAudit(tx, &request, models.SwapAuditAccepted, &userID, previousStatus, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Swap request responded to successfully")
+	return nil
+}
+
+func CancelSwapRequest(requestID uint, userID uint) error {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return errors.New("database connection is nil")
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var request models.SwapRequest
+		if err := tx.Clauses(forUpdate).First(&request, requestID).Error; err != nil {
+			logger.Error("Swap request not found: " + err.Error())
+			return ErrSwapRequestNotFound
+		}
+
+		if request.RequesterID != userID {
+			return errors.New("unauthorized to cancel this request")
+		}
+
+		if request.Status == models.COUNTERED {
+			return errors.New("a counter-offer cannot be cancelled, reject it instead")
+		}
+
+		requesterEvent, responderEvent, err := lockEventPair(tx, request.RequesterEventID, request.ResponderEventID)
+		if err != nil {
+			logger.Error("Failed to lock swap events: " + err.Error())
+			return errors.New("swap events not found")
+		}
+
+		if err := validatePendingSwap(&request, requesterEvent, responderEvent); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		previousStatus := request.Status
+		request.Status = models.CANCELLED
+		request.CancelledByID = &userID
+		request.CancelledAt = &now
+
+		requesterEvent.Status = models.EventStatusSwappable
+		responderEvent.Status = models.EventStatusSwappable
+
+		if err := tx.Save(requesterEvent).Error; err != nil {
 			logger.Error("Failed to update requester event: " + err.Error())
 			return err
 		}
 
-		if err := db.DB.Save(&request.ResponderEvent).Error; err != nil {
+		if err := tx.Save(responderEvent).Error; err != nil {
 			logger.Error("Failed to update responder event: " + err.Error())
 			return err
 		}
-	}
 
-	if err := db.DB.Save(&request).Error; err != nil {
-		logger.Error("Failed to update swap request: " + err.Error())
+		if err := tx.Save(&request).Error; err != nil {
+			logger.Error("Failed to update swap request: " + err.Error())
+			return err
+		}
+
+		return recordSwapRequestAudit(tx, &request, models.SwapAuditCancelled, &userID, previousStatus, "")
+	})
+	if err != nil {
 		return err
 	}
 
-	logger.Info("Swap request responded to successfully")
+	logger.Info(fmt.Sprintf("Swap request %d cancelled by user %d", requestID, userID))
 	return nil
 }
+
+// forceCancelSwapRequest cancels an open request on behalf of actorID, who
+// need not be its requester. Unlike CancelSwapRequest it must also work on
+// requests whose events have drifted, so only events still held are
+// released.
+func forceCancelSwapRequest(tx *gorm.DB, request *models.SwapRequest, actorID uint, details string) error {
+	requesterEvent, responderEvent, err := lockEventPair(tx, request.RequesterEventID, request.ResponderEventID)
+	if err != nil {
+		return errors.New("swap events not found")
+	}
+
+	for _, event := range []*models.Event{requesterEvent, responderEvent} {
+		if event.Status != models.EventStatusSwapPending {
+			continue
+		}
+		event.Status = models.EventStatusSwappable
+		if err := tx.Save(event).Error; err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	previousStatus := request.Status
+	request.Status = models.CANCELLED
+	request.CancelledByID = &actorID
+	request.CancelledAt = &now
+	if err := tx.Save(request).Error; err != nil {
+		return err
+	}
+
+	return recordSwapRequestAudit(tx, request, models.SwapAuditCancelled, &actorID, previousStatus, details)
+}
+
+// CounterSwapRequest answers an open request with a different event of the
+// caller's own. The original request is superseded and a linked request is
+// opened for the other party: the responder countering produces a COUNTERED
+// request for the original requester, and the requester countering back
+// produces a fresh PENDING request for the responder.
+func CounterSwapRequest(requestID uint, userID uint, offeredEventID uint, ttl time.Duration) (*models.SwapRequest, error) {
+	if db.DB == nil {
+		logger.Error("Database connection is nil")
+		return nil, errors.New("database connection is nil")
+	}
+
+	var counter models.SwapRequest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var request models.SwapRequest
+		if err := tx.Clauses(forUpdate).First(&request, requestID).Error; err != nil {
+			logger.Error("Swap request not found: " + err.Error())
+			return ErrSwapRequestNotFound
+		}
+
+		if !isOpenSwapStatus(request.Status) {
+			return ErrSwapRequestNotPending
+		}
+
+		if awaitingResponseFrom(&request) != userID {
+			return errors.New("unauthorized to counter this request")
+		}
+
+		now := time.Now()
+		if isSwapExpired(&request, now) {
+			return errors.New("swap request has expired")
+		}
+
+		counter = models.SwapRequest{
+			RequesterID:      request.RequesterID,
+			ResponderID:      request.ResponderID,
+			RequesterEventID: request.RequesterEventID,
+			ResponderEventID: request.ResponderEventID,
+			ParentRequestID:  &request.ID,
+			ExpiresAt:        swapDeadline(now, ttl),
+		}
+
+		replacedEventID := request.ResponderEventID
+		if userID == request.ResponderID {
+			counter.ResponderEventID = offeredEventID
+			counter.Status = models.COUNTERED
+		} else {
+			replacedEventID = request.RequesterEventID
+			counter.RequesterEventID = offeredEventID
+			counter.Status = models.PENDING
+		}
+
+		if offeredEventID == replacedEventID {
+			return errors.New("counter-offer must propose a different event")
+		}
+
+		locked, err := lockEvents(tx, request.RequesterEventID, request.ResponderEventID, offeredEventID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("event not found")
+			}
+			return err
+		}
+
+		requesterEvent := locked[request.RequesterEventID]
+		responderEvent := locked[request.ResponderEventID]
+		offeredEvent := locked[offeredEventID]
+
+		if err := validatePendingSwap(&request, requesterEvent, responderEvent); err != nil {
+			return err
+		}
+
+		if offeredEvent.OwnerID != userID {
+			return errors.New("offered event is not owned by you")
+		}
+
+		if offeredEvent.Status != models.EventStatusSwappable {
+			return errors.New("offered event is not swappable")
+		}
+
+		otherPartyID, otherPartyEventID := request.RequesterID, request.RequesterEventID
+		if userID == request.RequesterID {
+			otherPartyID, otherPartyEventID = request.ResponderID, request.ResponderEventID
+		}
+
+		if userID == request.ResponderID {
+			err = validateSwapScope(tx, request.RequesterID, requesterEvent, offeredEvent)
+		} else {
+			err = validateSwapScope(tx, request.RequesterID, offeredEvent, responderEvent)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := enforceConflictPolicy(tx, otherPartyID, offeredEvent, otherPartyEventID, false, false); err != nil {
+			return err
+		}
+
+		replacedEvent := locked[replacedEventID]
+		replacedEvent.Status = models.EventStatusSwappable
+		offeredEvent.Status = models.EventStatusSwapPending
+
+		if err := tx.Save(replacedEvent).Error; err != nil {
+			logger.Error("Failed to release replaced event: " + err.Error())
+			return err
+		}
+
+		if err := tx.Save(offeredEvent).Error; err != nil {
+			logger.Error("Failed to update offered event: " + err.Error())
+			return err
+		}
+
+		previousStatus := request.Status
+		request.Status = models.SUPERSEDED
+		if err := tx.Save(&request).Error; err != nil {
+			logger.Error("Failed to update swap request: " + err.Error())
+			return err
+		}
+
+		if err := tx.Create(&counter).Error; err != nil {
+			logger.Error("Failed to create counter-offer: " + err.Error())
+			return err
+		}
+
+		details := fmt.Sprintf("countered with request %d offering event %d", counter.ID, offeredEventID)
+		if err := recordSwapRequestAudit(tx, &request, models.SwapAuditCountered, &userID, previousStatus, details); err != nil {
+			return err
+		}
+
+		details = fmt.Sprintf("counter-offer to request %d", request.ID)
+		return recordSwapRequestAudit(tx, &counter, models.SwapAuditCreated, &userID, "", details)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("Swap request %d countered by user %d with request %d", requestID, userID, counter.ID))
+	return &counter, nil
+}
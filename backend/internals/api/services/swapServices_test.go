@@ -7,6 +7,7 @@ import (
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock database for testing
@@ -148,4 +149,356 @@ func TestRespondToSwapRequest(t *testing.T) {
 		wrongResponderID := uint(999)
 		assert.NotEqual(t, wrongResponderID, swapRequest.ResponderID)
 	})
-}
\ No newline at end of file
+}
+
+func TestValidateSwapCreation(t *testing.T) {
+	requesterEvent := &models.Event{ID: 10, OwnerID: 1, Status: models.EventStatusSwappable}
+	responderEvent := &models.Event{ID: 20, OwnerID: 2, Status: models.EventStatusSwappable}
+
+	t.Run("Valid Pair", func(t *testing.T) {
+		assert.NoError(t, validateSwapCreation(1, requesterEvent, responderEvent))
+	})
+
+	t.Run("Requester Does Not Own Event", func(t *testing.T) {
+		assert.EqualError(t, validateSwapCreation(999, requesterEvent, responderEvent), "requester does not own the event")
+	})
+
+	t.Run("Same Event", func(t *testing.T) {
+		assert.Error(t, validateSwapCreation(1, requesterEvent, requesterEvent))
+	})
+
+	t.Run("Responder Event Already Pending", func(t *testing.T) {
+		pending := *responderEvent
+		pending.Status = models.EventStatusSwapPending
+		assert.EqualError(t, validateSwapCreation(1, requesterEvent, &pending), "responder event is not swappable")
+	})
+}
+
+func TestValidatePendingSwap(t *testing.T) {
+	request := &models.SwapRequest{RequesterID: 1, ResponderID: 2, Status: models.PENDING}
+	requesterEvent := &models.Event{ID: 10, OwnerID: 1, Status: models.EventStatusSwapPending}
+	responderEvent := &models.Event{ID: 20, OwnerID: 2, Status: models.EventStatusSwapPending}
+
+	t.Run("Still Pending", func(t *testing.T) {
+		assert.NoError(t, validatePendingSwap(request, requesterEvent, responderEvent))
+	})
+
+	t.Run("Already Accepted", func(t *testing.T) {
+		accepted := *request
+		accepted.Status = models.ACCEPTED
+		assert.EqualError(t, validatePendingSwap(&accepted, requesterEvent, responderEvent), "swap request is no longer pending")
+	})
+
+	t.Run("Event Released", func(t *testing.T) {
+		released := *requesterEvent
+		released.Status = models.EventStatusSwappable
+		assert.Error(t, validatePendingSwap(request, &released, responderEvent))
+	})
+
+	t.Run("Owner Changed", func(t *testing.T) {
+		moved := *responderEvent
+		moved.OwnerID = 3
+		assert.Error(t, validatePendingSwap(request, requesterEvent, &moved))
+	})
+
+	t.Run("Countered", func(t *testing.T) {
+		countered := *request
+		countered.Status = models.COUNTERED
+		assert.NoError(t, validatePendingSwap(&countered, requesterEvent, responderEvent))
+	})
+
+	t.Run("Superseded", func(t *testing.T) {
+		superseded := *request
+		superseded.Status = models.SUPERSEDED
+		assert.EqualError(t, validatePendingSwap(&superseded, requesterEvent, responderEvent), "swap request is no longer pending")
+	})
+}
+
+func TestAwaitingResponseFrom(t *testing.T) {
+	request := &models.SwapRequest{RequesterID: 1, ResponderID: 2, Status: models.PENDING}
+	assert.Equal(t, uint(2), awaitingResponseFrom(request))
+
+	request.Status = models.COUNTERED
+	assert.Equal(t, uint(1), awaitingResponseFrom(request))
+}
+
+func TestIsSwapExpired(t *testing.T) {
+	now := time.Now()
+
+	t.Run("No Deadline", func(t *testing.T) {
+		assert.False(t, isSwapExpired(&models.SwapRequest{}, now))
+		assert.Nil(t, swapDeadline(now, 0))
+	})
+
+	t.Run("Before Deadline", func(t *testing.T) {
+		request := &models.SwapRequest{ExpiresAt: swapDeadline(now, time.Hour)}
+		assert.False(t, isSwapExpired(request, now))
+	})
+
+	t.Run("Past Deadline", func(t *testing.T) {
+		request := &models.SwapRequest{ExpiresAt: swapDeadline(now, time.Hour)}
+		assert.True(t, isSwapExpired(request, now.Add(2*time.Hour)))
+	})
+}
+
+func TestBuildCycleLegs(t *testing.T) {
+	events := []*models.Event{
+		{ID: 10, OwnerID: 1, Status: models.EventStatusSwappable},
+		{ID: 20, OwnerID: 2, Status: models.EventStatusSwappable},
+		{ID: 30, OwnerID: 3, Status: models.EventStatusSwappable},
+	}
+
+	t.Run("Three Way Rotation", func(t *testing.T) {
+		legs, err := buildCycleLegs(1, events)
+		assert.NoError(t, err)
+		assert.Len(t, legs, 3)
+
+		// A (1) receives B's slot, B (2) receives C's slot, C (3) receives A's slot.
+		assert.Equal(t, uint(3), legs[0].ReceiverID)
+		assert.Equal(t, uint(1), legs[1].ReceiverID)
+		assert.Equal(t, uint(2), legs[2].ReceiverID)
+		for i, leg := range legs {
+			assert.Equal(t, i, leg.Position)
+			assert.Equal(t, events[i].OwnerID, leg.GiverID)
+		}
+	})
+
+	t.Run("Too Few Events", func(t *testing.T) {
+		_, err := buildCycleLegs(1, events[:2])
+		assert.Error(t, err)
+	})
+
+	t.Run("Proposer Not Participating", func(t *testing.T) {
+		_, err := buildCycleLegs(99, events)
+		assert.Error(t, err)
+	})
+
+	t.Run("Participant Gives Twice", func(t *testing.T) {
+		duplicate := append([]*models.Event{}, events...)
+		duplicate = append(duplicate, &models.Event{ID: 40, OwnerID: 1, Status: models.EventStatusSwappable})
+		_, err := buildCycleLegs(1, duplicate)
+		assert.Error(t, err)
+	})
+
+	t.Run("Event Not Swappable", func(t *testing.T) {
+		busy := []*models.Event{events[0], events[1], {ID: 30, OwnerID: 3, Status: models.EventStatusBusy}}
+		_, err := buildCycleLegs(1, busy)
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckReversalAllowed(t *testing.T) {
+	acceptedAt := time.Now().Add(-time.Hour)
+	request := &models.SwapRequest{RequesterID: 1, ResponderID: 2, Status: models.ACCEPTED, AcceptedAt: &acceptedAt}
+	now := time.Now()
+
+	t.Run("Within Window", func(t *testing.T) {
+		assert.NoError(t, checkReversalAllowed(request, 1, now, 24*time.Hour))
+		assert.NoError(t, checkReversalAllowed(request, 2, now, 24*time.Hour))
+	})
+
+	t.Run("Window Closed", func(t *testing.T) {
+		assert.EqualError(t, checkReversalAllowed(request, 1, now, 30*time.Minute), "the undo window for this swap has closed")
+	})
+
+	t.Run("Not A Party", func(t *testing.T) {
+		assert.Error(t, checkReversalAllowed(request, 3, now, 24*time.Hour))
+	})
+
+	t.Run("Not Accepted", func(t *testing.T) {
+		rejected := *request
+		rejected.Status = models.REJECTED
+		assert.Error(t, checkReversalAllowed(&rejected, 1, now, 24*time.Hour))
+	})
+
+	t.Run("Already Requested By Same Party", func(t *testing.T) {
+		requested := *request
+		requesterID := uint(1)
+		requested.ReversalRequestedByID = &requesterID
+		assert.Error(t, checkReversalAllowed(&requested, 1, now, 24*time.Hour))
+		assert.NoError(t, checkReversalAllowed(&requested, 2, now, 24*time.Hour))
+	})
+}
+
+func TestFindConflicts(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	incoming := &models.Event{ID: 1, StartTime: start, EndTime: start.Add(2 * time.Hour)}
+	calendar := []models.Event{
+		{ID: 2, Title: "Overlaps", StartTime: start.Add(time.Hour), EndTime: start.Add(3 * time.Hour)},
+		{ID: 3, Title: "Touches", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(4 * time.Hour)},
+		{ID: 4, Title: "Given away", StartTime: start, EndTime: start.Add(time.Hour)},
+	}
+
+	conflicts := findConflicts(incoming, calendar, 4)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, uint(2), conflicts[0].ConflictingID)
+	assert.Equal(t, 60, conflicts[0].OverlapMinutes)
+
+	assert.Len(t, findConflicts(incoming, calendar, 0), 2)
+}
+
+func TestIncomingSwapRequestsIncludeCounterOffers(t *testing.T) {
+	conn := useTestDB(t)
+
+	requester := models.User{Name: "Requester", Email: "requester@example.com", Password: "x"}
+	responder := models.User{Name: "Responder", Email: "responder@example.com", Password: "x"}
+	require.NoError(t, conn.Create(&requester).Error)
+	require.NoError(t, conn.Create(&responder).Error)
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	offered := models.Event{Title: "Offered", StartTime: start, EndTime: start.Add(time.Hour), Status: models.EventStatusSwappable, OwnerID: requester.ID}
+	wanted := models.Event{Title: "Wanted", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour), Status: models.EventStatusSwappable, OwnerID: responder.ID}
+	alternative := models.Event{Title: "Alternative", StartTime: start.Add(4 * time.Hour), EndTime: start.Add(5 * time.Hour), Status: models.EventStatusSwappable, OwnerID: responder.ID}
+	require.NoError(t, conn.Create(&offered).Error)
+	require.NoError(t, conn.Create(&wanted).Error)
+	require.NoError(t, conn.Create(&alternative).Error)
+
+	request, err := CreateSwapRequest(requester.ID, offered.ID, wanted.ID, time.Hour, true)
+	require.NoError(t, err)
+	counter, err := CounterSwapRequest(request.ID, responder.ID, alternative.ID, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, models.COUNTERED, counter.Status)
+	require.Equal(t, requester.ID, awaitingResponseFrom(counter))
+
+	incoming, err := GetIncomingSwapRequests(requester.ID)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	assert.Equal(t, counter.ID, incoming[0].ID)
+	assert.Equal(t, responder.ID, incoming[0].Responder.ID)
+
+	// The responder made the counter-offer, so it waits on the requester and
+	// not on them; the request it superseded stays in their list.
+	incoming, err = GetIncomingSwapRequests(responder.ID)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	assert.Equal(t, request.ID, incoming[0].ID)
+	assert.Equal(t, models.SUPERSEDED, incoming[0].Status)
+}
+
+func TestCancelSwapRequest(t *testing.T) {
+	conn := useTestDB(t)
+
+	requester := createTestUser(t, conn, "Requester")
+	responder := createTestUser(t, conn, "Responder")
+
+	t.Run("Requester Cancels", func(t *testing.T) {
+		offered := createTestEvent(t, conn, requester.ID, 24)
+		wanted := createTestEvent(t, conn, responder.ID, 26)
+		request, err := CreateSwapRequest(requester.ID, offered.ID, wanted.ID, time.Hour, true)
+		require.NoError(t, err)
+
+		assert.EqualError(t, CancelSwapRequest(request.ID, responder.ID), "unauthorized to cancel this request")
+
+		require.NoError(t, CancelSwapRequest(request.ID, requester.ID))
+
+		require.NoError(t, conn.First(request, request.ID).Error)
+		assert.Equal(t, models.CANCELLED, request.Status)
+		require.NotNil(t, request.CancelledByID)
+		assert.Equal(t, requester.ID, *request.CancelledByID)
+		assert.NotNil(t, request.CancelledAt)
+
+		for _, id := range []uint{offered.ID, wanted.ID} {
+			var event models.Event
+			require.NoError(t, conn.First(&event, id).Error)
+			assert.Equal(t, models.EventStatusSwappable, event.Status)
+		}
+
+		assert.EqualError(t, CancelSwapRequest(request.ID, requester.ID), "swap request is no longer pending")
+	})
+
+	t.Run("Answered Requests Stay Answered", func(t *testing.T) {
+		offered := createTestEvent(t, conn, requester.ID, 28)
+		wanted := createTestEvent(t, conn, responder.ID, 30)
+		request, err := CreateSwapRequest(requester.ID, offered.ID, wanted.ID, time.Hour, true)
+		require.NoError(t, err)
+		require.NoError(t, RespondToSwapRequest(request.ID, responder.ID, false, true))
+
+		assert.EqualError(t, CancelSwapRequest(request.ID, requester.ID), "swap request is no longer pending")
+		require.NoError(t, conn.First(request, request.ID).Error)
+		assert.Equal(t, models.REJECTED, request.Status)
+		assert.Nil(t, request.CancelledByID)
+	})
+
+	t.Run("Unknown Request", func(t *testing.T) {
+		assert.EqualError(t, CancelSwapRequest(9999, requester.ID), "swap request not found")
+	})
+}
+
+func TestSwapCycleConflictChecks(t *testing.T) {
+	conn := useTestDB(t)
+
+	proposer := createTestUser(t, conn, "Proposer")
+	second := createTestUser(t, conn, "Second")
+	third := createTestUser(t, conn, "Third")
+
+	// proposer gives eventIDs[0] to third, second gives eventIDs[1] to
+	// proposer and third gives eventIDs[2] to second.
+	cycleEvents := func(hoursAhead int) []uint {
+		return []uint{
+			createTestEvent(t, conn, proposer.ID, hoursAhead).ID,
+			createTestEvent(t, conn, second.ID, hoursAhead+2).ID,
+			createTestEvent(t, conn, third.ID, hoursAhead+4).ID,
+		}
+	}
+	busyAt := func(ownerID uint, hoursAhead int) models.Event {
+		event := createTestEvent(t, conn, ownerID, hoursAhead)
+		require.NoError(t, conn.Model(&event).Update("status", models.EventStatusBusy).Error)
+		return event
+	}
+
+	t.Run("Blocking Participant", func(t *testing.T) {
+		require.NoError(t, conn.Model(&third).Update("conflict_policy", models.ConflictPolicyBlock).Error)
+		t.Cleanup(func() { conn.Model(&third).Update("conflict_policy", models.ConflictPolicyWarn) })
+
+		eventIDs := cycleEvents(24)
+		busy := busyAt(third.ID, 24)
+
+		_, err := ProposeSwapCycle(proposer.ID, eventIDs, time.Hour, true)
+		var conflictErr *ConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		assert.Equal(t, third.ID, conflictErr.UserID)
+		assert.True(t, conflictErr.Blocking)
+		assert.Equal(t, busy.ID, conflictErr.Conflicts[0].ConflictingID)
+
+		var event models.Event
+		require.NoError(t, conn.First(&event, eventIDs[0]).Error)
+		assert.Equal(t, models.EventStatusSwappable, event.Status, "a refused cycle holds nothing")
+	})
+
+	t.Run("Proposer Can Override Their Own Warning", func(t *testing.T) {
+		eventIDs := cycleEvents(48)
+		busyAt(proposer.ID, 50)
+
+		_, err := ProposeSwapCycle(proposer.ID, eventIDs, time.Hour, false)
+		var conflictErr *ConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		assert.Equal(t, proposer.ID, conflictErr.UserID)
+		assert.False(t, conflictErr.Blocking)
+
+		_, err = ProposeSwapCycle(proposer.ID, eventIDs, time.Hour, true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Approvals Check Calendars Again", func(t *testing.T) {
+		eventIDs := cycleEvents(72)
+		cycle, err := ProposeSwapCycle(proposer.ID, eventIDs, time.Hour, false)
+		require.NoError(t, err)
+
+		// The slot second would receive now overlaps a BUSY event of theirs.
+		busyAt(second.ID, 76)
+
+		_, err = RespondToSwapCycle(cycle.ID, second.ID, true, false)
+		var conflictErr *ConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		assert.Equal(t, second.ID, conflictErr.UserID)
+
+		cycle, err = RespondToSwapCycle(cycle.ID, second.ID, true, true)
+		require.NoError(t, err)
+		assert.Equal(t, models.SwapCyclePending, cycle.Status)
+
+		// A WARN conflict only stops the participant it belongs to.
+		cycle, err = RespondToSwapCycle(cycle.ID, third.ID, true, false)
+		require.NoError(t, err)
+		assert.Equal(t, models.SwapCycleCommitted, cycle.Status)
+	})
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"gorm.io/gorm"
+)
+
+// TeamScope is the set of teams and organizations a user belongs to. It
+// decides which slots the user may see in the marketplace and swap for.
+type TeamScope struct {
+	TeamIDs map[uint]bool
+	OrgIDs  map[uint]bool
+}
+
+func newTeamScope() *TeamScope {
+	return &TeamScope{TeamIDs: map[uint]bool{}, OrgIDs: map[uint]bool{}}
+}
+
+// canSee reports whether an event is offered to the holder of the scope.
+// Events without a team are open to everyone; team events are open to the
+// team, and to the whole organization when published org-wide. A nil scope
+// belongs to a user with no memberships.
+func (s *TeamScope) canSee(event *models.Event) bool {
+	if event.TeamID == nil {
+		return true
+	}
+	if s == nil {
+		return false
+	}
+	if s.TeamIDs[*event.TeamID] {
+		return true
+	}
+	return event.OrgWide && event.OrganizationID != nil && s.OrgIDs[*event.OrganizationID]
+}
+
+// canView reports whether userID may look at an event outside the
+// marketplace listing: their own events, and slots the marketplace would
+// show them.
+func (s *TeamScope) canView(userID uint, event *models.Event) bool {
+	if event.OwnerID == userID {
+		return true
+	}
+	return event.Status == models.EventStatusSwappable && s.canSee(event)
+}
+
+func (s *TeamScope) teamIDList() []uint {
+	ids := make([]uint, 0, len(s.TeamIDs))
+	for id := range s.TeamIDs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *TeamScope) orgIDList() []uint {
+	ids := make([]uint, 0, len(s.OrgIDs))
+	for id := range s.OrgIDs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// loadTeamScopes loads the memberships of every given user. Users with no
+// memberships are left out of the map, which canSee treats as no scope.
+func loadTeamScopes(tx *gorm.DB, userIDs ...uint) (map[uint]*TeamScope, error) {
+	scopes := make(map[uint]*TeamScope, len(userIDs))
+	if len(userIDs) == 0 {
+		return scopes, nil
+	}
+
+	scopeFor := func(userID uint) *TeamScope {
+		if scopes[userID] == nil {
+			scopes[userID] = newTeamScope()
+		}
+		return scopes[userID]
+	}
+
+	var orgMembers []models.OrganizationMember
+	if err := tx.Where("user_id IN ?", userIDs).Find(&orgMembers).Error; err != nil {
+		return nil, err
+	}
+	for _, member := range orgMembers {
+		scopeFor(member.UserID).OrgIDs[member.OrganizationID] = true
+	}
+
+	var teamMembers []models.TeamMember
+	if err := tx.Where("user_id IN ?", userIDs).Find(&teamMembers).Error; err != nil {
+		return nil, err
+	}
+	for _, member := range teamMembers {
+		scopeFor(member.UserID).TeamIDs[member.TeamID] = true
+	}
+
+	return scopes, nil
+}
+
+func loadTeamScope(tx *gorm.DB, userID uint) (*TeamScope, error) {
+	scopes, err := loadTeamScopes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scopes[userID], nil
+}
+
+// scopeMarketplace restricts an event query to the slots a user with scope
+// may see, mirroring TeamScope.canSee.
+func scopeMarketplace(query *gorm.DB, scope *TeamScope) *gorm.DB {
+	if scope == nil {
+		return query.Where("events.team_id IS NULL")
+	}
+	return query.Where(
+		"(events.team_id IS NULL OR events.team_id IN ? OR (events.org_wide AND events.organization_id IN ?))",
+		scope.teamIDList(), scope.orgIDList(),
+	)
+}
+
+// validateSwapScope checks that each party to a swap can see the event they
+// would receive, so swaps never cross team boundaries.
+func validateSwapScope(tx *gorm.DB, requesterID uint, requesterEvent *models.Event, responderEvent *models.Event) error {
+	if requesterEvent.TeamID == nil && responderEvent.TeamID == nil {
+		return nil
+	}
+
+	scopes, err := loadTeamScopes(tx, requesterID, responderEvent.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	if !scopes[requesterID].canSee(responderEvent) {
+		return errors.New("event is not available to your teams")
+	}
+	if !scopes[responderEvent.OwnerID].canSee(requesterEvent) {
+		return errors.New("your event is not available to the other user's teams")
+	}
+	return nil
+}
+
+// validateCycleScope checks that every participant can see the event they
+// would receive.
+func validateCycleScope(tx *gorm.DB, legs []models.SwapCycleLeg, events map[uint]*models.Event) error {
+	userIDs := make([]uint, 0, len(legs))
+	scoped := false
+	for _, leg := range legs {
+		userIDs = append(userIDs, leg.ReceiverID)
+		if events[leg.EventID].TeamID != nil {
+			scoped = true
+		}
+	}
+	if !scoped {
+		return nil
+	}
+
+	scopes, err := loadTeamScopes(tx, userIDs...)
+	if err != nil {
+		return err
+	}
+
+	for _, leg := range legs {
+		if !scopes[leg.ReceiverID].canSee(events[leg.EventID]) {
+			return fmt.Errorf("event %d is not available to the teams of the user who would receive it", leg.EventID)
+		}
+	}
+	return nil
+}
+
+// resolveEventTeam validates a team chosen for an event by its owner and
+// returns the team's organization.
+func resolveEventTeam(tx *gorm.DB, ownerID uint, teamID uint, errs *ValidationError) *uint {
+	var team models.Team
+	if err := tx.First(&team, teamID).Error; err != nil {
+		errs.add("teamId", "team not found")
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&models.TeamMember{}).Where("team_id = ? AND user_id = ?", teamID, ownerID).Count(&count).Error; err != nil || count == 0 {
+		errs.add("teamId", "you are not a member of this team")
+		return nil
+	}
+
+	return &team.OrganizationID
+}
+
+// defaultEventTeam picks the team for a new event that did not name one: the
+// user's only team, if they have exactly one. Users in several teams must
+// choose, so a slot is never published more widely than intended.
+func defaultEventTeam(tx *gorm.DB, ownerID uint, errs *ValidationError) *uint {
+	var teamIDs []uint
+	if err := tx.Model(&models.TeamMember{}).Where("user_id = ?", ownerID).Pluck("team_id", &teamIDs).Error; err != nil {
+		errs.add("teamId", "could not load your teams")
+		return nil
+	}
+
+	switch len(teamIDs) {
+	case 0:
+		return nil
+	case 1:
+		return &teamIDs[0]
+	default:
+		errs.add("teamId", "is required because you belong to several teams")
+		return nil
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamScopeCanSee(t *testing.T) {
+	teamA, teamB, org := uint(1), uint(2), uint(7)
+	scope := &TeamScope{TeamIDs: map[uint]bool{teamA: true}, OrgIDs: map[uint]bool{org: true}}
+
+	t.Run("Unscoped Events Are Public", func(t *testing.T) {
+		event := &models.Event{}
+		assert.True(t, scope.canSee(event))
+		assert.True(t, (*TeamScope)(nil).canSee(event))
+	})
+
+	t.Run("Team Events", func(t *testing.T) {
+		assert.True(t, scope.canSee(&models.Event{TeamID: &teamA, OrganizationID: &org}))
+		assert.False(t, scope.canSee(&models.Event{TeamID: &teamB, OrganizationID: &org}))
+		assert.False(t, (*TeamScope)(nil).canSee(&models.Event{TeamID: &teamA, OrganizationID: &org}))
+	})
+
+	t.Run("Org Wide Events", func(t *testing.T) {
+		otherOrg := uint(8)
+		assert.True(t, scope.canSee(&models.Event{TeamID: &teamB, OrganizationID: &org, OrgWide: true}))
+		assert.False(t, scope.canSee(&models.Event{TeamID: &teamB, OrganizationID: &otherOrg, OrgWide: true}))
+	})
+}
+
+func TestTeamScopeCanView(t *testing.T) {
+	team := uint(1)
+	scope := &TeamScope{TeamIDs: map[uint]bool{team: true}, OrgIDs: map[uint]bool{}}
+
+	t.Run("Own Events", func(t *testing.T) {
+		assert.True(t, (*TeamScope)(nil).canView(1, &models.Event{OwnerID: 1, Status: models.EventStatusBusy, TeamID: &team}))
+	})
+
+	t.Run("Marketplace Slots", func(t *testing.T) {
+		assert.True(t, scope.canView(1, &models.Event{OwnerID: 2, Status: models.EventStatusSwappable, TeamID: &team}))
+		assert.False(t, (*TeamScope)(nil).canView(1, &models.Event{OwnerID: 2, Status: models.EventStatusSwappable, TeamID: &team}))
+	})
+
+	t.Run("Other Users' Busy Events", func(t *testing.T) {
+		assert.False(t, scope.canView(1, &models.Event{OwnerID: 2, Status: models.EventStatusBusy}))
+	})
+}
+
+func TestFindMatchesRespectsTeams(t *testing.T) {
+	monday := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	teamA, teamB := uint(1), uint(2)
+	events := []models.Event{
+		{ID: 10, OwnerID: 1, TeamID: &teamA, Status: models.EventStatusSwappable, StartTime: monday, EndTime: monday.Add(time.Hour)},
+		{ID: 20, OwnerID: 2, TeamID: &teamB, Status: models.EventStatusSwappable, StartTime: monday, EndTime: monday.Add(time.Hour)},
+	}
+	wants := []models.SwapWant{{UserID: 1, EventID: 10}, {UserID: 2, EventID: 20}}
+
+	t.Run("Different Teams", func(t *testing.T) {
+		scopes := map[uint]*TeamScope{
+			1: {TeamIDs: map[uint]bool{teamA: true}, OrgIDs: map[uint]bool{}},
+			2: {TeamIDs: map[uint]bool{teamB: true}, OrgIDs: map[uint]bool{}},
+		}
+		assert.Empty(t, findMatches(1, events, wants, scopes))
+	})
+
+	t.Run("Shared Teams", func(t *testing.T) {
+		both := map[uint]bool{teamA: true, teamB: true}
+		scopes := map[uint]*TeamScope{
+			1: {TeamIDs: both, OrgIDs: map[uint]bool{}},
+			2: {TeamIDs: both, OrgIDs: map[uint]bool{}},
+		}
+		assert.Len(t, findMatches(1, events, wants, scopes), 1)
+	})
+}
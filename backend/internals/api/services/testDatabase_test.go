@@ -0,0 +1,68 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/db"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/logger"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// useTestDB points db.DB at the PostgreSQL database named by
+// TEST_DATABASE_URL, migrated and emptied, for the length of the test.
+// Tests that need a database are skipped when it is not set.
+func useTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	logger.InitLogger()
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(conn))
+
+	tables, err := conn.Migrator().GetTables()
+	require.NoError(t, err)
+	for i, table := range tables {
+		tables[i] = strconv.Quote(table)
+	}
+	require.NoError(t, conn.Exec("TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE").Error)
+
+	previous := db.DB
+	db.DB = conn
+	t.Cleanup(func() {
+		db.DB = previous
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return conn
+}
+
+// createTestUser stores a user whose email is derived from name.
+func createTestUser(t *testing.T, conn *gorm.DB, name string) models.User {
+	t.Helper()
+	user := models.User{Name: name, Email: strings.ToLower(name) + "@example.com", Password: "x"}
+	require.NoError(t, conn.Create(&user).Error)
+	return user
+}
+
+// createTestEvent stores an hour-long SWAPPABLE event of ownerID starting
+// hoursAhead hours from now.
+func createTestEvent(t *testing.T, conn *gorm.DB, ownerID uint, hoursAhead int) models.Event {
+	t.Helper()
+	start := time.Now().Truncate(time.Hour).Add(time.Duration(hoursAhead) * time.Hour)
+	event := models.Event{Title: "Slot", StartTime: start, EndTime: start.Add(time.Hour), Status: models.EventStatusSwappable, OwnerID: ownerID}
+	require.NoError(t, conn.Create(&event).Error)
+	return event
+}
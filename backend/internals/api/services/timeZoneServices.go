@@ -0,0 +1,164 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"gorm.io/gorm"
+)
+
+// defaultTimeZone is the zone of users who have not chosen one. Events and
+// series stored before zones were recorded have no zone and are treated as
+// UTC.
+const defaultTimeZone = "UTC"
+
+// localTimeLayouts are the date-times without an offset that events accept;
+// they are read in the event's zone.
+var localTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// dateLayout is the plain date all-day events accept.
+const dateLayout = "2006-01-02"
+
+// LoadTimeZone loads an IANA time zone such as "Europe/Berlin". The empty
+// name and "Local" are refused, as both would mean the server's own zone.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// zoneOrUTC loads a stored zone name. The empty name, and names the zone
+// database no longer knows, give UTC.
+func zoneOrUTC(name string) *time.Location {
+	loc, err := LoadTimeZone(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func validateTimeZone(field string, name string, errs *ValidationError) string {
+	if _, err := LoadTimeZone(name); err != nil {
+		errs.add(field, "must be an IANA time zone such as Europe/Berlin")
+	}
+	return name
+}
+
+// userTimeZone is the zone the user chose, or the default when they have not
+// chosen one or cannot be found.
+func userTimeZone(tx *gorm.DB, userID uint) string {
+	var names []string
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Limit(1).Pluck("time_zone", &names).Error; err != nil {
+		return defaultTimeZone
+	}
+	if len(names) == 0 || names[0] == "" {
+		return defaultTimeZone
+	}
+	return names[0]
+}
+
+// parseEventTime reads an RFC3339 time, or a date-time without an offset in
+// loc, and returns it in UTC.
+func parseEventTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not an RFC3339 or local date-time", value)
+}
+
+// parseEventDate reads a date of an all-day event: a plain date, or the date
+// an RFC3339 or local date-time falls on in loc. It returns midnight UTC of
+// that date.
+func parseEventDate(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return t, nil
+	}
+	t, err := parseEventTime(value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date, RFC3339 or local date-time", value)
+	}
+	return floatingDate(t.In(loc)), nil
+}
+
+// floatingDate is midnight UTC of the date t falls on in its own zone, the
+// way all-day events store their dates.
+func floatingDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// readEventTime parses the startTime or endTime field of an event, as a date
+// when the event is all-day. A value that cannot be read is added to errs.
+func readEventTime(field string, value string, allDay bool, loc *time.Location, errs *ValidationError) (time.Time, bool) {
+	if allDay {
+		t, err := parseEventDate(value, loc)
+		if err != nil {
+			errs.add(field, "invalid date format, expected YYYY-MM-DD, RFC3339 or a local date-time")
+			return time.Time{}, false
+		}
+		return t, true
+	}
+	t, err := parseEventTime(value, loc)
+	if err != nil {
+		errs.add(field, "invalid time format, expected RFC3339 or a local date-time")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// LocalizeEvent shows the event's times in loc. Only the representation
+// changes; the instants stay the same. All-day events keep their dates.
+func LocalizeEvent(event *models.Event, loc *time.Location) {
+	if event.AllDay {
+		return
+	}
+	event.StartTime = event.StartTime.In(loc)
+	event.EndTime = event.EndTime.In(loc)
+	if event.OccurrenceStart != nil {
+		occurrenceStart := event.OccurrenceStart.In(loc)
+		event.OccurrenceStart = &occurrenceStart
+	}
+}
+
+func LocalizeEvents(events []models.Event, loc *time.Location) {
+	for i := range events {
+		LocalizeEvent(&events[i], loc)
+	}
+}
+
+// LocalizeEventSeries shows a series, its exception dates and its
+// occurrences in loc.
+func LocalizeEventSeries(series *models.EventSeries, loc *time.Location) {
+	series.StartTime = series.StartTime.In(loc)
+	series.EndTime = series.EndTime.In(loc)
+	for i := range series.ExDates {
+		series.ExDates[i] = series.ExDates[i].In(loc)
+	}
+	LocalizeEvents(series.Occurrences, loc)
+}
+
+// LocalizeSwapRequests shows the slots of each swap request in loc.
+func LocalizeSwapRequests(requests []models.SwapRequest, loc *time.Location) {
+	for i := range requests {
+		LocalizeEvent(&requests[i].RequesterEvent, loc)
+		LocalizeEvent(&requests[i].ResponderEvent, loc)
+	}
+}
+
+// LocalizeConflicts shows the conflicting events' times in loc.
+func LocalizeConflicts(conflicts []models.EventConflict, loc *time.Location) {
+	for i := range conflicts {
+		conflicts[i].ConflictingStart = conflicts[i].ConflictingStart.In(loc)
+		conflicts[i].ConflictingEnd = conflicts[i].ConflictingEnd.In(loc)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTimeZone(t *testing.T) {
+	loc, err := LoadTimeZone("Europe/Berlin")
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", loc.String())
+
+	for _, name := range []string{"", "Local", "Mars/Olympus_Mons"} {
+		_, err := LoadTimeZone(name)
+		assert.Error(t, err, name)
+	}
+
+	assert.Equal(t, time.UTC, zoneOrUTC(""))
+	assert.Equal(t, time.UTC, zoneOrUTC("Mars/Olympus_Mons"))
+
+	errs := &ValidationError{}
+	validateTimeZone("timeZone", "Nowhere", errs)
+	require.Len(t, errs.Fields, 1)
+	assert.Equal(t, "timeZone", errs.Fields[0].Field)
+}
+
+func TestParseEventTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	got, err := parseEventTime("2025-06-02T09:00:00+02:00", time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC), got)
+	assert.Equal(t, time.UTC, got.Location())
+
+	got, err = parseEventTime("2025-06-02T09:00:00", berlin)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC), got)
+
+	got, err = parseEventTime("2025-01-06T09:00", berlin)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC), got, "winter time is an hour behind")
+
+	_, err = parseEventTime("next monday", berlin)
+	assert.Error(t, err)
+}
+
+func TestParseEventDate(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	june2 := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	got, err := parseEventDate("2025-06-02", tokyo)
+	require.NoError(t, err)
+	assert.Equal(t, june2, got)
+
+	got, err = parseEventDate("2025-06-01T20:00:00Z", tokyo)
+	require.NoError(t, err)
+	assert.Equal(t, june2, got, "the date is taken in the event's zone")
+
+	got, err = parseEventDate("2025-06-02T08:00", tokyo)
+	require.NoError(t, err)
+	assert.Equal(t, june2, got)
+
+	_, err = parseEventDate("June 2nd", tokyo)
+	assert.Error(t, err)
+}
+
+func TestReadEventTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	errs := &ValidationError{}
+	got, ok := readEventTime("startTime", "2025-06-02T09:00", false, berlin, errs)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC), got)
+
+	got, ok = readEventTime("startTime", "2025-06-02", true, berlin, errs)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), got)
+	assert.Empty(t, errs.Fields)
+
+	_, ok = readEventTime("endTime", "2025-06-02", false, berlin, errs)
+	assert.False(t, ok, "timed events need a time")
+	require.Len(t, errs.Fields, 1)
+	assert.Equal(t, "endTime", errs.Fields[0].Field)
+}
+
+func TestLocalizeAllDayEvent(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	event := &models.Event{StartTime: day, EndTime: day.AddDate(0, 0, 1), AllDay: true}
+	LocalizeEvent(event, losAngeles)
+
+	assert.Equal(t, "2025-06-02", event.StartTime.Format(dateLayout), "all-day events keep their date")
+	assert.Equal(t, "2025-06-03", event.EndTime.Format(dateLayout))
+}
+
+func TestLocalizeEventSeries(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	series := &models.EventSeries{
+		StartTime:   start,
+		EndTime:     start.Add(time.Hour),
+		ExDates:     []time.Time{start.AddDate(0, 0, 7)},
+		Occurrences: []models.Event{{StartTime: start, EndTime: start.Add(time.Hour), OccurrenceStart: &start}},
+	}
+	LocalizeEventSeries(series, tokyo)
+
+	assert.Equal(t, "2025-06-02T18:00:00+09:00", series.StartTime.Format(time.RFC3339))
+	assert.Equal(t, tokyo, series.ExDates[0].Location())
+	occurrence := series.Occurrences[0]
+	assert.Equal(t, "2025-06-02T18:00:00+09:00", occurrence.StartTime.Format(time.RFC3339))
+	assert.True(t, occurrence.OccurrenceStart.Equal(start))
+	assert.Equal(t, time.UTC, start.Location(), "the shared start is not changed in place")
+}
+
+func TestCreateEventTimes(t *testing.T) {
+	conn := useTestDB(t)
+
+	user := models.User{Name: "Zoned", Email: "zoned@example.com", Password: "x", TimeZone: "Europe/Berlin"}
+	require.NoError(t, conn.Create(&user).Error)
+
+	t.Run("Local Times Are Read In The Event's Zone", func(t *testing.T) {
+		event, err := CreateEvent(user.ID, &models.CreateEventInput{Title: "Standup", StartTime: "2025-06-02T09:00", EndTime: "2025-06-02T09:15"}, EventLimits{})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC), event.StartTime)
+		assert.Equal(t, "Europe/Berlin", event.TimeZone)
+	})
+
+	t.Run("All Day Events Keep Their Dates", func(t *testing.T) {
+		event, err := CreateEvent(user.ID, &models.CreateEventInput{Title: "Offsite", StartTime: "2025-06-02", EndTime: "2025-06-04", AllDay: true}, EventLimits{})
+		require.NoError(t, err)
+		assert.True(t, event.AllDay)
+		assert.Equal(t, time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), event.StartTime)
+
+		_, err = UpdateEventPartial(event.ID, user.ID, &models.UpdateEventInput{AllDay: new(bool)}, EventLimits{})
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr)
+		assert.Equal(t, "allDay", verr.Fields[0].Field)
+
+		start, end := "2025-06-02T13:00", "2025-06-02T14:00"
+		updated, err := UpdateEventPartial(event.ID, user.ID, &models.UpdateEventInput{AllDay: new(bool), StartTime: &start, EndTime: &end}, EventLimits{})
+		require.NoError(t, err)
+		assert.False(t, updated.AllDay)
+		assert.Equal(t, time.Date(2025, 6, 2, 11, 0, 0, 0, time.UTC), updated.StartTime)
+	})
+
+	t.Run("All Day Events May Span Several Days", func(t *testing.T) {
+		limits := EventLimits{MaxDuration: 24 * time.Hour}
+		event, err := CreateEvent(user.ID, &models.CreateEventInput{Title: "Conference", StartTime: "2025-06-09", EndTime: "2025-06-12", AllDay: true}, limits)
+		require.NoError(t, err)
+		assert.Equal(t, 72*time.Hour, event.EndTime.Sub(event.StartTime))
+
+		end := "2025-06-13"
+		_, err = UpdateEventPartial(event.ID, user.ID, &models.UpdateEventInput{EndTime: &end}, limits)
+		assert.NoError(t, err)
+
+		_, err = CreateEvent(user.ID, &models.CreateEventInput{Title: "Marathon", StartTime: "2025-06-09T09:00", EndTime: "2025-06-12T09:00"}, limits)
+		assert.Error(t, err, "timed events keep the maximum duration")
+	})
+
+	t.Run("Invalid Times", func(t *testing.T) {
+		_, err := CreateEvent(user.ID, &models.CreateEventInput{Title: "Broken", StartTime: "soon", EndTime: "2025-06-02"}, EventLimits{})
+		var verr *ValidationError
+		require.ErrorAs(t, err, &verr)
+		assert.Len(t, verr.Fields, 2)
+	})
+}
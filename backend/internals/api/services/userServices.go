@@ -54,14 +54,3 @@ func GetEmailByID(id uint) (*models.User, error) {
 
 	return &user, nil
 }
-
-func UpdateUser(user *models.User) error {
-	if db.DB == nil {
-		logger.Error("Database connection is nil")
-		return errors.New("database connection is nil")
-	}
-	if err := db.DB.Save(user).Error; err != nil {
-		return err
-	}
-	return nil
-}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+)
+
+const maxEventTitleLength = 255
+
+// FieldError describes why a single input field was refused.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every field error found in one request so clients
+// can show them all at once.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) add(field string, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// errOrNil returns e as an error only when it holds field errors.
+func (e *ValidationError) errOrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// EventLimits bounds how long a single event may be. Zero disables a bound.
+type EventLimits struct {
+	MinDuration time.Duration
+	MaxDuration time.Duration
+}
+
+func EventLimitsFromConfig(cfg *config.Config) EventLimits {
+	return EventLimits{
+		MinDuration: cfg.EVENT_MIN_DURATION,
+		MaxDuration: cfg.EVENT_MAX_DURATION,
+	}
+}
+
+// clientStatusTransitions lists the status changes a client may make
+// directly. SWAP_PENDING is entered and left only by the swap services.
+var clientStatusTransitions = map[models.EventStatus][]models.EventStatus{
+	models.EventStatusBusy:      {models.EventStatusSwappable},
+	models.EventStatusSwappable: {models.EventStatusBusy},
+}
+
+// validateClientStatus checks a status requested by a client. current is nil
+// when the event is being created.
+func validateClientStatus(current *models.EventStatus, requested string, errs *ValidationError) models.EventStatus {
+	status := models.EventStatus(requested)
+
+	if status != models.EventStatusBusy && status != models.EventStatusSwappable {
+		if status == models.EventStatusSwapPending {
+			errs.add("status", "SWAP_PENDING can only be set by creating a swap request")
+		} else {
+			errs.add("status", fmt.Sprintf("must be one of %s or %s", models.EventStatusBusy, models.EventStatusSwappable))
+		}
+		return status
+	}
+
+	if current == nil || *current == status {
+		return status
+	}
+
+	for _, allowed := range clientStatusTransitions[*current] {
+		if allowed == status {
+			return status
+		}
+	}
+
+	errs.add("status", fmt.Sprintf("cannot change status from %s to %s", *current, status))
+	return status
+}
+
+func validateEventTitle(title string, errs *ValidationError) {
+	if strings.TrimSpace(title) == "" {
+		errs.add("title", "is required")
+	} else if len(title) > maxEventTitleLength {
+		errs.add("title", fmt.Sprintf("must be at most %d characters", maxEventTitleLength))
+	}
+}
+
+// validateEventTimes checks that end follows start and that the event lasts
+// as long as limits allow. All-day events cover whole days, so the maximum
+// duration does not apply to them.
+func validateEventTimes(start time.Time, end time.Time, allDay bool, limits EventLimits, errs *ValidationError) {
+	if start.IsZero() {
+		errs.add("startTime", "is required")
+	}
+	if end.IsZero() {
+		errs.add("endTime", "is required")
+	}
+	if start.IsZero() || end.IsZero() {
+		return
+	}
+
+	if !end.After(start) {
+		errs.add("endTime", "must be after startTime")
+		return
+	}
+
+	duration := end.Sub(start)
+	if limits.MinDuration > 0 && duration < limits.MinDuration {
+		errs.add("endTime", "event must last at least "+limits.MinDuration.String())
+	}
+	if !allDay && limits.MaxDuration > 0 && duration > limits.MaxDuration {
+		errs.add("endTime", "event must last at most "+limits.MaxDuration.String())
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateClientStatus(t *testing.T) {
+	busy := models.EventStatusBusy
+	pending := models.EventStatusSwapPending
+
+	t.Run("Create As Swappable", func(t *testing.T) {
+		errs := &ValidationError{}
+		assert.Equal(t, models.EventStatusSwappable, validateClientStatus(nil, "SWAPPABLE", errs))
+		assert.NoError(t, errs.errOrNil())
+	})
+
+	t.Run("Client Cannot Enter Swap Pending", func(t *testing.T) {
+		errs := &ValidationError{}
+		validateClientStatus(&busy, "SWAP_PENDING", errs)
+		assert.Error(t, errs.errOrNil())
+		assert.Equal(t, "status", errs.Fields[0].Field)
+	})
+
+	t.Run("Unknown Status", func(t *testing.T) {
+		errs := &ValidationError{}
+		validateClientStatus(nil, "FREE", errs)
+		assert.Error(t, errs.errOrNil())
+	})
+
+	t.Run("Client Cannot Leave Swap Pending", func(t *testing.T) {
+		errs := &ValidationError{}
+		validateClientStatus(&pending, "BUSY", errs)
+		assert.Error(t, errs.errOrNil())
+	})
+}
+
+func TestValidateEventTimes(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	limits := EventLimits{MinDuration: 5 * time.Minute, MaxDuration: 24 * time.Hour}
+
+	t.Run("Valid Range", func(t *testing.T) {
+		errs := &ValidationError{}
+		validateEventTimes(start, start.Add(time.Hour), false, limits, errs)
+		assert.NoError(t, errs.errOrNil())
+	})
+
+	t.Run("End Before Start", func(t *testing.T) {
+		errs := &ValidationError{}
+		validateEventTimes(start, start.Add(-time.Hour), false, limits, errs)
+		assert.EqualError(t, errs.errOrNil(), "validation failed: endTime: must be after startTime")
+	})
+
+	t.Run("Too Short And Too Long", func(t *testing.T) {
+		errs := &ValidationError{}
+		validateEventTimes(start, start.Add(time.Minute), false, limits, errs)
+		assert.Error(t, errs.errOrNil())
+
+		errs = &ValidationError{}
+		validateEventTimes(start, start.Add(48*time.Hour), false, limits, errs)
+		assert.Error(t, errs.errOrNil())
+	})
+
+	t.Run("All Day Events Span Several Days", func(t *testing.T) {
+		day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+		errs := &ValidationError{}
+		validateEventTimes(day, day.AddDate(0, 0, 3), true, limits, errs)
+		assert.NoError(t, errs.errOrNil())
+	})
+
+	t.Run("Missing Times", func(t *testing.T) {
+		errs := &ValidationError{}
+		validateEventTimes(time.Time{}, time.Time{}, false, limits, errs)
+		assert.Len(t, errs.Fields, 2)
+	})
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"strings"
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -10,6 +13,51 @@ type Config struct {
 	DB_URL               string
 	ACCESS_TOKEN_SECRET  string
 	REFRESH_TOKEN_SECRET string
+
+	SWAP_REQUEST_TTL           time.Duration
+	SWAP_EXPIRY_SWEEP_INTERVAL time.Duration
+	SWAP_UNDO_WINDOW           time.Duration
+	SWAP_UNDO_POLICY           string
+
+	EVENT_MIN_DURATION time.Duration
+	EVENT_MAX_DURATION time.Duration
+
+	RECURRENCE_HORIZON         time.Duration
+	RECURRENCE_EXTEND_INTERVAL time.Duration
+
+	APP_BASE_URL       string
+	PASSWORD_RESET_TTL time.Duration
+
+	EMAIL_VERIFICATION_TTL     time.Duration
+	REQUIRE_EMAIL_VERIFICATION bool
+
+	MFA_ISSUER      string
+	MFA_PENDING_TTL time.Duration
+
+	ADMIN_EMAILS []string
+
+	// TRUSTED_PROXIES lists the proxies whose X-Forwarded-For is believed
+	// when working out a client's IP. Empty trusts none.
+	TRUSTED_PROXIES []string
+
+	INVITATION_TTL time.Duration
+
+	LOGIN_FREE_ATTEMPTS    int
+	LOGIN_BACKOFF_BASE     time.Duration
+	LOGIN_BACKOFF_MAX      time.Duration
+	LOGIN_MAX_FAILURES     int
+	LOGIN_IP_MAX_FAILURES  int
+	LOGIN_LOCKOUT_DURATION time.Duration
+	LOGIN_FAILURE_WINDOW   time.Duration
+	ACCOUNT_UNLOCK_TTL     time.Duration
+
+	MAIL_DRIVER   string
+	MAIL_FROM     string
+	MAIL_FILE_DIR string
+	SMTP_HOST     string
+	SMTP_PORT     string
+	SMTP_USERNAME string
+	SMTP_PASSWORD string
 }
 
 func LoadConfig() *Config {
@@ -17,12 +65,94 @@ func LoadConfig() *Config {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("SWAP_REQUEST_TTL", "72h")
+	viper.SetDefault("SWAP_EXPIRY_SWEEP_INTERVAL", "1m")
+	viper.SetDefault("SWAP_UNDO_WINDOW", "24h")
+	viper.SetDefault("SWAP_UNDO_POLICY", "mutual")
+	viper.SetDefault("EVENT_MIN_DURATION", "5m")
+	viper.SetDefault("EVENT_MAX_DURATION", "24h")
+	viper.SetDefault("RECURRENCE_HORIZON", "2160h")
+	viper.SetDefault("RECURRENCE_EXTEND_INTERVAL", "1h")
+	viper.SetDefault("APP_BASE_URL", "http://localhost:5173")
+	viper.SetDefault("PASSWORD_RESET_TTL", "1h")
+	viper.SetDefault("EMAIL_VERIFICATION_TTL", "48h")
+	viper.SetDefault("REQUIRE_EMAIL_VERIFICATION", false)
+	viper.SetDefault("MFA_ISSUER", "SlotSwapper")
+	viper.SetDefault("MFA_PENDING_TTL", "5m")
+	viper.SetDefault("INVITATION_TTL", "168h")
+	viper.SetDefault("LOGIN_FREE_ATTEMPTS", 3)
+	viper.SetDefault("LOGIN_BACKOFF_BASE", "1s")
+	viper.SetDefault("LOGIN_BACKOFF_MAX", "5m")
+	viper.SetDefault("LOGIN_MAX_FAILURES", 10)
+	viper.SetDefault("LOGIN_IP_MAX_FAILURES", 50)
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "30m")
+	viper.SetDefault("LOGIN_FAILURE_WINDOW", "1h")
+	viper.SetDefault("ACCOUNT_UNLOCK_TTL", "24h")
+	viper.SetDefault("MAIL_DRIVER", "smtp")
+	viper.SetDefault("MAIL_FROM", "SlotSwapper <no-reply@slotswapper.local>")
+	viper.SetDefault("MAIL_FILE_DIR", "tmp/mail")
+	viper.SetDefault("SMTP_PORT", "587")
+
 	config := &Config{
 		PORT:                 viper.GetString("PORT"),
 		DB_URL:               viper.GetString("DATABASE_URL"),
 		ACCESS_TOKEN_SECRET:  viper.GetString("ACCESS_TOKEN_SECRET"),
 		REFRESH_TOKEN_SECRET: viper.GetString("REFRESH_TOKEN_SECRET"),
+
+		SWAP_REQUEST_TTL:           viper.GetDuration("SWAP_REQUEST_TTL"),
+		SWAP_EXPIRY_SWEEP_INTERVAL: viper.GetDuration("SWAP_EXPIRY_SWEEP_INTERVAL"),
+		SWAP_UNDO_WINDOW:           viper.GetDuration("SWAP_UNDO_WINDOW"),
+		SWAP_UNDO_POLICY:           viper.GetString("SWAP_UNDO_POLICY"),
+
+		EVENT_MIN_DURATION: viper.GetDuration("EVENT_MIN_DURATION"),
+		EVENT_MAX_DURATION: viper.GetDuration("EVENT_MAX_DURATION"),
+
+		RECURRENCE_HORIZON:         viper.GetDuration("RECURRENCE_HORIZON"),
+		RECURRENCE_EXTEND_INTERVAL: viper.GetDuration("RECURRENCE_EXTEND_INTERVAL"),
+
+		APP_BASE_URL:       viper.GetString("APP_BASE_URL"),
+		PASSWORD_RESET_TTL: viper.GetDuration("PASSWORD_RESET_TTL"),
+
+		EMAIL_VERIFICATION_TTL:     viper.GetDuration("EMAIL_VERIFICATION_TTL"),
+		REQUIRE_EMAIL_VERIFICATION: viper.GetBool("REQUIRE_EMAIL_VERIFICATION"),
+
+		MFA_ISSUER:      viper.GetString("MFA_ISSUER"),
+		MFA_PENDING_TTL: viper.GetDuration("MFA_PENDING_TTL"),
+
+		ADMIN_EMAILS: strings.Split(viper.GetString("ADMIN_EMAILS"), ","),
+
+		TRUSTED_PROXIES: splitList(viper.GetString("TRUSTED_PROXIES")),
+
+		INVITATION_TTL: viper.GetDuration("INVITATION_TTL"),
+
+		LOGIN_FREE_ATTEMPTS:    viper.GetInt("LOGIN_FREE_ATTEMPTS"),
+		LOGIN_BACKOFF_BASE:     viper.GetDuration("LOGIN_BACKOFF_BASE"),
+		LOGIN_BACKOFF_MAX:      viper.GetDuration("LOGIN_BACKOFF_MAX"),
+		LOGIN_MAX_FAILURES:     viper.GetInt("LOGIN_MAX_FAILURES"),
+		LOGIN_IP_MAX_FAILURES:  viper.GetInt("LOGIN_IP_MAX_FAILURES"),
+		LOGIN_LOCKOUT_DURATION: viper.GetDuration("LOGIN_LOCKOUT_DURATION"),
+		LOGIN_FAILURE_WINDOW:   viper.GetDuration("LOGIN_FAILURE_WINDOW"),
+		ACCOUNT_UNLOCK_TTL:     viper.GetDuration("ACCOUNT_UNLOCK_TTL"),
+
+		MAIL_DRIVER:   viper.GetString("MAIL_DRIVER"),
+		MAIL_FROM:     viper.GetString("MAIL_FROM"),
+		MAIL_FILE_DIR: viper.GetString("MAIL_FILE_DIR"),
+		SMTP_HOST:     viper.GetString("SMTP_HOST"),
+		SMTP_PORT:     viper.GetString("SMTP_PORT"),
+		SMTP_USERNAME: viper.GetString("SMTP_USERNAME"),
+		SMTP_PASSWORD: viper.GetString("SMTP_PASSWORD"),
 	}
 
 	return config
 }
+
+// splitList reads a comma-separated setting, leaving out empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -12,6 +12,11 @@ import (
 
 var DB *gorm.DB
 
+// Migrate creates or updates the tables of every model.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&models.User{}, &models.Event{}, &models.EventSeries{}, &models.SwapRequest{}, &models.SwapCycle{}, &models.SwapCycleLeg{}, &models.SwapWant{}, &models.SwapAuditEntry{}, &models.EventOwnershipHistory{}, &models.Session{}, &models.RevokedToken{}, &models.PasswordResetToken{}, &models.EmailVerificationToken{}, &models.MFARecoveryCode{}, &models.AdminAuditEntry{}, &models.Organization{}, &models.OrganizationMember{}, &models.Team{}, &models.TeamMember{}, &models.Invitation{}, &models.APIKey{}, &models.LoginAttempt{}, &models.AccountUnlockToken{}, &models.CalendarFeedToken{})
+}
+
 func ConnectDB(cfg *config.Config) (*gorm.DB, error) {
 	if cfg.DB_URL == "" {
 		return nil, fmt.Errorf("db url is nil")
@@ -43,7 +48,7 @@ func ConnectDB(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to create pgcrypto extension: %w", err)
 	}
 
-	if err := db.AutoMigrate(&models.User{}, &models.Event{}, &models.SwapRequest{}); err != nil {
+	if err := Migrate(db); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
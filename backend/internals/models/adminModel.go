@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+type AdminAuditAction string
+
+const (
+	AdminAuditUserDisabled         AdminAuditAction = "USER_DISABLED"
+	AdminAuditUserEnabled          AdminAuditAction = "USER_ENABLED"
+	AdminAuditUserRoleChanged      AdminAuditAction = "USER_ROLE_CHANGED"
+	AdminAuditSwapRequestCancelled AdminAuditAction = "SWAP_REQUEST_CANCELLED"
+	AdminAuditEventStatusReset     AdminAuditAction = "EVENT_STATUS_RESET"
+	AdminAuditUserUnlocked         AdminAuditAction = "USER_UNLOCKED"
+)
+
+// AdminAuditEntry records an action taken through the admin API. Like
+// SwapAuditEntry it is append-only and has no foreign keys.
+type AdminAuditEntry struct {
+	ID         uint             `gorm:"primaryKey" json:"id"`
+	ActorID    uint             `gorm:"not null;index" json:"actorId"`
+	Action     AdminAuditAction `gorm:"type:varchar(30);not null" json:"action"`
+	TargetType string           `gorm:"type:varchar(20);not null;index:idx_admin_audit_target" json:"targetType"`
+	TargetID   uint             `gorm:"not null;index:idx_admin_audit_target" json:"targetId"`
+	Details    string           `json:"details,omitempty"`
+	CreatedAt  time.Time        `gorm:"index" json:"createdAt"`
+}
+
+// AdminReasonInput is the optional justification stored with an admin action.
+type AdminReasonInput struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type EventStatusResetInput struct {
+	Status EventStatus `json:"status" binding:"required,oneof=BUSY SWAPPABLE"`
+	Reason string      `json:"reason" binding:"max=500"`
+}
+
+// UserSearch filters the admin user listing.
+type UserSearch struct {
+	Query    string
+	Role     Role
+	Disabled *bool
+	Page     int
+	Limit    int
+}
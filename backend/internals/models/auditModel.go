@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+type SwapAuditAction string
+
+const (
+	SwapAuditCreated   SwapAuditAction = "CREATED"
+	SwapAuditAccepted  SwapAuditAction = "ACCEPTED"
+	SwapAuditRejected  SwapAuditAction = "REJECTED"
+	SwapAuditCancelled SwapAuditAction = "CANCELLED"
+	SwapAuditCountered SwapAuditAction = "COUNTERED"
+	SwapAuditExpired   SwapAuditAction = "EXPIRED"
+	SwapAuditApproved  SwapAuditAction = "APPROVED"
+	SwapAuditCommitted SwapAuditAction = "COMMITTED"
+
+	SwapAuditReversalRequested SwapAuditAction = "REVERSAL_REQUESTED"
+	SwapAuditReversed          SwapAuditAction = "REVERSED"
+)
+
+// SwapAuditEntry is an append-only record of a state change on a swap
+// request or swap cycle. ActorID is nil for changes made by the server, such
+// as expiry. Rows are never updated or deleted, and carry no foreign keys so
+// they outlive the rows they describe.
+type SwapAuditEntry struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	SwapRequestID *uint           `gorm:"index" json:"swapRequestId,omitempty"`
+	SwapCycleID   *uint           `gorm:"index" json:"swapCycleId,omitempty"`
+	Action        SwapAuditAction `gorm:"type:varchar(20);not null" json:"action"`
+	ActorID       *uint           `json:"actorId,omitempty"`
+	FromStatus    string          `gorm:"type:varchar(20)" json:"fromStatus,omitempty"`
+	ToStatus      string          `gorm:"type:varchar(20);not null" json:"toStatus"`
+	Details       string          `json:"details,omitempty"`
+	CreatedAt     time.Time       `gorm:"index" json:"createdAt"`
+}
+
+// EventOwnershipHistory records every change of Event.OwnerID together with
+// the swap that caused it. Like SwapAuditEntry it is append-only.
+type EventOwnershipHistory struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	EventID         uint      `gorm:"not null;index" json:"eventId"`
+	PreviousOwnerID uint      `gorm:"not null;index" json:"previousOwnerId"`
+	NewOwnerID      uint      `gorm:"not null;index" json:"newOwnerId"`
+	SwapRequestID   *uint     `gorm:"index" json:"swapRequestId,omitempty"`
+	SwapCycleID     *uint     `gorm:"index" json:"swapCycleId,omitempty"`
+	ChangedAt       time.Time `gorm:"not null;index" json:"changedAt"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CalendarFeedToken lets calendar apps subscribe to a user's events without
+// signing in. Each user has at most one; only its hash is stored.
+type CalendarFeedToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;uniqueIndex" json:"userId"`
+	User       User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// EmailVerificationToken is a single-use token mailed to confirm that a user
+// owns Email. The address is kept on the token so that a link sent before the
+// user changed their email cannot verify the new one.
+type EmailVerificationToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Email     string    `gorm:"not null"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
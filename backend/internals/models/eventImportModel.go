@@ -0,0 +1,32 @@
+package models
+
+type EventImportResult string
+
+const (
+	EventImportCreated EventImportResult = "CREATED"
+	EventImportUpdated EventImportResult = "UPDATED"
+	EventImportSkipped EventImportResult = "SKIPPED"
+	EventImportInvalid EventImportResult = "INVALID"
+)
+
+// EventImportItem reports what an import did with one VEVENT. RecurrenceID
+// is set for an entry that changes a single occurrence of a recurring one.
+type EventImportItem struct {
+	UID          string            `json:"uid,omitempty"`
+	RecurrenceID string            `json:"recurrenceId,omitempty"`
+	Summary      string            `json:"summary,omitempty"`
+	Result       EventImportResult `json:"result"`
+	Reason       string            `json:"reason,omitempty"`
+	EventID      *uint             `json:"eventId,omitempty"`
+	SeriesID     *uint             `json:"seriesId,omitempty"`
+}
+
+// EventImportReport counts the results of an import, with one item per
+// VEVENT in file order.
+type EventImportReport struct {
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Invalid int               `json:"invalid"`
+	Items   []EventImportItem `json:"items"`
+}
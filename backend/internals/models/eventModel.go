@@ -14,11 +14,36 @@ const (
 	EventStatusSwapPending EventStatus = "SWAP_PENDING"
 )
 
+// CreateEventInput is what clients may send when creating an event. Owner and
+// swap state are always set by the server. TimeZone defaults to the owner's.
+// Times are RFC3339, or date-times without an offset read in the event's
+// time zone; all-day events also take plain dates, with endTime the day
+// after the last.
+type CreateEventInput struct {
+	Title     string  `json:"title" binding:"required"`
+	StartTime string  `json:"startTime" binding:"required"`
+	EndTime   string  `json:"endTime" binding:"required"`
+	AllDay    bool    `json:"allDay,omitempty"`
+	TimeZone  *string `json:"timeZone,omitempty"`
+	Status    *string `json:"status,omitempty"`
+	TeamID    *uint   `json:"teamId,omitempty"`
+	OrgWide   bool    `json:"orgWide,omitempty"`
+	// ICalUID is set by the server for events created over CalDAV.
+	ICalUID string `json:"-"`
+}
+
+// UpdateEventInput changes only the fields that are present. A teamId of 0
+// removes the event from its team. Times are read as for CreateEventInput;
+// allDay can only change together with both times.
 type UpdateEventInput struct {
 	Title     *string `json:"title,omitempty"`
 	StartTime *string `json:"startTime,omitempty"`
 	EndTime   *string `json:"endTime,omitempty"`
+	AllDay    *bool   `json:"allDay,omitempty"`
+	TimeZone  *string `json:"timeZone,omitempty"`
 	Status    *string `json:"status,omitempty"`
+	TeamID    *uint   `json:"teamId,omitempty"`
+	OrgWide   *bool   `json:"orgWide,omitempty"`
 }
 
 type Event struct {
@@ -27,11 +52,53 @@ type Event struct {
 	StartTime time.Time   `gorm:"not null" json:"startTime"`
 	EndTime   time.Time   `gorm:"not null" json:"endTime"`
 	Status    EventStatus `gorm:"type:varchar(20);not null;default:BUSY" json:"status"`
+	// TimeZone is the IANA zone the event was entered in, used to read local
+	// times sent for it. Times are stored in UTC; events from before zones
+	// were recorded have none.
+	TimeZone string `gorm:"type:varchar(64)" json:"timeZone,omitempty"`
+	// AllDay marks an event covering whole days. Its times are midnight UTC
+	// of the first day and of the day after the last, and it keeps those
+	// dates in every time zone.
+	AllDay bool `gorm:"not null;default:false" json:"allDay"`
 
 	OwnerID uint `gorm:"not null" json:"ownerId"`
 	Owner   User `gorm:"foreignKey:OwnerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"owner,omitempty"`
 
+	// TeamID scopes the event to a team's marketplace; OrganizationID is
+	// copied from the team so org-wide slots can be found without a join.
+	// Events without a team are offered to everyone.
+	TeamID         *uint `gorm:"index" json:"teamId,omitempty"`
+	OrganizationID *uint `gorm:"index" json:"organizationId,omitempty"`
+	OrgWide        bool  `gorm:"not null;default:false" json:"orgWide"`
+
+	// SeriesID and OccurrenceStart tie an occurrence to its recurring series;
+	// OccurrenceStart is the start the rule gave it, which stays the same when
+	// the occurrence is moved. Overridden marks an occurrence edited on its own,
+	// which later changes to the series leave alone.
+	SeriesID        *uint      `gorm:"index" json:"seriesId,omitempty"`
+	OccurrenceStart *time.Time `json:"occurrenceStart,omitempty"`
+	Overridden      bool       `gorm:"not null;default:false" json:"overridden,omitempty"`
+
+	// Sequence is the iCalendar revision of the event, raised when its times
+	// or owner change so calendar clients replace their copy.
+	Sequence int `gorm:"not null;default:0" json:"sequence"`
+	// ICalUID is the UID of the calendar entry the event was imported or
+	// created over CalDAV from, so importing the same file again updates the
+	// event instead of copying it.
+	ICalUID string `gorm:"column:ical_uid;type:varchar(255);index" json:"icalUid,omitempty"`
+
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
 }
+
+// EventConflict describes an overlap between an event and one already on a
+// user's calendar.
+type EventConflict struct {
+	EventID          uint      `json:"eventId"`
+	ConflictingID    uint      `json:"conflictingEventId"`
+	ConflictingTitle string    `json:"conflictingTitle"`
+	ConflictingStart time.Time `json:"conflictingStartTime"`
+	ConflictingEnd   time.Time `json:"conflictingEndTime"`
+	OverlapMinutes   int       `json:"overlapMinutes"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EventSeriesInput creates a recurring series. StartTime and EndTime are the
+// first occurrence; RRule is an RFC 5545 rule such as
+// "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10". TimeZone defaults to the owner's.
+type EventSeriesInput struct {
+	Title     string      `json:"title" binding:"required"`
+	StartTime time.Time   `json:"startTime" binding:"required"`
+	EndTime   time.Time   `json:"endTime" binding:"required"`
+	TimeZone  *string     `json:"timeZone,omitempty"`
+	RRule     string      `json:"rrule" binding:"required"`
+	ExDates   []time.Time `json:"exDates,omitempty"`
+	Status    *string     `json:"status,omitempty"`
+	TeamID    *uint       `json:"teamId,omitempty"`
+	OrgWide   bool        `json:"orgWide,omitempty"`
+}
+
+// UpdateEventSeriesInput changes only the fields that are present. ExDates,
+// when present, replaces the whole list.
+type UpdateEventSeriesInput struct {
+	Title     *string      `json:"title,omitempty"`
+	StartTime *time.Time   `json:"startTime,omitempty"`
+	EndTime   *time.Time   `json:"endTime,omitempty"`
+	TimeZone  *string      `json:"timeZone,omitempty"`
+	RRule     *string      `json:"rrule,omitempty"`
+	ExDates   *[]time.Time `json:"exDates,omitempty"`
+	Status    *string      `json:"status,omitempty"`
+	TeamID    *uint        `json:"teamId,omitempty"`
+	OrgWide   *bool        `json:"orgWide,omitempty"`
+}
+
+// EventSeries is a recurring event. Its occurrences are stored as ordinary
+// events so each one can be listed, offered and swapped on its own.
+type EventSeries struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title     string    `gorm:"type:varchar(255);not null" json:"title"`
+	StartTime time.Time `gorm:"not null" json:"startTime"`
+	EndTime   time.Time `gorm:"not null" json:"endTime"`
+	RRule     string    `gorm:"type:varchar(255);not null" json:"rrule"`
+	// TimeZone is the IANA zone the rule is expanded in, so occurrences keep
+	// their wall-clock time across daylight saving changes. Series from
+	// before zones were recorded have none and are expanded in UTC.
+	TimeZone string `gorm:"type:varchar(64)" json:"timeZone,omitempty"`
+	// ExDates are occurrence starts the rule would give that are skipped.
+	ExDates []time.Time `gorm:"type:text;serializer:json" json:"exDates"`
+	// Status is given to newly created occurrences.
+	Status EventStatus `gorm:"type:varchar(20);not null;default:BUSY" json:"status"`
+
+	OwnerID uint `gorm:"not null;index" json:"ownerId"`
+	Owner   User `gorm:"foreignKey:OwnerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+
+	TeamID         *uint `json:"teamId,omitempty"`
+	OrganizationID *uint `json:"organizationId,omitempty"`
+	OrgWide        bool  `gorm:"not null;default:false" json:"orgWide"`
+
+	// ICalUID is the UID of the recurring calendar entry the series was
+	// imported from.
+	ICalUID string `gorm:"column:ical_uid;type:varchar(255);index" json:"icalUid,omitempty"`
+
+	Occurrences []Event `gorm:"foreignKey:SeriesID" json:"occurrences,omitempty"`
+
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginAttempt is the failed sign-in state for one account or client IP,
+// keyed "account:<email>" or "ip:<address>".
+type LoginAttempt struct {
+	Key           string `gorm:"primaryKey;type:varchar(320)"`
+	Failures      int    `gorm:"not null;default:0"`
+	LastFailureAt time.Time
+	BlockedUntil  time.Time `gorm:"index"`
+	Locked        bool      `gorm:"not null;default:false"`
+	UpdatedAt     time.Time
+}
+
+// AccountUnlockToken is a single-use token mailed to a user whose account
+// was locked after repeated failed sign-ins. Only its SHA-256 hash is stored.
+type AccountUnlockToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type UnlockAccountInput struct {
+	Token string `json:"token" binding:"required"`
+}
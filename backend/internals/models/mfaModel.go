@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// MFARecoveryCode is a one-time code that stands in for a TOTP code when the
+// user has lost their authenticator. Only the SHA-256 hash is stored.
+type MFARecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	User      User   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CodeHash  string `gorm:"type:varchar(64);not null;index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// MFACodeInput carries either a six digit TOTP code or a recovery code.
+type MFACodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFASignInInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrgRole is a user's role within one organization. Owners and admins manage
+// teams, members and invitations; members only take part in swaps.
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "OWNER"
+	OrgRoleAdmin  OrgRole = "ADMIN"
+	OrgRoleMember OrgRole = "MEMBER"
+)
+
+type Organization struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"type:varchar(255);not null" json:"name"`
+	CreatedByID uint           `gorm:"not null" json:"createdById"`
+	Teams       []Team         `gorm:"foreignKey:OrganizationID" json:"teams,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type OrganizationMember struct {
+	ID             uint         `gorm:"primaryKey" json:"id"`
+	OrganizationID uint         `gorm:"not null;uniqueIndex:idx_org_member" json:"organizationId"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID;constraint:OnDelete:CASCADE" json:"-"`
+	UserID         uint         `gorm:"not null;uniqueIndex:idx_org_member;index" json:"userId"`
+	User           User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Role           OrgRole      `gorm:"type:varchar(10);not null;default:'MEMBER'" json:"role"`
+	CreatedAt      time.Time    `json:"createdAt"`
+}
+
+// Team groups members of an organization. Slots scoped to a team are only
+// offered to its members unless they are published org-wide.
+type Team struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	OrganizationID uint           `gorm:"not null;index" json:"organizationId"`
+	Name           string         `gorm:"type:varchar(255);not null" json:"name"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type TeamMember struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TeamID    uint      `gorm:"not null;uniqueIndex:idx_team_member" json:"teamId"`
+	Team      Team      `gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_team_member;index" json:"userId"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Invitation asks someone, by email, to join an organization and optionally
+// one of its teams. The emailed token is stored hashed.
+type Invitation struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	OrganizationID uint       `gorm:"not null;index" json:"organizationId"`
+	TeamID         *uint      `json:"teamId,omitempty"`
+	Email          string     `gorm:"not null;index" json:"email"`
+	Role           OrgRole    `gorm:"type:varchar(10);not null;default:'MEMBER'" json:"role"`
+	TokenHash      string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	InvitedByID    uint       `gorm:"not null" json:"invitedById"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expiresAt"`
+	AcceptedAt     *time.Time `json:"acceptedAt,omitempty"`
+	AcceptedByID   *uint      `json:"acceptedById,omitempty"`
+	RevokedAt      *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// OrganizationMemberView is a member as listed to other members.
+type OrganizationMemberView struct {
+	UserID  uint    `json:"userId"`
+	Name    string  `json:"name"`
+	Email   string  `json:"email"`
+	Role    OrgRole `json:"role"`
+	TeamIDs []uint  `json:"teamIds"`
+}
+
+type OrganizationInput struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type TeamInput struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type TeamMemberInput struct {
+	UserID uint `json:"userId" binding:"required"`
+}
+
+type InvitationInput struct {
+	Email  string  `json:"email" binding:"required,email"`
+	TeamID *uint   `json:"teamId"`
+	Role   OrgRole `json:"role" binding:"omitempty,oneof=ADMIN MEMBER"`
+}
+
+type AcceptInvitationInput struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Session is one signed-in device. Its refresh token is stored only as a
+// hash and is replaced on every refresh; AccessTokenID is the jti of the most
+// recent access token so it can be denylisted when the session is revoked.
+type Session struct {
+	ID                   uint       `gorm:"primaryKey" json:"id"`
+	UserID               uint       `gorm:"not null;index" json:"-"`
+	User                 User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	Device               string     `gorm:"type:varchar(255)" json:"device"`
+	IP                   string     `gorm:"type:varchar(64)" json:"ip"`
+	UserAgent            string     `gorm:"type:varchar(512)" json:"userAgent"`
+	RefreshTokenHash     string     `gorm:"type:varchar(64)" json:"-"`
+	AccessTokenID        string     `gorm:"type:varchar(64)" json:"-"`
+	AccessTokenExpiresAt *time.Time `json:"-"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	LastUsedAt           time.Time  `json:"lastUsedAt"`
+	RevokedAt            *time.Time `gorm:"index" json:"revokedAt,omitempty"`
+	Current              bool       `gorm:"-" json:"current"`
+}
+
+// RevokedToken is a denylisted JWT, kept until the token would have expired
+// anyway.
+type RevokedToken struct {
+	TokenID   string    `gorm:"primaryKey;type:varchar(64)"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
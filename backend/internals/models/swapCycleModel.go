@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+type SwapCycleStatus string
+
+const (
+	SwapCyclePending   SwapCycleStatus = "PENDING"
+	SwapCycleCommitted SwapCycleStatus = "COMMITTED"
+	SwapCycleRejected  SwapCycleStatus = "REJECTED"
+	SwapCycleExpired   SwapCycleStatus = "EXPIRED"
+)
+
+// SwapCycle is an N-way trade. Legs are ordered by Position and each leg's
+// event moves from its giver to the giver of the previous leg, so the legs
+// [A's slot, B's slot, C's slot] hand B's slot to A, C's to B and A's to C.
+type SwapCycle struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	ProposerID   uint            `gorm:"not null" json:"proposerId"`
+	Proposer     User            `gorm:"foreignKey:ProposerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"proposer,omitempty"`
+	Status       SwapCycleStatus `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"`
+	Legs         []SwapCycleLeg  `gorm:"foreignKey:CycleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"legs"`
+	RejectedByID *uint           `json:"rejectedById,omitempty"`
+	ExpiresAt    *time.Time      `gorm:"index" json:"expiresAt,omitempty"`
+	CommittedAt  *time.Time      `json:"committedAt,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+}
+
+type SwapCycleLeg struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CycleID    uint       `gorm:"not null;index" json:"cycleId"`
+	Position   int        `gorm:"not null" json:"position"`
+	EventID    uint       `gorm:"not null;index" json:"eventId"`
+	Event      Event      `gorm:"foreignKey:EventID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"event"`
+	GiverID    uint       `gorm:"not null;index" json:"giverId"`
+	ReceiverID uint       `gorm:"not null" json:"receiverId"`
+	ApprovedAt *time.Time `json:"approvedAt,omitempty"`
+}
+
+type SwapCycleInput struct {
+	EventIDs        []uint `json:"event_ids" binding:"required"`
+	IgnoreConflicts bool   `json:"ignore_conflicts"`
+}
+
+type SwapCycleResponseInput struct {
+	Accepted        *bool `json:"accepted" binding:"required"`
+	IgnoreConflicts bool  `json:"ignore_conflicts"`
+}
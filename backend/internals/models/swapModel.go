@@ -6,27 +6,62 @@ import (
 
 type SwapStatus string
 
+// PENDING waits on the responder and COUNTERED waits on the requester; a
+// request that has been answered with a counter-offer becomes SUPERSEDED and
+// the offer continues on its child request. Open requests that outlive their
+// ExpiresAt are moved to EXPIRED by the background sweeper. An ACCEPTED swap
+// undone within the undo window becomes REVERSED.
 const (
-	PENDING  SwapStatus = "PENDING"
-	ACCEPTED SwapStatus = "ACCEPTED"
-	REJECTED SwapStatus = "REJECTED"
+	PENDING    SwapStatus = "PENDING"
+	COUNTERED  SwapStatus = "COUNTERED"
+	ACCEPTED   SwapStatus = "ACCEPTED"
+	REJECTED   SwapStatus = "REJECTED"
+	CANCELLED  SwapStatus = "CANCELLED"
+	SUPERSEDED SwapStatus = "SUPERSEDED"
+	EXPIRED    SwapStatus = "EXPIRED"
+	REVERSED   SwapStatus = "REVERSED"
 )
 
 type SwapRequest struct {
-	ID               uint       `gorm:"primaryKey"`
-	RequesterID      uint       `gorm:"not null"`
-	Requester        User       `gorm:"foreignKey:RequesterID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	ResponderID      uint       `gorm:"not null"`
-	Responder        User       `gorm:"foreignKey:ResponderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	RequesterEventID uint       `gorm:"not null"`
-	RequesterEvent   Event      `gorm:"foreignKey:RequesterEventID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	ResponderEventID uint       `gorm:"not null"`
-	ResponderEvent   Event      `gorm:"foreignKey:ResponderEventID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Status           SwapStatus `gorm:"type:varchar(20);not null;default:'PENDING'"`
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
+	ID               uint         `gorm:"primaryKey"`
+	RequesterID      uint         `gorm:"not null"`
+	Requester        User         `gorm:"foreignKey:RequesterID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ResponderID      uint         `gorm:"not null"`
+	Responder        User         `gorm:"foreignKey:ResponderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	RequesterEventID uint         `gorm:"not null"`
+	RequesterEvent   Event        `gorm:"foreignKey:RequesterEventID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ResponderEventID uint         `gorm:"not null"`
+	ResponderEvent   Event        `gorm:"foreignKey:ResponderEventID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Status           SwapStatus   `gorm:"type:varchar(20);not null;default:'PENDING'"`
+	ParentRequestID  *uint        `gorm:"index"`
+	ParentRequest    *SwapRequest `gorm:"foreignKey:ParentRequestID"`
+	ExpiresAt        *time.Time   `gorm:"index"`
+	CancelledByID    *uint
+	CancelledAt      *time.Time
+	AcceptedAt       *time.Time
+
+	ReversalRequestedByID *uint
+	ReversalRequestedAt   *time.Time
+	ReversedByID          *uint
+	ReversedAt            *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
+
+// Swap undo policies. Under SwapUndoMutual both parties must ask for the
+// reversal; under SwapUndoUnilateral either party can reverse on their own.
+const (
+	SwapUndoMutual     = "mutual"
+	SwapUndoUnilateral = "unilateral"
+)
+
 type SwapRequestInput struct {
-	MySlotID    uint `json:"my_slot_id" binding:"required"`
-	TheirSlotID uint `json:"their_slot_id" binding:"required"`
+	MySlotID        uint `json:"my_slot_id" binding:"required"`
+	TheirSlotID     uint `json:"their_slot_id" binding:"required"`
+	IgnoreConflicts bool `json:"ignore_conflicts"`
+}
+
+type CounterOfferInput struct {
+	MySlotID uint `json:"my_slot_id" binding:"required"`
 }
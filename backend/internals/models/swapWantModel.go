@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SwapWant describes what a user would accept in exchange for one of their
+// swappable events. Zero-valued bounds are unconstrained.
+type SwapWant struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	UserID             uint       `gorm:"not null;index" json:"userId"`
+	User               User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	EventID            uint       `gorm:"not null;index" json:"eventId"`
+	Event              Event      `gorm:"foreignKey:EventID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"event,omitempty"`
+	EarliestStart      *time.Time `json:"earliestStart,omitempty"`
+	LatestEnd          *time.Time `json:"latestEnd,omitempty"`
+	MinDurationMinutes int        `gorm:"not null;default:0" json:"minDurationMinutes"`
+	MaxDurationMinutes int        `gorm:"not null;default:0" json:"maxDurationMinutes"`
+	ExcludedDays       string     `gorm:"type:varchar(64)" json:"excludedDays"`
+
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type SwapWantInput struct {
+	EventID            uint     `json:"event_id" binding:"required"`
+	EarliestStart      *string  `json:"earliest_start,omitempty"`
+	LatestEnd          *string  `json:"latest_end,omitempty"`
+	MinDurationMinutes int      `json:"min_duration_minutes"`
+	MaxDurationMinutes int      `json:"max_duration_minutes"`
+	ExcludedDays       []string `json:"excluded_days,omitempty"`
+}
+
+type MatchKind string
+
+const (
+	MatchPairwise MatchKind = "PAIRWISE"
+	MatchCycle    MatchKind = "CYCLE"
+)
+
+// MatchLeg is one hand-off inside a suggested trade.
+type MatchLeg struct {
+	EventID    uint `json:"eventId"`
+	GiverID    uint `json:"giverId"`
+	ReceiverID uint `json:"receiverId"`
+}
+
+// Match is a suggested trade. EventIDs are in swap cycle order and always
+// start with the caller's event; ID encodes them so a match can be accepted
+// without storing it.
+type Match struct {
+	ID       string     `json:"id"`
+	Kind     MatchKind  `json:"kind"`
+	EventIDs []uint     `json:"eventIds"`
+	Legs     []MatchLeg `json:"legs"`
+	Score    float64    `json:"score"`
+}
+
+// MatchAcceptance holds whatever accepting a match created: a swap request
+// for a pairwise match or a swap cycle for a longer one.
+type MatchAcceptance struct {
+	SwapRequest *SwapRequest `json:"swapRequest,omitempty"`
+	SwapCycle   *SwapCycle   `json:"swapCycle,omitempty"`
+}
@@ -6,14 +6,75 @@ import (
 	"gorm.io/gorm"
 )
 
+// ConflictPolicy decides what happens when a swap would hand a user a slot
+// that overlaps one of their BUSY events. WARN refuses with the conflicts
+// unless the user confirms, BLOCK always refuses and ALLOW never checks.
+type ConflictPolicy string
+
+const (
+	ConflictPolicyWarn  ConflictPolicy = "WARN"
+	ConflictPolicyBlock ConflictPolicy = "BLOCK"
+	ConflictPolicyAllow ConflictPolicy = "ALLOW"
+)
+
+// Role controls access to the admin API. Moderators can cancel swap requests
+// and reset event statuses; only admins can manage accounts.
+type Role string
+
+const (
+	RoleUser      Role = "USER"
+	RoleModerator Role = "MODERATOR"
+	RoleAdmin     Role = "ADMIN"
+)
+
 type User struct {
-	ID        uint    `gorm:"primaryKey"`
-	Name      string  `gorm:"not null"`
-	Email     string  `gorm:"uniqueIndex;not null"`
-	Password  string  `gorm:"not null"`
-	RefreshToken string
-	Events    []Event `gorm:"foreignKey:OwnerID"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID              uint   `gorm:"primaryKey"`
+	Name            string `gorm:"not null"`
+	Email           string `gorm:"uniqueIndex;not null"`
+	Password        string `gorm:"not null"`
+	Role            Role   `gorm:"type:varchar(20);not null;default:'USER';index"`
+	DisabledAt      *time.Time
+	EmailVerifiedAt *time.Time
+	// PendingEmail is an address the user asked to switch to; it replaces
+	// Email once its verification link is used.
+	PendingEmail    string         `gorm:"type:varchar(255)"`
+	TOTPSecret      string         `gorm:"column:totp_secret" json:"-"`
+	TOTPEnabledAt   *time.Time     `gorm:"column:totp_enabled_at"`
+	TOTPLastCounter int64          `gorm:"column:totp_last_counter" json:"-"`
+	ConflictPolicy  ConflictPolicy `gorm:"type:varchar(10);not null;default:'WARN'"`
+	TimeZone        string         `gorm:"type:varchar(64);not null;default:'UTC'"`
+	Events          []Event        `gorm:"foreignKey:OwnerID"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ConflictPolicyInput struct {
+	Policy ConflictPolicy `json:"policy" binding:"required,oneof=WARN BLOCK ALLOW"`
+}
+
+// UpdateProfileInput changes only the fields that are present. Changing the
+// email needs the current password.
+type UpdateProfileInput struct {
+	Name            *string `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Email           *string `json:"email,omitempty" binding:"omitempty,email"`
+	TimeZone        *string `json:"timeZone,omitempty"`
+	CurrentPassword string  `json:"currentPassword,omitempty"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type RoleInput struct {
+	Role Role `json:"role" binding:"required,oneof=USER MODERATOR ADMIN"`
 }
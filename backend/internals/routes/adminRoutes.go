@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/handlers"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/middlewares"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/models"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(r *gin.Engine, cfg *config.Config, guard *loginguard.Guard) {
+	admin := r.Group("/api/admin")
+	admin.Use(middlewares.JWTAuthMiddleware(cfg), middlewares.RequireRole(models.RoleAdmin, models.RoleModerator))
+	adminOnly := middlewares.RequireRole(models.RoleAdmin)
+	{
+		admin.GET("/users", adminOnly, handlers.AdminListUsersHandler)
+		admin.POST("/users/:id/disable", adminOnly, handlers.AdminDisableUserHandler)
+		admin.POST("/users/:id/enable", adminOnly, handlers.AdminEnableUserHandler)
+		admin.POST("/users/:id/unlock", adminOnly, func(c *gin.Context) { handlers.AdminUnlockUserHandler(c, guard) })
+		admin.PUT("/users/:id/role", adminOnly, handlers.AdminSetUserRoleHandler)
+		admin.POST("/swap-requests/:requestId/cancel", handlers.AdminCancelSwapRequestHandler)
+		admin.POST("/events/:id/reset-status", handlers.AdminResetEventStatusHandler)
+		admin.GET("/audit", adminOnly, handlers.AdminAuditLogHandler)
+	}
+}
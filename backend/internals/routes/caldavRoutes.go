@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/handlers"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/middlewares"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/gin-gonic/gin"
+)
+
+func CalDAVRoutes(r *gin.Engine, cfg *config.Config) {
+	r.GET("/.well-known/caldav", handlers.CalDAVWellKnownHandler)
+	r.Handle("PROPFIND", "/.well-known/caldav", handlers.CalDAVWellKnownHandler)
+	r.OPTIONS("/dav/*path", handlers.CalDAVOptionsHandler)
+
+	protected := r.Group("/dav")
+	protected.Use(middlewares.DAVAuthMiddleware(cfg), middlewares.RequireScope("events"))
+	{
+		protected.Handle("PROPFIND", "/*path", handlers.CalDAVPropfindHandler)
+		protected.Handle("REPORT", "/*path", handlers.CalDAVReportHandler)
+		protected.GET("/*path", handlers.CalDAVGetHandler)
+		protected.HEAD("/*path", handlers.CalDAVGetHandler)
+		protected.PUT("/*path", func(c *gin.Context) { handlers.CalDAVPutHandler(c, cfg) })
+		protected.DELETE("/*path", handlers.CalDAVDeleteHandler)
+	}
+}
@@ -8,13 +8,24 @@ import (
 )
 
 func EventRoutes(r *gin.Engine, cfg *config.Config) {
+	r.GET("/api/calendar/feeds/:token", handlers.CalendarFeedHandler)
+
 	protected := r.Group("/api")
-	protected.Use(middlewares.JWTAuthMiddleware(cfg))
+	protected.Use(middlewares.AuthMiddleware(cfg), middlewares.RequireScope("events"))
 	{
-		protected.POST("/events", handlers.CreateEventHandler)
+		protected.POST("/events", func(c *gin.Context) { handlers.CreateEventHandler(c, cfg) })
 		protected.GET("/events", handlers.GetUserEventsHandler)
-		protected.PUT("/events/:id", handlers.UpdateEventHandler)
+		protected.GET("/events.ics", handlers.ExportEventsICSHandler)
+		protected.POST("/events/import", func(c *gin.Context) { handlers.ImportEventsHandler(c, cfg) })
+		protected.GET("/events/conflicts", handlers.GetEventConflictsHandler)
+		protected.PUT("/events/:id", func(c *gin.Context) { handlers.UpdateEventHandler(c, cfg) })
 		protected.DELETE("/events/:id", handlers.DeleteEventHandler)
+		protected.GET("/events/:id/history", handlers.GetEventHistoryHandler)
+		protected.POST("/event-series", func(c *gin.Context) { handlers.CreateEventSeriesHandler(c, cfg) })
+		protected.GET("/event-series", handlers.GetEventSeriesListHandler)
+		protected.GET("/event-series/:id", handlers.GetEventSeriesHandler)
+		protected.PUT("/event-series/:id", func(c *gin.Context) { handlers.UpdateEventSeriesHandler(c, cfg) })
+		protected.DELETE("/event-series/:id", handlers.DeleteEventSeriesHandler)
 		protected.GET("/swappable-slots", handlers.GetSwappableSlotsHandler)
 	}
 }
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/handlers"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/middlewares"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/gin-gonic/gin"
+)
+
+func MatchRoutes(r *gin.Engine, cfg *config.Config) {
+	protected := r.Group("/api")
+	protected.Use(middlewares.AuthMiddleware(cfg), middlewares.RequireScope("swaps"))
+	verified := middlewares.RequireVerifiedEmail(cfg)
+	{
+		protected.POST("/wants", handlers.CreateSwapWantHandler)
+		protected.GET("/wants", handlers.GetSwapWantsHandler)
+		protected.DELETE("/wants/:id", handlers.DeleteSwapWantHandler)
+		protected.GET("/matches", handlers.GetMatchesHandler)
+		protected.POST("/matches/:matchId/accept", verified, func(c *gin.Context) { handlers.AcceptMatchHandler(c, cfg) })
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/handlers"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/middlewares"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
+	"github.com/gin-gonic/gin"
+)
+
+func OrganizationRoutes(r *gin.Engine, cfg *config.Config, m mailer.Mailer) {
+	protected := r.Group("/api")
+	protected.Use(middlewares.AuthMiddleware(cfg), middlewares.RequireScope("orgs"))
+	{
+		protected.POST("/orgs", handlers.CreateOrganizationHandler)
+		protected.GET("/orgs", handlers.GetOrganizationsHandler)
+		protected.GET("/orgs/:orgId", handlers.GetOrganizationHandler)
+		protected.DELETE("/orgs/:orgId/members/:userId", handlers.RemoveOrganizationMemberHandler)
+		protected.POST("/orgs/:orgId/teams", handlers.CreateTeamHandler)
+		protected.GET("/orgs/:orgId/teams", handlers.GetTeamsHandler)
+		protected.POST("/orgs/:orgId/teams/:teamId/members", handlers.AddTeamMemberHandler)
+		protected.DELETE("/orgs/:orgId/teams/:teamId/members/:userId", handlers.RemoveTeamMemberHandler)
+		protected.POST("/orgs/:orgId/invitations", func(c *gin.Context) { handlers.CreateInvitationHandler(c, cfg, m) })
+		protected.GET("/orgs/:orgId/invitations", handlers.GetInvitationsHandler)
+		protected.DELETE("/orgs/:orgId/invitations/:id", handlers.RevokeInvitationHandler)
+		protected.POST("/invitations/accept", handlers.AcceptInvitationHandler)
+	}
+}
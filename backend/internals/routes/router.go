@@ -2,11 +2,18 @@ package routes
 
 import (
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
 	"github.com/gin-gonic/gin"
 )
 
-func SetUpRoutes(r *gin.Engine, cfg *config.Config) {
-	UserRoutes(r, cfg)
+func SetUpRoutes(r *gin.Engine, cfg *config.Config, m mailer.Mailer, guard *loginguard.Guard) {
+	UserRoutes(r, cfg, m, guard)
 	EventRoutes(r, cfg)
+	CalDAVRoutes(r, cfg)
 	SwapRoutes(r, cfg)
+	SwapCycleRoutes(r, cfg)
+	MatchRoutes(r, cfg)
+	AdminRoutes(r, cfg, guard)
+	OrganizationRoutes(r, cfg, m)
 }
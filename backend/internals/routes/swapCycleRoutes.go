@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/handlers"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/middlewares"
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/gin-gonic/gin"
+)
+
+func SwapCycleRoutes(r *gin.Engine, cfg *config.Config) {
+	protected := r.Group("/api")
+	protected.Use(middlewares.AuthMiddleware(cfg), middlewares.RequireScope("swaps"))
+	verified := middlewares.RequireVerifiedEmail(cfg)
+	{
+		protected.POST("/swap-cycles", verified, func(c *gin.Context) { handlers.ProposeSwapCycleHandler(c, cfg) })
+		protected.GET("/swap-cycles", handlers.GetUserSwapCyclesHandler)
+		protected.GET("/swap-cycles/:cycleId", handlers.GetSwapCycleHandler)
+		protected.POST("/swap-cycles/:cycleId/respond", handlers.RespondToSwapCycleHandler)
+	}
+}
@@ -9,11 +9,16 @@ import (
 
 func SwapRoutes(r *gin.Engine, cfg *config.Config) {
 	protected := r.Group("/api")
-	protected.Use(middlewares.JWTAuthMiddleware(cfg))
+	protected.Use(middlewares.AuthMiddleware(cfg), middlewares.RequireScope("swaps"))
+	verified := middlewares.RequireVerifiedEmail(cfg)
 	{
-		protected.POST("/swap-request", handlers.CreateSwapRequestHandler)
+		protected.POST("/swap-request", verified, func(c *gin.Context) { handlers.CreateSwapRequestHandler(c, cfg) })
+		protected.DELETE("/swap-request/:requestId", handlers.CancelSwapRequestHandler)
+		protected.POST("/swap-request/:requestId/counter", verified, func(c *gin.Context) { handlers.CounterSwapRequestHandler(c, cfg) })
+		protected.POST("/swap-request/:requestId/reverse", func(c *gin.Context) { handlers.ReverseSwapRequestHandler(c, cfg) })
 		protected.GET("/swap-requests/incoming", handlers.GetIncomingSwapRequestsHandler)
 		protected.GET("/swap-requests/outgoing", handlers.GetOutgoingSwapRequestsHandler)
+		protected.GET("/swap-requests/:requestId/timeline", handlers.GetSwapRequestTimelineHandler)
 		protected.POST("/swap-response/:requestId", handlers.RespondToSwapRequestHandler)
 	}
 }
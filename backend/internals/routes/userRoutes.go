@@ -4,19 +4,45 @@ import (
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/handlers"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/api/middlewares"
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/loginguard"
+	"github.com/amarjeet-choudhary666/slotSwapper/pkg/utils/mailer"
 	"github.com/gin-gonic/gin"
 )
 
-func UserRoutes(r *gin.Engine, cfg *config.Config) {
+func UserRoutes(r *gin.Engine, cfg *config.Config, m mailer.Mailer, guard *loginguard.Guard) {
 	userGroup := r.Group("/api/users")
 	{
-		userGroup.POST("/signup", handlers.RegisterUserHandler)
-		userGroup.POST("/signin", func(c *gin.Context) { handlers.SignInUserHandler(c, cfg) })
+		userGroup.POST("/signup", func(c *gin.Context) { handlers.RegisterUserHandler(c, cfg, m) })
+		userGroup.POST("/signin", func(c *gin.Context) { handlers.SignInUserHandler(c, cfg, m, guard) })
+		userGroup.POST("/signin/mfa", func(c *gin.Context) { handlers.MFASignInHandler(c, cfg, m, guard) })
+		userGroup.POST("/refresh", func(c *gin.Context) { handlers.RefreshTokenHandler(c, cfg) })
+		userGroup.POST("/password/forgot", func(c *gin.Context) { handlers.ForgotPasswordHandler(c, cfg, m) })
+		userGroup.POST("/password/reset", handlers.ResetPasswordHandler)
+		userGroup.GET("/verify", handlers.VerifyEmailHandler)
+		userGroup.POST("/unlock", func(c *gin.Context) { handlers.UnlockAccountHandler(c, guard) })
 	}
 
 	protected := r.Group("/api")
 	protected.Use(middlewares.JWTAuthMiddleware(cfg))
 	{
 		protected.GET("/users/profile", handlers.GetUserProfileHandler)
+		protected.PATCH("/users/profile", func(c *gin.Context) { handlers.UpdateProfileHandler(c, cfg, m) })
+		protected.POST("/users/password", handlers.ChangePasswordHandler)
+		protected.DELETE("/users/me", handlers.DeleteAccountHandler)
+		protected.PUT("/users/conflict-policy", handlers.UpdateConflictPolicyHandler)
+		protected.POST("/users/logout", handlers.LogoutHandler)
+		protected.POST("/users/mfa/enroll", func(c *gin.Context) { handlers.BeginMFAEnrollmentHandler(c, cfg) })
+		protected.POST("/users/mfa/enroll/confirm", handlers.ConfirmMFAEnrollmentHandler)
+		protected.POST("/users/mfa/disable", handlers.DisableMFAHandler)
+		protected.POST("/users/mfa/recovery-codes", handlers.RegenerateRecoveryCodesHandler)
+		protected.POST("/users/verify/resend", func(c *gin.Context) { handlers.ResendVerificationHandler(c, cfg, m) })
+		protected.GET("/users/sessions", handlers.GetSessionsHandler)
+		protected.DELETE("/users/sessions/:id", handlers.RevokeSessionHandler)
+		protected.POST("/users/api-keys", handlers.CreateAPIKeyHandler)
+		protected.GET("/users/api-keys", handlers.GetAPIKeysHandler)
+		protected.DELETE("/users/api-keys/:id", handlers.RevokeAPIKeyHandler)
+		protected.POST("/users/calendar-feed", handlers.CreateCalendarFeedHandler)
+		protected.GET("/users/calendar-feed", handlers.GetCalendarFeedHandler)
+		protected.DELETE("/users/calendar-feed", handlers.RevokeCalendarFeedHandler)
 	}
 }
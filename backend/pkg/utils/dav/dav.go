@@ -0,0 +1,278 @@
+// Package dav reads the WebDAV and CalDAV request bodies the CalDAV endpoint
+// understands (PROPFIND, calendar-query and calendar-multiget) and writes
+// multistatus responses.
+package dav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	NSDAV            = "DAV:"
+	NSCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NSCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// prefixes are the namespace prefixes used in responses.
+var prefixes = map[string]string{
+	NSDAV:            "d",
+	NSCalDAV:         "c",
+	NSCalendarServer: "cs",
+}
+
+func Name(space, local string) xml.Name {
+	return xml.Name{Space: space, Local: local}
+}
+
+// Propfind is a PROPFIND body. An empty body asks for all properties.
+type Propfind struct {
+	AllProp  bool
+	PropName bool
+	Props    []xml.Name
+}
+
+// ParsePropfind reads a PROPFIND body.
+func ParsePropfind(r io.Reader) (*Propfind, error) {
+	root, err := parseTree(r)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return &Propfind{AllProp: true}, nil
+	}
+	if root.name != Name(NSDAV, "propfind") {
+		return nil, fmt.Errorf("expected DAV:propfind, got %s", root.name.Local)
+	}
+
+	propfind := &Propfind{}
+	for _, child := range root.children {
+		switch child.name {
+		case Name(NSDAV, "allprop"):
+			propfind.AllProp = true
+		case Name(NSDAV, "propname"):
+			propfind.PropName = true
+		case Name(NSDAV, "prop"):
+			propfind.Props = child.childNames()
+		}
+	}
+	if !propfind.AllProp && !propfind.PropName && propfind.Props == nil {
+		return nil, errors.New("propfind names no properties")
+	}
+	return propfind, nil
+}
+
+// Report is a REPORT body. Name is the report asked for; Hrefs are set for
+// calendar-multiget and Start and End for a calendar-query time-range, where
+// a zero value leaves that side open.
+type Report struct {
+	Name  xml.Name
+	Props []xml.Name
+	Hrefs []string
+	Start time.Time
+	End   time.Time
+}
+
+// ParseReport reads a REPORT body.
+func ParseReport(r io.Reader) (*Report, error) {
+	root, err := parseTree(r)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, errors.New("report body is empty")
+	}
+
+	report := &Report{Name: root.name}
+	for _, child := range root.children {
+		switch child.name {
+		case Name(NSDAV, "prop"):
+			report.Props = child.childNames()
+		case Name(NSDAV, "href"):
+			report.Hrefs = append(report.Hrefs, child.text)
+		case Name(NSCalDAV, "filter"):
+			if timeRange := child.find(Name(NSCalDAV, "time-range")); timeRange != nil {
+				if report.Start, err = parseRangeTime(timeRange.attrs["start"]); err != nil {
+					return nil, err
+				}
+				if report.End, err = parseRangeTime(timeRange.attrs["end"]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+func parseRangeTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("20060102T150405Z", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time-range %q is not a UTC date-time", value)
+	}
+	return t, nil
+}
+
+// element is a parsed XML element; only what the requests above need is
+// kept.
+type element struct {
+	name     xml.Name
+	attrs    map[string]string
+	text     string
+	children []*element
+}
+
+func (e *element) childNames() []xml.Name {
+	names := make([]xml.Name, len(e.children))
+	for i, child := range e.children {
+		names[i] = child.name
+	}
+	return names
+}
+
+func (e *element) find(name xml.Name) *element {
+	for _, child := range e.children {
+		if child.name == name {
+			return child
+		}
+		if found := child.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// parseTree reads an XML document into elements. An empty body gives nil.
+func parseTree(r io.Reader) (*element, error) {
+	decoder := xml.NewDecoder(r)
+	var root *element
+	var stack []*element
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("malformed XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			e := &element{name: t.Name, attrs: map[string]string{}}
+			for _, attr := range t.Attr {
+				e.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(bytes.TrimSpace(t))
+			}
+		}
+	}
+	return root, nil
+}
+
+// Prop is a property value. Text is escaped when written; XML is written as
+// is and must already be well-formed, using the d, c and cs prefixes.
+type Prop struct {
+	Name xml.Name
+	Text string
+	XML  string
+}
+
+// Response is one resource in a multistatus. Status, when set, answers for
+// the whole resource, such as 404 for an unknown href in a multiget.
+type Response struct {
+	Href     string
+	Status   int
+	Found    []Prop
+	NotFound []xml.Name
+}
+
+// Multistatus renders a 207 Multi-Status body.
+func Multistatus(responses []Response) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`)
+	for _, response := range responses {
+		b.WriteString("<d:response><d:href>")
+		xml.EscapeText(&b, []byte(response.Href))
+		b.WriteString("</d:href>")
+		if response.Status != 0 {
+			writeStatus(&b, response.Status)
+		}
+		if len(response.Found) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, prop := range response.Found {
+				openTag, closeTag := tag(prop.Name, 0)
+				b.WriteString(openTag)
+				if prop.XML != "" {
+					b.WriteString(prop.XML)
+				} else {
+					xml.EscapeText(&b, []byte(prop.Text))
+				}
+				b.WriteString(closeTag)
+			}
+			b.WriteString("</d:prop>")
+			writeStatus(&b, http.StatusOK)
+			b.WriteString("</d:propstat>")
+		}
+		if len(response.NotFound) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for i, name := range response.NotFound {
+				b.WriteString(emptyTag(name, i))
+			}
+			b.WriteString("</d:prop>")
+			writeStatus(&b, http.StatusNotFound)
+			b.WriteString("</d:propstat>")
+		}
+		b.WriteString("</d:response>")
+	}
+	b.WriteString("</d:multistatus>\n")
+	return b.Bytes()
+}
+
+// Error renders a DAV:error body naming the precondition that failed.
+func Error(condition xml.Name) []byte {
+	return []byte(`<?xml version="1.0" encoding="utf-8"?>` + "\n" +
+		`<d:error xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">` + emptyTag(condition, 0) + "</d:error>\n")
+}
+
+// tag returns the opening and closing tags for name. Names outside the known
+// namespaces get a prefix of their own, numbered by n.
+func tag(name xml.Name, n int) (string, string) {
+	if prefix, ok := prefixes[name.Space]; ok {
+		return "<" + prefix + ":" + name.Local + ">", "</" + prefix + ":" + name.Local + ">"
+	}
+	if name.Space == "" {
+		return "<" + name.Local + ">", "</" + name.Local + ">"
+	}
+	var space bytes.Buffer
+	xml.EscapeText(&space, []byte(name.Space))
+	prefix := fmt.Sprintf("x%d", n)
+	return "<" + prefix + ":" + name.Local + ` xmlns:` + prefix + `="` + space.String() + `">`, "</" + prefix + ":" + name.Local + ">"
+}
+
+func emptyTag(name xml.Name, n int) string {
+	openTag, _ := tag(name, n)
+	return openTag[:len(openTag)-1] + "/>"
+}
+
+func writeStatus(b *bytes.Buffer, status int) {
+	fmt.Fprintf(b, "<d:status>HTTP/1.1 %d %s</d:status>", status, http.StatusText(status))
+}
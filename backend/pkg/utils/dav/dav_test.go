@@ -0,0 +1,109 @@
+package dav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePropfind(t *testing.T) {
+	t.Run("Empty Body Means Allprop", func(t *testing.T) {
+		propfind, err := ParsePropfind(strings.NewReader(""))
+		require.NoError(t, err)
+		assert.True(t, propfind.AllProp)
+	})
+
+	t.Run("Named Properties", func(t *testing.T) {
+		propfind, err := ParsePropfind(strings.NewReader(`<?xml version="1.0"?>
+<A:propfind xmlns:A="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav" xmlns:X="http://example.com/ns">
+  <A:prop><A:getetag/><C:calendar-home-set/><X:color/></A:prop>
+</A:propfind>`))
+		require.NoError(t, err)
+		assert.Equal(t, []xml.Name{
+			Name(NSDAV, "getetag"),
+			Name(NSCalDAV, "calendar-home-set"),
+			Name("http://example.com/ns", "color"),
+		}, propfind.Props)
+	})
+
+	t.Run("Rejected Bodies", func(t *testing.T) {
+		for _, body := range []string{`<d:propfind xmlns:d="DAV:"/>`, `<d:other xmlns:d="DAV:"/>`, `<d:propfind xmlns:d="DAV:">`} {
+			_, err := ParsePropfind(strings.NewReader(body))
+			assert.Error(t, err, body)
+		}
+	})
+}
+
+func TestParseReport(t *testing.T) {
+	t.Run("Calendar Query With Time Range", func(t *testing.T) {
+		report, err := ParseReport(strings.NewReader(`<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT">
+    <c:time-range start="20250601T000000Z" end="20250701T000000Z"/>
+  </c:comp-filter></c:comp-filter></c:filter>
+</c:calendar-query>`))
+		require.NoError(t, err)
+		assert.Equal(t, Name(NSCalDAV, "calendar-query"), report.Name)
+		assert.Len(t, report.Props, 2)
+		assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), report.Start)
+		assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), report.End)
+	})
+
+	t.Run("Multiget Hrefs", func(t *testing.T) {
+		report, err := ParseReport(strings.NewReader(`<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/></d:prop>
+  <d:href> /dav/calendars/events/a.ics </d:href>
+  <d:href>/dav/calendars/events/b.ics</d:href>
+</c:calendar-multiget>`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/dav/calendars/events/a.ics", "/dav/calendars/events/b.ics"}, report.Hrefs)
+		assert.True(t, report.Start.IsZero())
+	})
+
+	t.Run("Bad Time Range", func(t *testing.T) {
+		_, err := ParseReport(strings.NewReader(`<c:calendar-query xmlns:c="urn:ietf:params:xml:ns:caldav"><c:filter><c:time-range start="2025-06-01"/></c:filter></c:calendar-query>`))
+		assert.Error(t, err)
+	})
+}
+
+func TestMultistatus(t *testing.T) {
+	out := Multistatus([]Response{
+		{
+			Href: "/dav/calendars/events/a&b.ics",
+			Found: []Prop{
+				{Name: Name(NSDAV, "getetag"), Text: `"abc"`},
+				{Name: Name(NSDAV, "resourcetype"), XML: "<d:collection/><c:calendar/>"},
+			},
+			NotFound: []xml.Name{Name("http://example.com/ns", "color")},
+		},
+		{Href: "/dav/calendars/events/missing.ics", Status: http.StatusNotFound},
+	})
+
+	// The body must be well-formed with every namespace declared.
+	decoder := xml.NewDecoder(bytes.NewReader(out))
+	var names []xml.Name
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			assert.Equal(t, "EOF", err.Error())
+			break
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			names = append(names, start.Name)
+		}
+	}
+	assert.Contains(t, names, Name(NSCalDAV, "calendar"))
+	assert.Contains(t, names, Name("http://example.com/ns", "color"))
+
+	body := string(out)
+	assert.Contains(t, body, "<d:href>/dav/calendars/events/a&amp;b.ics</d:href>")
+	assert.Contains(t, body, "<d:getetag>&#34;abc&#34;</d:getetag>")
+	assert.Contains(t, body, "<d:status>HTTP/1.1 404 Not Found</d:status></d:propstat>")
+	assert.Contains(t, body, "<d:href>/dav/calendars/events/missing.ics</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>")
+}
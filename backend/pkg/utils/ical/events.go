@@ -0,0 +1,221 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// windowsZones maps the Windows time zone names Outlook and Exchange write
+// as TZID to IANA zones.
+var windowsZones = map[string]string{
+	"UTC":                            "UTC",
+	"Greenwich Standard Time":        "Atlantic/Reykjavik",
+	"GMT Standard Time":              "Europe/London",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"Central European Standard Time": "Europe/Warsaw",
+	"E. Europe Standard Time":        "Europe/Chisinau",
+	"FLE Standard Time":              "Europe/Kiev",
+	"GTB Standard Time":              "Europe/Bucharest",
+	"Russian Standard Time":          "Europe/Moscow",
+	"Eastern Standard Time":          "America/New_York",
+	"Central Standard Time":          "America/Chicago",
+	"Mountain Standard Time":         "America/Denver",
+	"US Mountain Standard Time":      "America/Phoenix",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"Alaskan Standard Time":          "America/Anchorage",
+	"Hawaiian Standard Time":         "Pacific/Honolulu",
+	"Atlantic Standard Time":         "America/Halifax",
+	"E. South America Standard Time": "America/Sao_Paulo",
+	"India Standard Time":            "Asia/Kolkata",
+	"Arabian Standard Time":          "Asia/Dubai",
+	"China Standard Time":            "Asia/Shanghai",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Korea Standard Time":            "Asia/Seoul",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"New Zealand Standard Time":      "Pacific/Auckland",
+	"South Africa Standard Time":     "Africa/Johannesburg",
+}
+
+// LoadZone resolves a TZID: an IANA name, a Windows zone name, or an IANA
+// name behind a vendor prefix such as "/citadel.org/20190914_1/Europe/Berlin".
+func LoadZone(tzid string) (*time.Location, error) {
+	tzid = strings.Trim(strings.TrimSpace(tzid), `"`)
+	if loc, err := time.LoadLocation(tzid); err == nil && tzid != "" && tzid != "Local" {
+		return loc, nil
+	}
+	if name, ok := windowsZones[tzid]; ok {
+		return time.LoadLocation(name)
+	}
+	if parts := strings.Split(strings.Trim(tzid, "/"), "/"); len(parts) > 2 {
+		for i := len(parts) - 3; i < len(parts)-1; i++ {
+			if i < 0 {
+				continue
+			}
+			if loc, err := time.LoadLocation(strings.Join(parts[i:], "/")); err == nil {
+				return loc, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown time zone %q", tzid)
+}
+
+// VEvent is a VEVENT with its times resolved to instants.
+type VEvent struct {
+	UID          string
+	Summary      string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time
+	Status       string
+	Sequence     int
+	// Zone is the time zone DTSTART was given in, or nil for UTC.
+	Zone *time.Location
+	// Err explains why the event could not be read. The other fields hold
+	// whatever was read before it.
+	Err error
+}
+
+// Events decodes every VEVENT in cal. Times with a TZID are read in that
+// zone and floating times in the calendar's X-WR-TIMEZONE, or defaultLoc
+// when it has none.
+func Events(cal *Component, defaultLoc *time.Location) []VEvent {
+	if defaultLoc == nil {
+		defaultLoc = time.UTC
+	}
+	if tzid := cal.Value("X-WR-TIMEZONE"); tzid != "" {
+		if loc, err := LoadZone(tzid); err == nil {
+			defaultLoc = loc
+		}
+	}
+
+	var events []VEvent
+	for _, child := range cal.Children {
+		if child.Name != "VEVENT" {
+			continue
+		}
+		event := VEvent{
+			UID:     strings.TrimSpace(child.Value("UID")),
+			Summary: strings.TrimSpace(UnescapeText(child.Value("SUMMARY"))),
+			Status:  strings.ToUpper(strings.TrimSpace(child.Value("STATUS"))),
+		}
+		event.Err = decodeEvent(child, &event, defaultLoc)
+		events = append(events, event)
+	}
+	return events
+}
+
+func decodeEvent(c *Component, event *VEvent, defaultLoc *time.Location) error {
+	if event.UID == "" {
+		return errors.New("UID is missing")
+	}
+	if seq := c.Value("SEQUENCE"); seq != "" {
+		event.Sequence, _ = strconv.Atoi(strings.TrimSpace(seq))
+	}
+
+	dtstart := c.Get("DTSTART")
+	if dtstart == nil {
+		return errors.New("DTSTART is missing")
+	}
+	start, allDay, loc, err := parseDateTime(dtstart, strings.TrimSpace(dtstart.Value), defaultLoc)
+	if err != nil {
+		return fmt.Errorf("DTSTART: %w", err)
+	}
+	event.Start, event.AllDay = start, allDay
+	if loc != time.UTC {
+		event.Zone = loc
+	}
+
+	switch {
+	case c.Get("DTEND") != nil:
+		dtend := c.Get("DTEND")
+		end, _, _, err := parseDateTime(dtend, strings.TrimSpace(dtend.Value), defaultLoc)
+		if err != nil {
+			return fmt.Errorf("DTEND: %w", err)
+		}
+		event.End = end
+	case c.Get("DURATION") != nil:
+		duration, err := ParseDuration(c.Value("DURATION"))
+		if err != nil {
+			return fmt.Errorf("DURATION: %w", err)
+		}
+		event.End = start.Add(duration)
+	case allDay:
+		event.End = start.AddDate(0, 0, 1)
+	default:
+		event.End = start
+	}
+
+	if rrules := c.All("RRULE"); len(rrules) > 1 {
+		return errors.New("only one RRULE is supported")
+	} else if len(rrules) == 1 {
+		event.RRule = strings.TrimSpace(rrules[0].Value)
+	}
+	if len(c.All("RDATE")) > 0 {
+		return errors.New("RDATE is not supported")
+	}
+
+	for _, exdate := range c.All("EXDATE") {
+		for _, value := range strings.Split(exdate.Value, ",") {
+			t, _, _, err := parseDateTime(&exdate, strings.TrimSpace(value), defaultLoc)
+			if err != nil {
+				return fmt.Errorf("EXDATE: %w", err)
+			}
+			if allDay && len(strings.TrimSpace(value)) == 8 {
+				// Date-only exceptions skip the occurrence on that day.
+				t = time.Date(t.Year(), t.Month(), t.Day(), start.Hour(), start.Minute(), start.Second(), 0, t.Location())
+			}
+			event.ExDates = append(event.ExDates, t)
+		}
+	}
+
+	if recurrenceID := c.Get("RECURRENCE-ID"); recurrenceID != nil {
+		t, _, _, err := parseDateTime(recurrenceID, strings.TrimSpace(recurrenceID.Value), defaultLoc)
+		if err != nil {
+			return fmt.Errorf("RECURRENCE-ID: %w", err)
+		}
+		event.RecurrenceID = &t
+	}
+	return nil
+}
+
+// parseDateTime reads one DATE or DATE-TIME value of line and returns it with
+// whether it was a date and the zone it was read in.
+func parseDateTime(line *ContentLine, value string, defaultLoc *time.Location) (time.Time, bool, *time.Location, error) {
+	loc := defaultLoc
+	if tzid, ok := line.Params["TZID"]; ok {
+		zone, err := LoadZone(tzid)
+		if err != nil {
+			return time.Time{}, false, nil, err
+		}
+		loc = zone
+	}
+
+	if strings.EqualFold(line.Params["VALUE"], "DATE") || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, false, nil, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, loc, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.ParseInLocation("20060102T150405Z", value, time.UTC)
+		if err != nil {
+			return time.Time{}, false, nil, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, time.UTC, nil
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, nil, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, false, loc, nil
+}
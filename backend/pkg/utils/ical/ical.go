@@ -0,0 +1,202 @@
+// Package ical reads and writes RFC 5545 calendars: a VCALENDAR holding
+// VEVENTs with escaped text and folded lines.
+package ical
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+
+	MethodPublish = "PUBLISH"
+
+	dateTimeUTC = "20060102T150405Z"
+	date        = "20060102"
+	// maxLineOctets is the longest a content line may be before folding.
+	maxLineOctets = 75
+)
+
+// Property is an extra property written as is, such as an X- property.
+type Property struct {
+	Name  string
+	Value string
+}
+
+// Event is one VEVENT. Times are written in UTC. An all-day event is
+// written with the dates of Start and End in UTC, End being the day after
+// the last.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Status       string
+	Transparent  bool
+	Sequence     int
+	Created      time.Time
+	LastModified time.Time
+	Categories   []string
+	Extra        []Property
+}
+
+// Calendar is a VCALENDAR. Method is left out when empty, as CalDAV
+// resources require. RefreshInterval, when set, tells subscribing clients
+// how often to poll.
+type Calendar struct {
+	ProdID          string
+	Method          string
+	Name            string
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Marshal renders the calendar with CRLF line endings. stamp is written as
+// every event's DTSTAMP.
+func (c *Calendar) Marshal(stamp time.Time) []byte {
+	w := &writer{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", c.ProdID)
+	w.line("CALSCALE", "GREGORIAN")
+	if c.Method != "" {
+		w.line("METHOD", c.Method)
+	}
+	if c.Name != "" {
+		w.line("X-WR-CALNAME", EscapeText(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		w.line("REFRESH-INTERVAL;VALUE=DURATION", formatDuration(c.RefreshInterval))
+		w.line("X-PUBLISHED-TTL", formatDuration(c.RefreshInterval))
+	}
+
+	for i := range c.Events {
+		e := &c.Events[i]
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", EscapeText(e.UID))
+		w.line("DTSTAMP", FormatTime(stamp))
+		if e.AllDay {
+			w.line("DTSTART;VALUE=DATE", FormatDate(e.Start))
+			w.line("DTEND;VALUE=DATE", FormatDate(e.End))
+		} else {
+			w.line("DTSTART", FormatTime(e.Start))
+			w.line("DTEND", FormatTime(e.End))
+		}
+		w.line("SUMMARY", EscapeText(e.Summary))
+		if e.Description != "" {
+			w.line("DESCRIPTION", EscapeText(e.Description))
+		}
+		if e.Status != "" {
+			w.line("STATUS", e.Status)
+		}
+		if e.Transparent {
+			w.line("TRANSP", "TRANSPARENT")
+		} else {
+			w.line("TRANSP", "OPAQUE")
+		}
+		w.line("SEQUENCE", strconv.Itoa(e.Sequence))
+		if !e.Created.IsZero() {
+			w.line("CREATED", FormatTime(e.Created))
+		}
+		if !e.LastModified.IsZero() {
+			w.line("LAST-MODIFIED", FormatTime(e.LastModified))
+		}
+		if len(e.Categories) > 0 {
+			escaped := make([]string, len(e.Categories))
+			for i, category := range e.Categories {
+				escaped[i] = EscapeText(category)
+			}
+			w.line("CATEGORIES", strings.Join(escaped, ","))
+		}
+		for _, prop := range e.Extra {
+			w.line(prop.Name, prop.Value)
+		}
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.buf.Bytes()
+}
+
+// FormatTime formats t as a UTC date-time.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeUTC)
+}
+
+// FormatDate formats the UTC date of t as a DATE.
+func FormatDate(t time.Time) string {
+	return t.UTC().Format(date)
+}
+
+// EscapeText escapes a TEXT value.
+func EscapeText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', ';', ',':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// formatDuration writes d as a DURATION value in whole seconds or larger.
+func formatDuration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	if seconds%86400 == 0 {
+		return "P" + strconv.FormatInt(seconds/86400, 10) + "D"
+	}
+	out := "PT"
+	if h := seconds / 3600; h > 0 {
+		out += strconv.FormatInt(h, 10) + "H"
+	}
+	if m := seconds % 3600 / 60; m > 0 {
+		out += strconv.FormatInt(m, 10) + "M"
+	}
+	if s := seconds % 60; s > 0 || out == "PT" {
+		out += strconv.FormatInt(s, 10) + "S"
+	}
+	return out
+}
+
+type writer struct {
+	buf bytes.Buffer
+}
+
+// line writes "name:value" folded at 75 octets without splitting a UTF-8
+// character.
+func (w *writer) line(name, value string) {
+	content := name + ":" + value
+	width := 0
+	for len(content) > 0 {
+		limit := maxLineOctets - width
+		if len(content) <= limit {
+			w.buf.WriteString(content)
+			break
+		}
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		w.buf.WriteString(content[:cut])
+		w.buf.WriteString("\r\n ")
+		content = content[cut:]
+		// Continuation lines start with the folding space.
+		width = 1
+	}
+	w.buf.WriteString("\r\n")
+}
@@ -0,0 +1,76 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	cal := &Calendar{
+		ProdID:          "-//SlotSwapper//Calendar//EN",
+		Method:          MethodPublish,
+		Name:            "Sam's slots",
+		RefreshInterval: time.Hour,
+		Events: []Event{{
+			UID:      "event-7@slotswapper",
+			Summary:  "On-call; primary, week 23",
+			Start:    start,
+			End:      start.Add(time.Hour),
+			Status:   StatusTentative,
+			Sequence: 2,
+			Extra:    []Property{{Name: "X-SLOTSWAPPER-STATUS", Value: "SWAP_PENDING"}},
+		}},
+	}
+
+	out := string(cal.Marshal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, out, "METHOD:PUBLISH\r\n")
+	assert.Contains(t, out, "DTSTART:20250602T070000Z\r\n")
+	assert.Contains(t, out, "SUMMARY:On-call\\; primary\\, week 23\r\n")
+	assert.Contains(t, out, "STATUS:TENTATIVE\r\nTRANSP:OPAQUE\r\nSEQUENCE:2\r\n")
+	assert.Contains(t, out, "REFRESH-INTERVAL;VALUE=DURATION:PT1H\r\n")
+	assert.Contains(t, out, "X-SLOTSWAPPER-STATUS:SWAP_PENDING\r\n")
+}
+
+func TestMarshalAllDay(t *testing.T) {
+	day := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	cal := &Calendar{ProdID: "-//SlotSwapper//Calendar//EN", Events: []Event{{
+		UID: "offsite", Summary: "Offsite", Start: day, End: day.AddDate(0, 0, 2), AllDay: true,
+	}}}
+
+	out := string(cal.Marshal(day))
+
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20250602\r\nDTEND;VALUE=DATE:20250604\r\n")
+}
+
+func TestLineFolding(t *testing.T) {
+	w := &writer{}
+	w.line("DESCRIPTION", strings.Repeat("é", 80))
+
+	lines := strings.Split(strings.TrimSuffix(w.buf.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line, "line %d splits a character", i)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `a\\b\;c\,d\ne`, EscapeText("a\\b;c,d\r\ne"))
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "PT1H", formatDuration(time.Hour))
+	assert.Equal(t, "PT1H30M", formatDuration(90*time.Minute))
+	assert.Equal(t, "P1D", formatDuration(24*time.Hour))
+	assert.Equal(t, "PT45S", formatDuration(45*time.Second))
+}
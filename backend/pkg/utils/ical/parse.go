@@ -0,0 +1,250 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxContentLine bounds one unfolded content line, so a malformed file
+// cannot grow a line without limit.
+const maxContentLine = 1 << 20
+
+// ContentLine is one property: NAME;PARAM=value:VALUE. Names and parameter
+// names are upper-cased; the value is kept as written.
+type ContentLine struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block such as VCALENDAR or VEVENT.
+type Component struct {
+	Name       string
+	Properties []ContentLine
+	Children   []*Component
+}
+
+// Get returns the first property called name, or nil.
+func (c *Component) Get(name string) *ContentLine {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// All returns every property called name.
+func (c *Component) All(name string) []ContentLine {
+	var lines []ContentLine
+	for _, line := range c.Properties {
+		if line.Name == name {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Value returns the value of the first property called name, or "".
+func (c *Component) Value(name string) string {
+	if line := c.Get(name); line != nil {
+		return line.Value
+	}
+	return ""
+}
+
+// Parse reads one VCALENDAR. Folded lines are joined and both CRLF and bare
+// LF line endings are accepted.
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for n, raw := range lines {
+		line, err := parseContentLine(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		switch line.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(line.Value)}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("line %d: only one VCALENDAR is allowed", n+1)
+				}
+				if component.Name != "VCALENDAR" {
+					return nil, errors.New("not an iCalendar file")
+				}
+				root = component
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, component)
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(line.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", n+1, line.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside VCALENDAR", n+1)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, line)
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("not an iCalendar file")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%s is not closed", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxContentLine)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseContentLine splits a line into its name, parameters and value.
+// Parameter values may be quoted, and the value starts at the first colon
+// outside quotes.
+func parseContentLine(raw string) (ContentLine, error) {
+	line := ContentLine{Params: map[string]string{}}
+
+	inQuotes := false
+	start := 0
+	var paramName string
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case c == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '=' && line.Name != "" && paramName == "":
+			paramName = strings.ToUpper(raw[start:i])
+			start = i + 1
+		case c == ';' || c == ':':
+			part := raw[start:i]
+			if line.Name == "" {
+				line.Name = strings.ToUpper(part)
+			} else if paramName != "" {
+				line.Params[paramName] = strings.Trim(part, `"`)
+				paramName = ""
+			}
+			start = i + 1
+			if c == ':' {
+				line.Value = raw[i+1:]
+				if line.Name == "" {
+					return ContentLine{}, errors.New("missing property name")
+				}
+				return line, nil
+			}
+		}
+	}
+	return ContentLine{}, fmt.Errorf("malformed content line %q", truncate(raw, 40))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// UnescapeText reverses EscapeText.
+func UnescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// ParseDuration reads a DURATION value such as PT1H30M, P1D or P1W.
+func ParseDuration(s string) (time.Duration, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign, value = -1, value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	value = value[1:]
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+			continue
+		case c == 'T' && number == "" && !inTime:
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		number = ""
+		unit := map[bool]map[rune]time.Duration{
+			false: {'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour},
+			true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+		}[inTime][c]
+		if unit == 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		total += time.Duration(n) * unit
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return sign * total, nil
+}
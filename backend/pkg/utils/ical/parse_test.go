@@ -0,0 +1,127 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Example//EN\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:Europe/Berlin\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"SUMMARY:Team standup\\, daily\r\n" +
+	"DTSTART;TZID=\"Europe/Berlin\":20250602T090000\r\n" +
+	"DURATION:PT15M\r\n" +
+	"RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR\r\n" +
+	"EXDATE;TZID=Europe/Berlin:20250603T090000,20250604T090000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"RECURRENCE-ID;TZID=Europe/Berlin:20250605T090000\r\n" +
+	"SUMMARY:Standup (moved)\r\n" +
+	"DTSTART:20250605T080000Z\r\n" +
+	"DTEND:20250605T081500Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:offsite@example.com\r\n" +
+	"SUMMARY:Offsite with a very long description that is folded over more than\r\n" +
+	"  one line\r\n" +
+	"DTSTART;VALUE=DATE:20250610\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:No UID\r\n" +
+	"DTSTART:20250610T100000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseAndEvents(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	cal, err := Parse(strings.NewReader(sampleCalendar))
+	require.NoError(t, err)
+	events := Events(cal, nil)
+	require.Len(t, events, 4)
+
+	standup := events[0]
+	require.NoError(t, standup.Err)
+	assert.Equal(t, "Team standup, daily", standup.Summary)
+	assert.Equal(t, time.Date(2025, 6, 2, 7, 0, 0, 0, time.UTC), standup.Start.UTC())
+	assert.Equal(t, 15*time.Minute, standup.End.Sub(standup.Start))
+	assert.Equal(t, berlin.String(), standup.Zone.String())
+	assert.Equal(t, "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", standup.RRule)
+	assert.Len(t, standup.ExDates, 2)
+
+	moved := events[1]
+	require.NoError(t, moved.Err)
+	require.NotNil(t, moved.RecurrenceID)
+	assert.Equal(t, time.Date(2025, 6, 5, 7, 0, 0, 0, time.UTC), moved.RecurrenceID.UTC())
+
+	offsite := events[2]
+	require.NoError(t, offsite.Err)
+	assert.True(t, offsite.AllDay)
+	assert.Equal(t, "Offsite with a very long description that is folded over more than one line", offsite.Summary)
+	assert.Equal(t, 24*time.Hour, offsite.End.Sub(offsite.Start))
+
+	assert.EqualError(t, events[3].Err, "UID is missing")
+}
+
+func TestParseRejectsBrokenFiles(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"BEGIN:VEVENT\r\nEND:VEVENT\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nnot a content line\r\nEND:VCALENDAR\r\n",
+	} {
+		_, err := Parse(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestLoadZone(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Berlin"); err != nil {
+		t.Skip("time zone data not available")
+	}
+	for _, tzid := range []string{"Europe/Berlin", "W. Europe Standard Time", "/citadel.org/20190914_1/Europe/Berlin"} {
+		loc, err := LoadZone(tzid)
+		require.NoError(t, err, tzid)
+		assert.Equal(t, "Europe/Berlin", loc.String(), tzid)
+	}
+	_, err := LoadZone("Middle Earth Standard Time")
+	assert.Error(t, err)
+}
+
+func TestParseDuration(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"PT15M":    15 * time.Minute,
+		"PT1H30M":  90 * time.Minute,
+		"P1D":      24 * time.Hour,
+		"P1DT2H":   26 * time.Hour,
+		"P2W":      14 * 24 * time.Hour,
+		"-PT5M":    -5 * time.Minute,
+		"PT0S":     0,
+		"+PT1H10S": time.Hour + 10*time.Second,
+	} {
+		got, err := ParseDuration(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"", "P", "PT", "1H", "PT1X", "PT1"} {
+		_, err := ParseDuration(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	assert.Equal(t, "a\\b;c,d\ne", UnescapeText(EscapeText("a\\b;c,d\ne")))
+}
@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+)
+
+var securityLogger = log.New(os.Stdout, "SECURITY: ", log.Ldate|log.Ltime|log.LUTC)
+
+// Security records a security event, such as an account lockout, as one JSON
+// object per line so it can be filtered and alerted on.
+func Security(event string, fields map[string]interface{}) {
+	entry := make(map[string]interface{}, len(fields)+1)
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["event"] = event
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		Error("Failed to encode security event " + event + ": " + err.Error())
+		return
+	}
+	securityLogger.Println(string(line))
+}
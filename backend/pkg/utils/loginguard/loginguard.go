@@ -0,0 +1,206 @@
+package loginguard
+
+import (
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+)
+
+// Record is the failed sign-in state kept for one account or IP address.
+type Record struct {
+	Failures      int
+	LastFailureAt time.Time
+	// BlockedUntil is when the next attempt is allowed. Locked marks a
+	// lockout rather than an ordinary backoff delay.
+	BlockedUntil time.Time
+	Locked       bool
+}
+
+// Store persists records by key. Update must apply fn atomically, so
+// concurrent failures for the same key are all counted.
+type Store interface {
+	Get(key string) (Record, error)
+	Update(key string, fn func(rec *Record)) (Record, error)
+	Delete(key string) error
+}
+
+// Policy sets how failures are throttled.
+type Policy struct {
+	// FreeAttempts failures are allowed before any delay is imposed.
+	FreeAttempts int
+	// Each failure after the free ones doubles the delay, starting at
+	// BackoffBase and capped at BackoffMax.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// AccountMaxFailures and IPMaxFailures trigger a lockout of
+	// LockoutDuration.
+	AccountMaxFailures int
+	IPMaxFailures      int
+	LockoutDuration    time.Duration
+	// FailureWindow is how long a record lives without new failures.
+	FailureWindow time.Duration
+}
+
+// PolicyFromConfig reads the LOGIN_* settings.
+func PolicyFromConfig(cfg *config.Config) Policy {
+	return Policy{
+		FreeAttempts:       cfg.LOGIN_FREE_ATTEMPTS,
+		BackoffBase:        cfg.LOGIN_BACKOFF_BASE,
+		BackoffMax:         cfg.LOGIN_BACKOFF_MAX,
+		AccountMaxFailures: cfg.LOGIN_MAX_FAILURES,
+		IPMaxFailures:      cfg.LOGIN_IP_MAX_FAILURES,
+		LockoutDuration:    cfg.LOGIN_LOCKOUT_DURATION,
+		FailureWindow:      cfg.LOGIN_FAILURE_WINDOW,
+	}
+}
+
+// Decision is the outcome of checking whether a sign-in may be attempted.
+type Decision struct {
+	Allowed    bool
+	Locked     bool
+	RetryAfter time.Duration
+	// Key is the account or IP key that blocked the attempt.
+	Key string
+}
+
+// Failure is the outcome of recording a failed attempt. AccountLocked and
+// IPLocked are set only by the failure that started a lockout.
+type Failure struct {
+	AccountFailures int
+	AccountLocked   bool
+	IPLocked        bool
+	RetryAfter      time.Duration
+}
+
+// Guard throttles sign-in attempts per account and per client IP.
+type Guard struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+func New(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy, now: time.Now}
+}
+
+// AccountKey identifies the account an attempt was made for. It is derived
+// from the submitted email, so unknown addresses are throttled the same way
+// as registered ones.
+func AccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// backoff returns the delay imposed after failures consecutive failures.
+func (p Policy) backoff(failures int) time.Duration {
+	over := failures - p.FreeAttempts
+	if over <= 0 || p.BackoffBase <= 0 {
+		return 0
+	}
+	delay := p.BackoffBase
+	for i := 1; i < over; i++ {
+		delay *= 2
+		if p.BackoffMax > 0 && delay >= p.BackoffMax {
+			return p.BackoffMax
+		}
+	}
+	if p.BackoffMax > 0 && delay > p.BackoffMax {
+		return p.BackoffMax
+	}
+	return delay
+}
+
+// expired reports whether rec has gone quiet long enough to be forgotten.
+func (p Policy) expired(rec Record, now time.Time) bool {
+	if rec.Failures == 0 {
+		return true
+	}
+	return now.After(rec.BlockedUntil) && p.FailureWindow > 0 && now.Sub(rec.LastFailureAt) > p.FailureWindow
+}
+
+// Check reports whether email may attempt to sign in from ip.
+func (g *Guard) Check(email, ip string) (Decision, error) {
+	now := g.now()
+	for _, key := range []string{AccountKey(email), IPKey(ip)} {
+		rec, err := g.store.Get(key)
+		if err != nil {
+			return Decision{}, err
+		}
+		if g.policy.expired(rec, now) || !now.Before(rec.BlockedUntil) {
+			continue
+		}
+		return Decision{Locked: rec.Locked, RetryAfter: rec.BlockedUntil.Sub(now), Key: key}, nil
+	}
+	return Decision{Allowed: true}, nil
+}
+
+// fail counts one failure against key and returns the new record and whether
+// this failure started a lockout.
+func (g *Guard) fail(key string, maxFailures int, now time.Time) (Record, bool, error) {
+	lockedNow := false
+	rec, err := g.store.Update(key, func(rec *Record) {
+		if g.policy.expired(*rec, now) || (rec.Locked && !now.Before(rec.BlockedUntil)) {
+			*rec = Record{}
+		}
+
+		rec.Failures++
+		rec.LastFailureAt = now
+		if maxFailures > 0 && rec.Failures >= maxFailures {
+			lockedNow = !rec.Locked
+			rec.Locked = true
+			rec.BlockedUntil = now.Add(g.policy.LockoutDuration)
+			return
+		}
+		rec.BlockedUntil = now.Add(g.policy.backoff(rec.Failures))
+	})
+	return rec, lockedNow, err
+}
+
+// RecordFailure counts a failed attempt against both the account and the IP.
+func (g *Guard) RecordFailure(email, ip string) (Failure, error) {
+	now := g.now()
+
+	account, accountLocked, err := g.fail(AccountKey(email), g.policy.AccountMaxFailures, now)
+	if err != nil {
+		return Failure{}, err
+	}
+	addr, ipLocked, err := g.fail(IPKey(ip), g.policy.IPMaxFailures, now)
+	if err != nil {
+		return Failure{}, err
+	}
+
+	retryAfter := account.BlockedUntil.Sub(now)
+	if wait := addr.BlockedUntil.Sub(now); wait > retryAfter {
+		retryAfter = wait
+	}
+	return Failure{
+		AccountFailures: account.Failures,
+		AccountLocked:   accountLocked,
+		IPLocked:        ipLocked,
+		RetryAfter:      retryAfter,
+	}, nil
+}
+
+// RecordSuccess clears the account's failures. The IP record is left to
+// expire so an attacker cannot reset it by signing in to their own account.
+func (g *Guard) RecordSuccess(email string) error {
+	return g.store.Delete(AccountKey(email))
+}
+
+// Unlock clears an account's failures and lockout.
+func (g *Guard) Unlock(email string) error {
+	return g.store.Delete(AccountKey(email))
+}
+
+// IsLocked reports whether an account is currently locked out.
+func (g *Guard) IsLocked(email string) (bool, error) {
+	rec, err := g.store.Get(AccountKey(email))
+	if err != nil {
+		return false, err
+	}
+	return rec.Locked && g.now().Before(rec.BlockedUntil), nil
+}
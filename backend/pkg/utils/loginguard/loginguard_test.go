@@ -0,0 +1,129 @@
+package loginguard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGuard(now *time.Time) *Guard {
+	g := New(NewMemoryStore(), Policy{
+		FreeAttempts:       2,
+		BackoffBase:        time.Second,
+		BackoffMax:         10 * time.Second,
+		AccountMaxFailures: 6,
+		IPMaxFailures:      8,
+		LockoutDuration:    30 * time.Minute,
+		FailureWindow:      time.Hour,
+	})
+	g.now = func() time.Time { return *now }
+	return g
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{FreeAttempts: 2, BackoffBase: time.Second, BackoffMax: 10 * time.Second}
+	assert.Equal(t, time.Duration(0), p.backoff(2))
+	assert.Equal(t, time.Second, p.backoff(3))
+	assert.Equal(t, 2*time.Second, p.backoff(4))
+	assert.Equal(t, 8*time.Second, p.backoff(6))
+	assert.Equal(t, 10*time.Second, p.backoff(7))
+	assert.Equal(t, 10*time.Second, p.backoff(40))
+}
+
+func TestGuard(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+
+	t.Run("Free Attempts Then Backoff", func(t *testing.T) {
+		now := start
+		g := newTestGuard(&now)
+
+		for i := 0; i < 2; i++ {
+			failure, err := g.RecordFailure("a@example.com", "10.0.0.1")
+			assert.NoError(t, err)
+			assert.Zero(t, failure.RetryAfter)
+		}
+		decision, _ := g.Check("a@example.com", "10.0.0.1")
+		assert.True(t, decision.Allowed)
+
+		failure, _ := g.RecordFailure("A@example.com ", "10.0.0.1")
+		assert.Equal(t, time.Second, failure.RetryAfter)
+
+		decision, _ = g.Check("a@example.com", "10.0.0.2")
+		assert.False(t, decision.Allowed)
+		assert.False(t, decision.Locked)
+		assert.Equal(t, AccountKey("a@example.com"), decision.Key)
+
+		now = now.Add(time.Second)
+		decision, _ = g.Check("a@example.com", "10.0.0.1")
+		assert.True(t, decision.Allowed)
+	})
+
+	t.Run("Account Lockout And Unlock", func(t *testing.T) {
+		now := start
+		g := newTestGuard(&now)
+
+		var failure Failure
+		for i := 0; i < 6; i++ {
+			now = now.Add(failure.RetryAfter)
+			failure, _ = g.RecordFailure("a@example.com", "10.0.0.1")
+		}
+		assert.True(t, failure.AccountLocked)
+		assert.Equal(t, 30*time.Minute, failure.RetryAfter)
+
+		// Another IP is still refused: the lock is on the account.
+		decision, _ := g.Check("a@example.com", "10.9.9.9")
+		assert.True(t, decision.Locked)
+		locked, _ := g.IsLocked("a@example.com")
+		assert.True(t, locked)
+
+		assert.NoError(t, g.Unlock("a@example.com"))
+		decision, _ = g.Check("a@example.com", "10.9.9.9")
+		assert.True(t, decision.Allowed)
+	})
+
+	t.Run("IP Lockout Spans Accounts", func(t *testing.T) {
+		now := start
+		g := newTestGuard(&now)
+
+		var failure Failure
+		for i := 0; i < 8; i++ {
+			failure, _ = g.RecordFailure("user"+string(rune('a'+i))+"@example.com", "10.0.0.1")
+		}
+		assert.True(t, failure.IPLocked)
+		assert.False(t, failure.AccountLocked)
+
+		decision, _ := g.Check("new@example.com", "10.0.0.1")
+		assert.True(t, decision.Locked)
+		assert.Equal(t, IPKey("10.0.0.1"), decision.Key)
+	})
+
+	t.Run("Success Clears Account But Not IP", func(t *testing.T) {
+		now := start
+		g := newTestGuard(&now)
+
+		for i := 0; i < 3; i++ {
+			g.RecordFailure("a@example.com", "10.0.0.1")
+		}
+		assert.NoError(t, g.RecordSuccess("a@example.com"))
+
+		decision, _ := g.Check("a@example.com", "10.0.0.2")
+		assert.True(t, decision.Allowed)
+		decision, _ = g.Check("b@example.com", "10.0.0.1")
+		assert.False(t, decision.Allowed)
+	})
+
+	t.Run("Failures Expire", func(t *testing.T) {
+		now := start
+		g := newTestGuard(&now)
+
+		for i := 0; i < 5; i++ {
+			g.RecordFailure("a@example.com", "10.0.0.1")
+		}
+		now = now.Add(2 * time.Hour)
+
+		failure, _ := g.RecordFailure("a@example.com", "10.0.0.1")
+		assert.Equal(t, 1, failure.AccountFailures)
+		assert.Zero(t, failure.RetryAfter)
+	})
+}
@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email. Services depend on this interface so that
+// local development and tests can run without an SMTP server.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New builds the mailer selected by MAIL_DRIVER: "smtp", "file" or "memory".
+// The smtp driver needs SMTP_HOST, so a server left with the defaults fails
+// to start instead of quietly not sending mail.
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MAIL_DRIVER {
+	case "smtp":
+		if cfg.SMTP_HOST == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required by the smtp mail driver; set MAIL_DRIVER=file to write mail to disk instead")
+		}
+		return NewSMTPMailer(cfg.SMTP_HOST, cfg.SMTP_PORT, cfg.SMTP_USERNAME, cfg.SMTP_PASSWORD, cfg.MAIL_FROM), nil
+	case "file":
+		return NewFileMailer(cfg.MAIL_FILE_DIR, cfg.MAIL_FROM)
+	case "memory":
+		return NewMemoryMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.MAIL_DRIVER)
+	}
+}
+
+// formatMessage renders msg as an RFC 5322 message with CRLF line endings.
+func formatMessage(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// validateHeaders rejects header values that would let a caller inject
+// extra headers or recipients.
+func validateHeaders(msg Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("mail recipient is required")
+	}
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return fmt.Errorf("mail headers must not contain line breaks")
+	}
+	return nil
+}
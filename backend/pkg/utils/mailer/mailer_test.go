@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailers(t *testing.T) {
+	msg := Message{To: "a@example.com", Subject: "Hello", Body: "line one\nline two"}
+
+	t.Run("Memory Mailer Records Messages", func(t *testing.T) {
+		m := NewMemoryMailer()
+		assert.NoError(t, m.Send(msg))
+		assert.Equal(t, []Message{msg}, m.Messages())
+	})
+
+	t.Run("File Mailer Writes One File Per Message", func(t *testing.T) {
+		dir := t.TempDir()
+		m, err := NewFileMailer(dir, "noreply@example.com")
+		assert.NoError(t, err)
+		assert.NoError(t, m.Send(msg))
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+
+		content, err := os.ReadFile(dir + "/" + entries[0].Name())
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "From: noreply@example.com\r\nTo: a@example.com\r\nSubject: Hello\r\n"))
+		assert.Contains(t, string(content), "line one\r\nline two")
+	})
+
+	t.Run("Rejects Header Injection", func(t *testing.T) {
+		m := NewMemoryMailer()
+		assert.Error(t, m.Send(Message{To: "a@example.com\r\nBcc: b@example.com", Subject: "Hi"}))
+		assert.Error(t, m.Send(Message{To: "a@example.com", Subject: "Hi\nBcc: b@example.com"}))
+		assert.Error(t, m.Send(Message{Subject: "Hi"}))
+		assert.Empty(t, m.Messages())
+	})
+}
+
+func TestNewNeedsSMTPHost(t *testing.T) {
+	_, err := New(&config.Config{MAIL_DRIVER: "smtp"})
+	assert.Error(t, err)
+
+	m, err := New(&config.Config{MAIL_DRIVER: "smtp", SMTP_HOST: "smtp.example.com", SMTP_PORT: "587"})
+	assert.NoError(t, err)
+	assert.NotNil(t, m)
+
+	_, err = New(&config.Config{MAIL_DRIVER: "pigeon"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,334 @@
+// Package rrule parses and expands the subset of RFC 5545 recurrence rules
+// that event series support: FREQ=DAILY, WEEKLY or MONTHLY with INTERVAL,
+// COUNT, UNTIL, BYDAY and WKST. Weeks start on Monday unless WKST says
+// otherwise.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxPeriods bounds how many days, weeks or months Expand walks through, so
+// a rule that rarely matches cannot loop for ever.
+const maxPeriods = 50000
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Weekday is one BYDAY entry. N picks the Nth such weekday of the month
+// (negative counts from the end) and is only allowed with MONTHLY; zero
+// means every such weekday.
+type Weekday struct {
+	N   int
+	Day time.Weekday
+}
+
+func (w Weekday) String() string {
+	code := strings.ToUpper(w.Day.String()[:2])
+	if w.N == 0 {
+		return code
+	}
+	return strconv.Itoa(w.N) + code
+}
+
+// Rule is a parsed recurrence rule. Until, when set, is inclusive.
+type Rule struct {
+	Freq      Frequency
+	Interval  int
+	Count     int
+	Until     time.Time
+	ByDay     []Weekday
+	WeekStart time.Weekday
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10".
+// A leading "RRULE:" is accepted. UNTIL may be a UTC date-time
+// (20240131T170000Z) or a date, which includes the whole day in UTC.
+func Parse(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return Rule{}, errors.New("rule is empty")
+	}
+
+	rule := Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || name == "" || value == "" {
+			return Rule{}, fmt.Errorf("malformed rule part %q", part)
+		}
+		if seen[name] {
+			return Rule{}, fmt.Errorf("%s is given more than once", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch Frequency(value) {
+			case Daily, Weekly, Monthly:
+				rule.Freq = Frequency(value)
+			default:
+				return Rule{}, fmt.Errorf("FREQ must be DAILY, WEEKLY or MONTHLY, not %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Rule{}, errors.New("INTERVAL must be a positive number")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Rule{}, errors.New("COUNT must be a positive number")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, err := parseWeekday(code)
+				if err != nil {
+					return Rule{}, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "WKST":
+			day, ok := weekdayCodes[value]
+			if !ok {
+				return Rule{}, fmt.Errorf("WKST %q is not a weekday", value)
+			}
+			rule.WeekStart = day
+		default:
+			return Rule{}, fmt.Errorf("%s is not supported", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return Rule{}, errors.New("COUNT and UNTIL cannot both be given")
+	}
+	if rule.Freq != Monthly {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return Rule{}, fmt.Errorf("BYDAY %s needs FREQ=MONTHLY", day)
+			}
+		}
+	}
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405"} {
+		if until, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return until, nil
+		}
+	}
+	if day, err := time.ParseInLocation("20060102", value, time.UTC); err == nil {
+		return day.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL %s is not a date or UTC date-time", value)
+}
+
+func parseWeekday(code string) (Weekday, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < 2 {
+		return Weekday{}, fmt.Errorf("BYDAY %q is not a weekday", code)
+	}
+	day, ok := weekdayCodes[code[len(code)-2:]]
+	if !ok {
+		return Weekday{}, fmt.Errorf("BYDAY %q is not a weekday", code)
+	}
+	n := 0
+	if prefix := code[:len(code)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return Weekday{}, fmt.Errorf("BYDAY %q must be numbered 1 to 5 or -1 to -5", code)
+		}
+	}
+	return Weekday{N: n, Day: day}, nil
+}
+
+// String formats the rule in a canonical order, without the RRULE: prefix.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+Weekday{Day: r.WeekStart}.String())
+	}
+	return strings.Join(parts, ";")
+}
+
+// Bounded reports whether the rule ends on its own.
+func (r Rule) Bounded() bool {
+	return r.Count > 0 || !r.Until.IsZero()
+}
+
+// Expand returns the occurrence starts of the rule beginning at dtstart, in
+// order, up to and including end. A zero end expands a bounded rule to its
+// last occurrence and returns nothing for an unbounded one. COUNT counts from
+// dtstart whatever end is. Occurrences keep dtstart's wall-clock time in
+// dtstart's location; days that do not exist in a month are skipped.
+func (r Rule) Expand(dtstart, end time.Time) []time.Time {
+	if !r.Until.IsZero() && (end.IsZero() || r.Until.Before(end)) {
+		end = r.Until
+	}
+	if end.IsZero() && r.Count == 0 {
+		return nil
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	var starts []time.Time
+	for period := 0; period < maxPeriods; period++ {
+		candidates := r.periodCandidates(dtstart, period*interval)
+		if len(candidates) == 0 {
+			continue
+		}
+		for _, candidate := range candidates {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if !end.IsZero() && candidate.After(end) {
+				return starts
+			}
+			starts = append(starts, candidate)
+			if r.Count > 0 && len(starts) == r.Count {
+				return starts
+			}
+		}
+	}
+	return starts
+}
+
+// periodCandidates lists the matching starts, in order, in the day, week or
+// month that is offset periods after dtstart's.
+func (r Rule) periodCandidates(dtstart time.Time, offset int) []time.Time {
+	hour, min, sec := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, dtstart.Nanosecond(), dtstart.Location())
+	}
+	year, month, day := dtstart.Date()
+
+	switch r.Freq {
+	case Daily:
+		candidate := at(year, month, day+offset)
+		if len(r.ByDay) > 0 && !r.matchesWeekday(candidate.Weekday()) {
+			return nil
+		}
+		return []time.Time{candidate}
+
+	case Weekly:
+		weekStart := day - daysAfter(dtstart.Weekday(), r.WeekStart) + offset*7
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []Weekday{{Day: dtstart.Weekday()}}
+		}
+		var candidates []time.Time
+		for _, weekday := range days {
+			candidates = append(candidates, at(year, month, weekStart+daysAfter(weekday.Day, r.WeekStart)))
+		}
+		sortTimes(candidates)
+		return dedupe(candidates)
+
+	case Monthly:
+		first := time.Date(year, month+time.Month(offset), 1, 0, 0, 0, 0, dtstart.Location())
+		y, m := first.Year(), first.Month()
+		daysInMonth := time.Date(y, m+1, 0, 0, 0, 0, 0, dtstart.Location()).Day()
+		if len(r.ByDay) == 0 {
+			if day > daysInMonth {
+				return nil
+			}
+			return []time.Time{at(y, m, day)}
+		}
+		var candidates []time.Time
+		for d := 1; d <= daysInMonth; d++ {
+			weekday := time.Date(y, m, d, 0, 0, 0, 0, dtstart.Location()).Weekday()
+			nth, nthFromEnd := 1+(d-1)/7, -(1 + (daysInMonth-d)/7)
+			for _, want := range r.ByDay {
+				if want.Day == weekday && (want.N == 0 || want.N == nth || want.N == nthFromEnd) {
+					candidates = append(candidates, at(y, m, d))
+					break
+				}
+			}
+		}
+		return candidates
+	}
+	return nil
+}
+
+// daysAfter is how many days day falls after the week start.
+func daysAfter(day, weekStart time.Weekday) int {
+	return (int(day) - int(weekStart) + 7) % 7
+}
+
+func (r Rule) matchesWeekday(day time.Weekday) bool {
+	for _, want := range r.ByDay {
+		if want.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTimes(times []time.Time) {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+}
+
+func dedupe(times []time.Time) []time.Time {
+	out := times[:0]
+	for i, t := range times {
+		if i == 0 || !t.Equal(times[i-1]) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dates(times []time.Time) []string {
+	out := make([]string, len(times))
+	for i, t := range times {
+		out[i] = t.Format("2006-01-02 Mon 15:04")
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Canonical Form", func(t *testing.T) {
+		rule, err := Parse("RRULE:byday=we,mo;freq=weekly;interval=2;until=20250630")
+		require.NoError(t, err)
+		assert.Equal(t, Weekly, rule.Freq)
+		assert.Equal(t, 2, rule.Interval)
+		assert.Equal(t, time.Date(2025, 6, 30, 23, 59, 59, 0, time.UTC), rule.Until)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;UNTIL=20250630T235959Z;BYDAY=WE,MO", rule.String())
+		assert.True(t, rule.Bounded())
+	})
+
+	t.Run("Rejected Rules", func(t *testing.T) {
+		for _, s := range []string{
+			"",
+			"INTERVAL=2",
+			"FREQ=YEARLY",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;COUNT=3;UNTIL=20250101",
+			"FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=MONTHLY;BYDAY=6MO",
+			"FREQ=MONTHLY;BYDAY=XX",
+			"FREQ=DAILY;BYMONTH=1",
+			"FREQ=DAILY;FREQ=WEEKLY",
+		} {
+			_, err := Parse(s)
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestExpand(t *testing.T) {
+	// Monday 2 June 2025, 09:00 UTC.
+	dtstart := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+
+	t.Run("Daily With Count", func(t *testing.T) {
+		rule, _ := Parse("FREQ=DAILY;INTERVAL=3;COUNT=3")
+		assert.Equal(t, []string{"2025-06-02 Mon 09:00", "2025-06-05 Thu 09:00", "2025-06-08 Sun 09:00"}, dates(rule.Expand(dtstart, time.Time{})))
+	})
+
+	t.Run("Daily Filtered By Weekday", func(t *testing.T) {
+		rule, _ := Parse("FREQ=DAILY;BYDAY=SA,SU;COUNT=3")
+		assert.Equal(t, []string{"2025-06-07 Sat 09:00", "2025-06-08 Sun 09:00", "2025-06-14 Sat 09:00"}, dates(rule.Expand(dtstart, time.Time{})))
+	})
+
+	t.Run("Weekly By Day Skips Days Before Start", func(t *testing.T) {
+		start := dtstart.AddDate(0, 0, 2) // Wednesday
+		rule, _ := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=4")
+		assert.Equal(t, []string{"2025-06-06 Fri 09:00", "2025-06-16 Mon 09:00", "2025-06-20 Fri 09:00", "2025-06-30 Mon 09:00"}, dates(rule.Expand(start, time.Time{})))
+	})
+
+	t.Run("Week Start Changes Which Weeks Count", func(t *testing.T) {
+		sunday := dtstart.AddDate(0, 0, -1)
+		monday, _ := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,MO;COUNT=3")
+		fromSunday, _ := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,MO;WKST=SU;COUNT=3")
+		assert.Equal(t, []string{"2025-06-01 Sun 09:00", "2025-06-09 Mon 09:00", "2025-06-15 Sun 09:00"}, dates(monday.Expand(sunday, time.Time{})))
+		assert.Equal(t, []string{"2025-06-01 Sun 09:00", "2025-06-02 Mon 09:00", "2025-06-15 Sun 09:00"}, dates(fromSunday.Expand(sunday, time.Time{})))
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;COUNT=3;BYDAY=SU,MO;WKST=SU", fromSunday.String())
+	})
+
+	t.Run("Weekly Until Is Inclusive", func(t *testing.T) {
+		rule, _ := Parse("FREQ=WEEKLY;UNTIL=20250616T090000Z")
+		assert.Equal(t, []string{"2025-06-02 Mon 09:00", "2025-06-09 Mon 09:00", "2025-06-16 Mon 09:00"}, dates(rule.Expand(dtstart, time.Time{})))
+	})
+
+	t.Run("Monthly Skips Short Months", func(t *testing.T) {
+		start := time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC)
+		rule, _ := Parse("FREQ=MONTHLY;COUNT=3")
+		assert.Equal(t, []string{"2025-01-31 Fri 09:00", "2025-03-31 Mon 09:00", "2025-05-31 Sat 09:00"}, dates(rule.Expand(start, time.Time{})))
+	})
+
+	t.Run("Monthly Nth Weekday", func(t *testing.T) {
+		rule, _ := Parse("FREQ=MONTHLY;BYDAY=1MO,-1FR;COUNT=4")
+		assert.Equal(t, []string{"2025-06-02 Mon 09:00", "2025-06-27 Fri 09:00", "2025-07-07 Mon 09:00", "2025-07-25 Fri 09:00"}, dates(rule.Expand(dtstart, time.Time{})))
+	})
+
+	t.Run("Unbounded Needs An End", func(t *testing.T) {
+		rule, _ := Parse("FREQ=WEEKLY")
+		assert.False(t, rule.Bounded())
+		assert.Empty(t, rule.Expand(dtstart, time.Time{}))
+		assert.Len(t, rule.Expand(dtstart, dtstart.AddDate(0, 0, 28)), 5)
+	})
+
+	t.Run("Count Counts From Start", func(t *testing.T) {
+		rule, _ := Parse("FREQ=DAILY;COUNT=10")
+		assert.Len(t, rule.Expand(dtstart, dtstart.AddDate(0, 0, 2)), 3)
+		assert.Len(t, rule.Expand(dtstart, dtstart.AddDate(1, 0, 0)), 10)
+	})
+
+	t.Run("Keeps Wall Clock Across DST", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			t.Skip("time zone data not available")
+		}
+		start := time.Date(2025, 3, 28, 9, 0, 0, 0, berlin)
+		rule, _ := Parse("FREQ=DAILY;COUNT=4")
+		starts := rule.Expand(start, time.Time{})
+		assert.Equal(t, 9, starts[3].Hour())
+		assert.Equal(t, 23*time.Hour, starts[2].Sub(starts[1]))
+	})
+}
@@ -1,37 +1,71 @@
 package pkg
 
 import (
+	"errors"
 	"time"
 
 	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Claims are shared by access and refresh tokens. SessionID ties both tokens
+// to the sign-in they were issued for, and the registered ID (jti) lets a
+// single token be revoked before it expires. Role is only set on access
+// tokens, so a role change takes effect at the next refresh. Purpose marks
+// tokens that are only good for one step, such as finishing a two-factor
+// sign-in; access tokens never carry one.
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role,omitempty"`
+	SessionID uint   `json:"sid,omitempty"`
+	Purpose   string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID uint, email string, cfg *config.Config) (string, error) {
+const PurposeMFAPending = "mfa_pending"
+
+var ErrWrongTokenPurpose = errors.New("token issued for a different purpose")
+
+func GenerateAccessToken(userID uint, email string, role string, sessionID uint, cfg *config.Config) (string, *Claims, error) {
+	tokenID, err := NewTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.ACCESS_TOKEN_SECRET))
+	signed, err := token.SignedString([]byte(cfg.ACCESS_TOKEN_SECRET))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return signed, &claims, nil
 }
 
-func GenerateRefreshToken(userID uint, email string, cfg *config.Config) (string, error) {
+func GenerateRefreshToken(userID uint, email string, sessionID uint, cfg *config.Config) (string, error) {
+	tokenID, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -41,7 +75,30 @@ func GenerateRefreshToken(userID uint, email string, cfg *config.Config) (string
 	return token.SignedString([]byte(cfg.REFRESH_TOKEN_SECRET))
 }
 
-func ValidateAccessToken(tokenString string, cfg *config.Config) (*Claims, error) {
+// GenerateMFAToken issues the short-lived token a user holds between
+// entering their password and entering their second factor.
+func GenerateMFAToken(userID uint, email string, ttl time.Duration, cfg *config.Config) (string, error) {
+	tokenID, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		UserID:  userID,
+		Email:   email,
+		Purpose: PurposeMFAPending,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(cfg.ACCESS_TOKEN_SECRET))
+}
+
+func parseAccessSecretToken(tokenString string, cfg *config.Config) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(cfg.ACCESS_TOKEN_SECRET), nil
 	})
@@ -57,6 +114,33 @@ func ValidateAccessToken(tokenString string, cfg *config.Config) (*Claims, error
 	return nil, jwt.ErrSignatureInvalid
 }
 
+func ValidateAccessToken(tokenString string, cfg *config.Config) (*Claims, error) {
+	claims, err := parseAccessSecretToken(tokenString, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// MFA tokens share the signing key but must not grant API access.
+	if claims.Purpose != "" {
+		return nil, ErrWrongTokenPurpose
+	}
+
+	return claims, nil
+}
+
+func ValidateMFAToken(tokenString string, cfg *config.Config) (*Claims, error) {
+	claims, err := parseAccessSecretToken(tokenString, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != PurposeMFAPending {
+		return nil, ErrWrongTokenPurpose
+	}
+
+	return claims, nil
+}
+
 func ValidateRefreshToken(tokenString string, cfg *config.Config) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(cfg.REFRESH_TOKEN_SECRET), nil
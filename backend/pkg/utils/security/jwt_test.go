@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amarjeet-choudhary666/slotSwapper/internals/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	cfg := &config.Config{ACCESS_TOKEN_SECRET: "access", REFRESH_TOKEN_SECRET: "refresh"}
+
+	first, err := GenerateRefreshToken(1, "a@example.com", 7, cfg)
+	assert.NoError(t, err)
+	second, err := GenerateRefreshToken(1, "a@example.com", 7, cfg)
+	assert.NoError(t, err)
+
+	// Tokens minted within the same second must still differ, otherwise a
+	// rotated token would be indistinguishable from its predecessor.
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, HashToken(first), HashToken(second))
+
+	claims, err := ValidateRefreshToken(second, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.SessionID)
+	assert.NotEmpty(t, claims.ID)
+
+	_, err = ValidateAccessToken(second, cfg)
+	assert.Error(t, err)
+}
+
+func TestMFATokenPurpose(t *testing.T) {
+	cfg := &config.Config{ACCESS_TOKEN_SECRET: "access", REFRESH_TOKEN_SECRET: "refresh"}
+
+	mfaToken, err := GenerateMFAToken(1, "a@example.com", 5*time.Minute, cfg)
+	assert.NoError(t, err)
+
+	claims, err := ValidateMFAToken(mfaToken, cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), claims.UserID)
+
+	// An MFA token must not work as an access token, nor the reverse.
+	_, err = ValidateAccessToken(mfaToken, cfg)
+	assert.ErrorIs(t, err, ErrWrongTokenPurpose)
+
+	accessToken, _, err := GenerateAccessToken(1, "a@example.com", "USER", 7, cfg)
+	assert.NoError(t, err)
+	_, err = ValidateMFAToken(accessToken, cfg)
+	assert.ErrorIs(t, err, ErrWrongTokenPurpose)
+}
@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// NewTokenID returns a random 128-bit identifier, hex encoded, for use as a
+// JWT ID or token family.
+func NewTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashToken returns the SHA-256 hex digest of a token so that tokens can be
+// stored and compared without keeping the raw value.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix marks personal API keys so they can be told apart from JWTs in
+// an Authorization header.
+const APIKeyPrefix = "ssk_"
+
+// apiKeyDisplayLength is how much of a key is kept in clear so users can
+// recognise it in a list.
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// NewAPIKey returns a random API key and the short prefix that is shown for
+// it. Only the prefix and HashToken of the key should be stored.
+func NewAPIKey() (string, string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key := APIKeyPrefix + hex.EncodeToString(buf)
+	return key, key[:apiKeyDisplayLength], nil
+}
+
+// IsAPIKey reports whether a credential looks like a personal API key.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 appendix B test secret, truncated to six digits.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	t.Run("RFC 6238 Vectors", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:         "287082",
+			1111111109: "081804",
+			1111111111: "050471",
+			1234567890: "005924",
+			2000000000: "279037",
+		}
+		for unix, want := range vectors {
+			code, err := GenerateTOTPCode(secret, time.Unix(unix, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, want, code, "at %d", unix)
+		}
+	})
+
+	t.Run("Validate Allows One Step Of Drift", func(t *testing.T) {
+		now := time.Unix(1111111111, 0)
+		previous, _ := GenerateTOTPCode(secret, now.Add(-TOTPPeriod))
+
+		counter, ok := ValidateTOTPCode(secret, previous, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, TOTPCounter(now)-1, counter)
+
+		_, ok = ValidateTOTPCode(secret, previous, now, 0)
+		assert.False(t, ok)
+
+		stale, _ := GenerateTOTPCode(secret, now.Add(-2*TOTPPeriod))
+		_, ok = ValidateTOTPCode(secret, stale, now, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("Rejects Malformed Input", func(t *testing.T) {
+		_, ok := ValidateTOTPCode(secret, "12345", time.Now(), 1)
+		assert.False(t, ok)
+		_, ok = ValidateTOTPCode("not base32!", "123456", time.Now(), 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("URI", func(t *testing.T) {
+		uri := TOTPURI("SlotSwapper", "sam@example.com", "ABC")
+		assert.True(t, strings.HasPrefix(uri, "otpauth://totp/SlotSwapper:sam@example.com?"))
+		assert.Contains(t, uri, "secret=ABC")
+		assert.Contains(t, uri, "issuer=SlotSwapper")
+	})
+
+	t.Run("Recovery Codes", func(t *testing.T) {
+		code, err := NewRecoveryCode()
+		assert.NoError(t, err)
+		assert.Len(t, code, 11)
+		assert.Equal(t, strings.ToLower(code[:5]+code[6:]), NormalizeRecoveryCode(" "+strings.ToUpper(code)+" "))
+	})
+}
@@ -82,7 +82,7 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
   };
 
   const logout = () => {
-    authAPI.logout();
+    void authAPI.logout();
     setToken(null);
     setUser(null);
   };
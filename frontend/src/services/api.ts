@@ -69,9 +69,23 @@ export const authAPI = {
     };
   },
 
-  logout: () => {
-    localStorage.removeItem('token');
-    localStorage.removeItem('refreshToken');
+  logout: async (): Promise<void> => {
+    // Revoke the session server-side before the tokens are cleared. The
+    // header is passed explicitly so the request still carries the token;
+    // local tokens are cleared regardless of the outcome.
+    const token = localStorage.getItem('token');
+    try {
+      if (token) {
+        await api.post('/users/logout', undefined, {
+          headers: { Authorization: `Bearer ${token}` },
+        });
+      }
+    } catch {
+      // The session expires on its own if it cannot be revoked now.
+    } finally {
+      localStorage.removeItem('token');
+      localStorage.removeItem('refreshToken');
+    }
   },
 
   getCurrentUser: async (): Promise<User> => {
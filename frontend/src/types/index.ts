@@ -11,6 +11,7 @@ export interface Event {
   title: string;
   startTime: string;
   endTime: string;
+  allDay: boolean;
   status: 'BUSY' | 'SWAPPABLE' | 'SWAP_PENDING';
   ownerId: number;
   owner?: User;
@@ -54,6 +55,7 @@ export interface CreateEventRequest {
   title: string;
   startTime: string;
   endTime: string;
+  allDay?: boolean;
 }
 
 export interface SwapRequestPayload {